@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v7"
+
+	"github.com/go-board/thor/pkg/interceptors"
+)
+
+type bulkheadMiddleware struct {
+	bulkhead *interceptors.Bulkhead
+	hooks    []redis.Hook
+}
+
+type bulkheadReleaseKey struct{}
+
+// NewBulkheadMiddleware bound concurrent commands and pipelines, rejected ones fail with *interceptors.BulkheadFullError.
+// hooks are run holding the slot, and the slot is released if one of them fails BeforeProcess,
+// for which redis calls no AfterProcess. So hooks added after it must be passed as hooks instead of added to client.
+func NewBulkheadMiddleware(b *interceptors.Bulkhead, hooks ...redis.Hook) redis.Hook {
+	return &bulkheadMiddleware{bulkhead: b, hooks: hooks}
+}
+
+func (b *bulkheadMiddleware) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return b.before(ctx, func(ctx context.Context, h redis.Hook) (context.Context, error) {
+		return h.BeforeProcess(ctx, cmd)
+	})
+}
+
+func (b *bulkheadMiddleware) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return b.after(ctx, func(h redis.Hook) error { return h.AfterProcess(ctx, cmd) })
+}
+
+func (b *bulkheadMiddleware) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return b.before(ctx, func(ctx context.Context, h redis.Hook) (context.Context, error) {
+		return h.BeforeProcessPipeline(ctx, cmds)
+	})
+}
+
+func (b *bulkheadMiddleware) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return b.after(ctx, func(h redis.Hook) error { return h.AfterProcessPipeline(ctx, cmds) })
+}
+
+// before acquire a slot and run hooks in order, the slot is released if any of them fails.
+func (b *bulkheadMiddleware) before(ctx context.Context, f func(ctx context.Context, h redis.Hook) (context.Context, error)) (context.Context, error) {
+	release, err := b.bulkhead.Acquire(ctx)
+	if err != nil {
+		return ctx, err
+	}
+	ctx = context.WithValue(ctx, bulkheadReleaseKey{}, release)
+	for _, h := range b.hooks {
+		if ctx, err = f(ctx, h); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return ctx, nil
+}
+
+// after run hooks in order and release the slot, the first error of hooks is returned.
+func (b *bulkheadMiddleware) after(ctx context.Context, f func(h redis.Hook) error) error {
+	var firstErr error
+	for _, h := range b.hooks {
+		if err := f(h); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if release, ok := ctx.Value(bulkheadReleaseKey{}).(func()); ok {
+		release()
+	}
+	return firstErr
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-redis/redis/v7"
+
+	"github.com/go-board/thor/pkg/interceptors"
+)
+
+type failingHook struct{ err error }
+
+func (h failingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, h.err
+}
+
+func (h failingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error { return nil }
+
+func (h failingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, h.err
+}
+
+func (h failingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error { return nil }
+
+func TestBulkheadMiddlewareRelease(t *testing.T) {
+	b := interceptors.NewBulkhead("redis-test", interceptors.BulkheadMaxConcurrent(1))
+	cmd := redis.NewStatusCmd("ping")
+	rejected := errors.New("rejected by hook")
+
+	// the slot is released when a hook after the bulkhead fails, so the next command gets it.
+	m := NewBulkheadMiddleware(b, failingHook{err: rejected})
+	for i := 0; i < 2; i++ {
+		if _, err := m.BeforeProcess(context.Background(), cmd); err != rejected {
+			t.Fatalf("err = %v, want error of hook", err)
+		}
+	}
+
+	m = NewBulkheadMiddleware(b, failingHook{})
+	ctx, err := m.BeforeProcess(context.Background(), cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var full *interceptors.BulkheadFullError
+	if _, err := m.BeforeProcessPipeline(context.Background(), []redis.Cmder{cmd}); !errors.As(err, &full) {
+		t.Fatalf("err = %v, want bulkhead full", err)
+	}
+	_ = m.AfterProcess(ctx, cmd)
+	if _, err := m.BeforeProcessPipeline(context.Background(), []redis.Cmder{cmd}); err != nil {
+		t.Fatalf("slot not released: %v", err)
+	}
+}
@@ -83,3 +83,10 @@ func status(err error) string {
 	}
 	return "success"
 }
+
+// PingCheck check redis connectivity, it can be used as server.HealthCheckFunc.
+func PingCheck(c *redis.Client) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return c.WithContext(ctx).Ping().Err()
+	}
+}
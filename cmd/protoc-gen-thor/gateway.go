@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	gatewayPackage = protogen.GoImportPath("github.com/go-board/thor/pkg/gateway")
+	webPackage     = protogen.GoImportPath("github.com/go-board/thor/pkg/web")
+	xhttpPackage   = protogen.GoImportPath("github.com/go-board/x-go/xnet/xhttp")
+	httpPackage    = protogen.GoImportPath("net/http")
+)
+
+// binding is a http rule of method.
+type binding struct {
+	method       string
+	template     string
+	body         string
+	responseBody string
+}
+
+// httpBindings return rules declared by `google.api.http` option of method, including additional bindings.
+func httpBindings(m *protogen.Method) ([]*binding, error) {
+	opts, ok := m.Desc.Options().(proto.Message)
+	if !ok {
+		return nil, nil
+	}
+	ext, err := proto.GetExtension(opts, annotations.E_Http)
+	if err != nil {
+		return nil, nil
+	}
+	rule := ext.(*annotations.HttpRule)
+	var bindings []*binding
+	for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+		b := &binding{body: r.GetBody(), responseBody: r.GetResponseBody()}
+		switch p := r.GetPattern().(type) {
+		case *annotations.HttpRule_Get:
+			b.method, b.template = http.MethodGet, p.Get
+		case *annotations.HttpRule_Put:
+			b.method, b.template = http.MethodPut, p.Put
+		case *annotations.HttpRule_Post:
+			b.method, b.template = http.MethodPost, p.Post
+		case *annotations.HttpRule_Delete:
+			b.method, b.template = http.MethodDelete, p.Delete
+		case *annotations.HttpRule_Patch:
+			b.method, b.template = http.MethodPatch, p.Patch
+		case *annotations.HttpRule_Custom:
+			b.method, b.template = p.Custom.GetKind(), p.Custom.GetPath()
+		default:
+			return nil, fmt.Errorf("%s: http rule without pattern", m.Desc.FullName())
+		}
+		if b.body != "" && b.body != "*" && m.Input.Desc.Fields().ByName(protoreflect.Name(b.body)) == nil {
+			return nil, fmt.Errorf("%s: body field %q not found", m.Desc.FullName(), b.body)
+		}
+		if b.responseBody != "" && m.Output.Desc.Fields().ByName(protoreflect.Name(b.responseBody)) == nil {
+			return nil, fmt.Errorf("%s: response_body field %q not found", m.Desc.FullName(), b.responseBody)
+		}
+		bindings = append(bindings, b)
+	}
+	return bindings, nil
+}
+
+// routerPath convert path template to httprouter path, `{x}` and `{x=*}` are converted to `:x`,
+// trailing `{x=**}` is converted to `*x`, other patterns and verbs aren't supported by httprouter.
+func routerPath(m *protogen.Method, template string) (string, error) {
+	if !strings.HasPrefix(template, "/") {
+		return "", fmt.Errorf("%s: path %q must start with /", m.Desc.FullName(), template)
+	}
+	segments := strings.Split(template[1:], "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") {
+			if strings.ContainsAny(segment, "{}*:") {
+				return "", fmt.Errorf("%s: path %q isn't supported", m.Desc.FullName(), template)
+			}
+			continue
+		}
+		if !strings.HasSuffix(segment, "}") {
+			return "", fmt.Errorf("%s: path %q isn't supported", m.Desc.FullName(), template)
+		}
+		name, pattern := strings.TrimSuffix(segment[1:], "}"), "*"
+		if j := strings.Index(name, "="); j >= 0 {
+			name, pattern = name[:j], name[j+1:]
+		}
+		if err := checkFieldPath(m, name); err != nil {
+			return "", err
+		}
+		switch {
+		case pattern == "*":
+			segments[i] = ":" + name
+		case pattern == "**" && i == len(segments)-1:
+			segments[i] = "*" + name
+		default:
+			return "", fmt.Errorf("%s: path %q isn't supported", m.Desc.FullName(), template)
+		}
+	}
+	return "/" + strings.Join(segments, "/"), nil
+}
+
+// checkFieldPath check dotted field path refers to a singular field of input message.
+func checkFieldPath(m *protogen.Method, path string) error {
+	md := m.Input.Desc
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil || fd.IsList() || fd.IsMap() || (i < len(names)-1 && fd.Message() == nil) {
+			return fmt.Errorf("%s: path param %q isn't a singular field of %s", m.Desc.FullName(), path, md.FullName())
+		}
+		md = fd.Message()
+	}
+	return nil
+}
+
+// route is a http binding of unary method converted to httprouter path.
+type route struct {
+	method  *protogen.Method
+	binding *binding
+	path    string
+}
+
+// httpRoutes return routes of unary methods of s with `google.api.http` option.
+func httpRoutes(s *protogen.Service) ([]*route, error) {
+	var routes []*route
+	for _, m := range s.Methods {
+		if m.Desc.IsStreamingClient() || m.Desc.IsStreamingServer() {
+			continue
+		}
+		bindings, err := httpBindings(m)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range bindings {
+			path, err := routerPath(m, b.template)
+			if err != nil {
+				return nil, err
+			}
+			routes = append(routes, &route{method: m, binding: b, path: path})
+		}
+	}
+	return routes, nil
+}
+
+// generateGateway generate functions registering HTTP handlers of each service onto web.Router,
+// only unary methods with `google.api.http` option are registered.
+func generateGateway(gen *protogen.Plugin, f *protogen.File) error {
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+"_gateway.pb.go", f.GoImportPath)
+	header(g, f)
+	generated := false
+	for _, s := range f.Services {
+		routes, err := httpRoutes(s)
+		if err != nil {
+			return err
+		}
+		if len(routes) == 0 {
+			continue
+		}
+		generated = true
+		g.P("// Register", s.GoName, "HTTPHandler register HTTP handlers of ", s.Desc.FullName(), " declared by `google.api.http` onto r,")
+		g.P("// requests are forwarded to c.")
+		g.P("func Register", s.GoName, "HTTPHandler(r ", webPackage.Ident("Router"), ", c ", s.GoName, "Client, middlewares ...", xhttpPackage.Ident("Middleware"), ") {")
+		for _, rt := range routes {
+			g.P("r.Handle(", strconv.Quote(rt.binding.method), ", ", strconv.Quote(rt.path), ", ", webPackage.Ident("HandlerFunc"), "(func(w ", httpPackage.Ident("ResponseWriter"), ", req *", httpPackage.Ident("Request"), ") error {")
+			g.P("in := &", rt.method.Input.GoIdent, "{}")
+			g.P("if err := ", gatewayPackage.Ident("Bind"), "(req, in, ", strconv.Quote(rt.binding.body), "); err != nil {")
+			g.P("return err")
+			g.P("}")
+			g.P("out, err := c.", rt.method.GoName, "(", gatewayPackage.Ident("OutgoingContext"), "(req), in)")
+			g.P("if err != nil {")
+			g.P("return err")
+			g.P("}")
+			g.P("return ", gatewayPackage.Ident("WriteResponse"), "(w, out, ", strconv.Quote(rt.binding.responseBody), ")")
+			g.P("}), middlewares...)")
+		}
+		g.P("}")
+		g.P()
+	}
+	if !generated {
+		g.Skip()
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/go-board/thor/pkg/pbjson"
+	thor_proto "github.com/go-board/thor/proto"
+)
+
+const pbjsonPackage = protogen.GoImportPath("github.com/go-board/thor/pkg/pbjson")
+
+// generateJSON generate json.Marshaler and json.Unmarshaler of each message by pkg/pbjson,
+// so that `json_tag`, `json_tag_mode` and `default_value` options are honored by encoding/json.
+func generateJSON(gen *protogen.Plugin, f *protogen.File) error {
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+"_json.pb.go", f.GoImportPath)
+	header(g, f)
+	var generate func(messages []*protogen.Message) error
+	generate = func(messages []*protogen.Message) error {
+		for _, m := range messages {
+			if m.Desc.IsMapEntry() {
+				continue
+			}
+			for _, field := range m.Fields {
+				if err := checkDefaultValue(field.Desc); err != nil {
+					return err
+				}
+			}
+			g.P("// MarshalJSON marshal x with field names declared by `json_tag` and `json_tag_mode` options.")
+			g.P("func (x *", m.GoIdent, ") MarshalJSON() ([]byte, error) {")
+			g.P("return ", pbjsonPackage.Ident("Marshal"), "(x)")
+			g.P("}")
+			g.P()
+			g.P("// UnmarshalJSON unmarshal b into x, fields missing in b are set to `default_value` option.")
+			g.P("func (x *", m.GoIdent, ") UnmarshalJSON(b []byte) error {")
+			g.P("return ", pbjsonPackage.Ident("UnmarshalOptions"), "{DiscardUnknown: true}.Unmarshal(b, x)")
+			g.P("}")
+			g.P()
+			if err := generate(m.Messages); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return generate(f.Messages)
+}
+
+// checkDefaultValue check `default_value` option of fd is set only to singular scalar field out of oneof
+// and parses as value of fd, otherwise it's ignored silently at runtime.
+func checkDefaultValue(fd protoreflect.FieldDescriptor) error {
+	opts, ok := fd.Options().(proto.Message)
+	if !ok || !proto.HasExtension(opts, thor_proto.E_DefaultValue) {
+		return nil
+	}
+	ext, err := proto.GetExtension(opts, thor_proto.E_DefaultValue)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fd.FullName(), err)
+	}
+	if fd.IsList() || fd.IsMap() || fd.Message() != nil || fd.ContainingOneof() != nil {
+		return fmt.Errorf("%s: default_value is only supported by singular scalar fields out of oneof", fd.FullName())
+	}
+	s := *ext.(*string)
+	if fd.Kind() == protoreflect.BytesKind {
+		return nil
+	}
+	if _, err := pbjson.ParseValue(fd, s); err != nil {
+		return fmt.Errorf("%s: invalid default_value %q", fd.FullName(), s)
+	}
+	return nil
+}
@@ -1,4 +1,62 @@
+// protoc-gen-thor generate thor code from proto files:
+//   - json.Marshaler and json.Unmarshaler of messages honoring `json_tag`, `json_tag_mode` and `default_value` options,
+//     into `<name>_json.pb.go`.
+//   - method metadata of services, client resolving service by name with pkg/client and registration onto server.Server,
+//     into `<name>_thor.pb.go`.
+//   - mocks of client and server interfaces for tests, into `<name>_mock.pb.go`.
+//   - method table of resilience policies declared by `policy` method option, into `<name>_policy.pb.go`.
+//   - HTTP handlers registered onto web.Router from `google.api.http` method option, into `<name>_gateway.pb.go`,
+//     path params, query params and body are bound into request, and fields are named by `json_tag` and `json_tag_mode`.
+//   - OpenAPI 3 document of HTTP routes with constraints of `rules` field option, into `<name>.openapi.json`,
+//     and registered to pkg/openapi by `<name>_openapi.pb.go` to be served by web.Server.Docs.
 package main
 
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
 func main() {
+	protogen.Options{}.Run(generate)
+}
+
+// generate all files of proto files to generate.
+func generate(gen *protogen.Plugin) error {
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		if len(f.Messages) > 0 {
+			if err := generateJSON(gen, f); err != nil {
+				return err
+			}
+		}
+		if len(f.Services) == 0 {
+			continue
+		}
+		if err := generateService(gen, f); err != nil {
+			return err
+		}
+		if err := generateMock(gen, f); err != nil {
+			return err
+		}
+		if err := generatePolicy(gen, f); err != nil {
+			return err
+		}
+		if err := generateGateway(gen, f); err != nil {
+			return err
+		}
+		if err := generateOpenAPI(gen, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// header write the generated code header and package clause.
+func header(g *protogen.GeneratedFile, f *protogen.File) {
+	g.P("// Code generated by protoc-gen-thor. DO NOT EDIT.")
+	g.P("// source: ", f.Desc.Path())
+	g.P()
+	g.P("package ", f.GoPackageName)
+	g.P()
 }
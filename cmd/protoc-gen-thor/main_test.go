@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	thor_proto "github.com/go-board/thor/proto"
+)
+
+// example.protoset is example.proto compiled with source info, imports are resolved from registered files.
+//go:generate protoc -I testdata -I ../../proto -I $GOOGLEAPIS --include_source_info --descriptor_set_out=testdata/example.protoset testdata/example.proto
+
+// update rewrite golden files by generated ones, run `go test -update` after changing generators.
+var update = flag.Bool("update", false, "update golden files of testdata/golden")
+
+// request build a code generator request of files in testdata/example.protoset.
+func request(t *testing.T) *pluginpb.CodeGeneratorRequest {
+	b, err := ioutil.ReadFile("testdata/example.protoset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, set); err != nil {
+		t.Fatal(err)
+	}
+	req := &pluginpb.CodeGeneratorRequest{Parameter: proto.String("paths=source_relative")}
+	seen := make(map[string]bool)
+	var addDependency func(path string)
+	addDependency = func(path string) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		fd, err := protoregistry.GlobalFiles.FindFileByPath(path)
+		if err != nil {
+			t.Fatalf("dependency %s: %v", path, err)
+		}
+		for i := 0; i < fd.Imports().Len(); i++ {
+			addDependency(fd.Imports().Get(i).Path())
+		}
+		req.ProtoFile = append(req.ProtoFile, protodesc.ToFileDescriptorProto(fd))
+	}
+	for _, f := range set.File {
+		for _, dependency := range f.Dependency {
+			addDependency(dependency)
+		}
+		req.ProtoFile = append(req.ProtoFile, f)
+		req.FileToGenerate = append(req.FileToGenerate, f.GetName())
+	}
+	return req
+}
+
+func TestGenerate(t *testing.T) {
+	gen, err := protogen.Options{}.New(request(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generate(gen); err != nil {
+		t.Fatal(err)
+	}
+	resp := gen.Response()
+	if resp.Error != nil {
+		t.Fatal(resp.GetError())
+	}
+
+	goldens, err := filepath.Glob("testdata/golden/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated := make(map[string]bool)
+	for _, f := range resp.File {
+		path := filepath.Join("testdata/golden", f.GetName())
+		generated[path] = true
+		if *update {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(path, []byte(f.GetContent()), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Errorf("golden of %s: %v", f.GetName(), err)
+			continue
+		}
+		if line, ok := diff(want, []byte(f.GetContent())); !ok {
+			t.Errorf("%s differs from golden at line %d, run `go test -update` if it's expected", f.GetName(), line)
+		}
+	}
+	for _, path := range goldens {
+		if !generated[path] && !*update {
+			t.Errorf("golden %s isn't generated", path)
+		}
+	}
+}
+
+// diff return the first line differs between want and got.
+func diff(want, got []byte) (int, bool) {
+	if bytes.Equal(want, got) {
+		return 0, true
+	}
+	wantLines, gotLines := strings.Split(string(want), "\n"), strings.Split(string(got), "\n")
+	for i := range wantLines {
+		if i >= len(gotLines) || wantLines[i] != gotLines[i] {
+			return i + 1, false
+		}
+	}
+	return len(wantLines) + 1, false
+}
+
+func TestGenerateInvalidDefaultValue(t *testing.T) {
+	for value, want := range map[string]string{
+		"ten": `example.ListItemsRequest.page_size: invalid default_value "ten"`,
+		"":    `example.ListItemsRequest.page_size: invalid default_value ""`,
+	} {
+		req := request(t)
+		for _, f := range req.ProtoFile {
+			for _, m := range f.MessageType {
+				for _, field := range m.Field {
+					if m.GetName() == "ListItemsRequest" && field.GetName() == "page_size" {
+						proto.SetExtension(field.Options, thor_proto.E_DefaultValue, value)
+					}
+				}
+			}
+		}
+		gen, err := protogen.Options{}.New(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := generate(gen); err == nil || err.Error() != want {
+			t.Errorf("generate with default_value %q = %v, want %s", value, err, want)
+		}
+	}
+}
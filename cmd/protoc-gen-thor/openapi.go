@@ -0,0 +1,330 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/go-board/thor/pkg/openapi"
+	"github.com/go-board/thor/pkg/pbjson"
+	thor_proto "github.com/go-board/thor/proto"
+)
+
+const (
+	openapiPackage = protogen.GoImportPath("github.com/go-board/thor/pkg/openapi")
+	// maxQueryDepth bound nested messages flattened into query params.
+	maxQueryDepth = 3
+)
+
+// wellKnownSchemas is schemas of well-known types by their JSON mapping.
+var wellKnownSchemas = map[protoreflect.FullName]openapi.Schema{
+	"google.protobuf.Timestamp":   {Type: "string", Format: "date-time"},
+	"google.protobuf.Duration":    {Type: "string", Description: "duration in seconds with suffix s, like 1.5s."},
+	"google.protobuf.FieldMask":   {Type: "string", Description: "comma separated field paths."},
+	"google.protobuf.Struct":      {Type: "object"},
+	"google.protobuf.Value":       {},
+	"google.protobuf.ListValue":   {Type: "array", Items: &openapi.Schema{}},
+	"google.protobuf.Empty":       {Type: "object"},
+	"google.protobuf.Any":         {Type: "object"},
+	"google.protobuf.DoubleValue": {Type: "number", Format: "double"},
+	"google.protobuf.FloatValue":  {Type: "number", Format: "float"},
+	"google.protobuf.Int64Value":  {Type: "string", Format: "int64"},
+	"google.protobuf.UInt64Value": {Type: "string", Format: "uint64"},
+	"google.protobuf.Int32Value":  {Type: "integer", Format: "int32"},
+	"google.protobuf.UInt32Value": {Type: "integer", Format: "int64"},
+	"google.protobuf.BoolValue":   {Type: "boolean"},
+	"google.protobuf.StringValue": {Type: "string"},
+	"google.protobuf.BytesValue":  {Type: "string", Format: "byte"},
+}
+
+// generateOpenAPI generate OpenAPI document of routes declared by `google.api.http` options into `<name>.openapi.json`,
+// and register it by init of `<name>_openapi.pb.go` so that web.Server can serve it.
+func generateOpenAPI(gen *protogen.Plugin, f *protogen.File) error {
+	doc := openapi.New(f.Desc.Path(), "1.0")
+	documented := false
+	for _, s := range f.Services {
+		routes, err := httpRoutes(s)
+		if err != nil {
+			return err
+		}
+		// additional bindings of a method are suffixed by their index.
+		bindings := make(map[*protogen.Method]int)
+		for _, rt := range routes {
+			op := operation(doc, s, rt)
+			if n := bindings[rt.method]; n > 0 {
+				op.OperationID += "_" + strconv.Itoa(n)
+			}
+			bindings[rt.method]++
+			doc.AddOperation(rt.binding.method, openapi.RouterPath(rt.path), op)
+			documented = true
+		}
+	}
+	if !documented {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+".openapi.json", f.GoImportPath)
+	_, _ = g.Write(append(b, '\n'))
+
+	if b, err = json.Marshal(doc); err != nil {
+		return err
+	}
+	g = gen.NewGeneratedFile(f.GeneratedFilenamePrefix+"_openapi.pb.go", f.GoImportPath)
+	header(g, f)
+	g.P("func init() {")
+	g.P(openapiPackage.Ident("Register"), "(", strconv.Quote(f.Desc.Path()), ", []byte(", strconv.Quote(string(b)), "))")
+	g.P("}")
+	return nil
+}
+
+func operation(doc *openapi.Document, s *protogen.Service, rt *route) *openapi.Operation {
+	m := rt.method
+	comments := comment(m.Comments.Leading)
+	op := &openapi.Operation{
+		OperationID: s.GoName + "_" + m.GoName,
+		Summary:     strings.SplitN(comments, "\n", 2)[0],
+		Description: comments,
+		Tags:        []string{string(s.Desc.FullName())},
+		Responses: map[string]*openapi.Response{
+			"default": {Description: "error.", Content: openapi.JSONContent(openapi.RefOf(openapi.ErrorSchema))},
+		},
+	}
+	if op.Description == op.Summary {
+		op.Description = ""
+	}
+
+	exclude := make(map[string]bool)
+	for _, segment := range strings.Split(rt.path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			exclude[name] = true
+			param := &openapi.Parameter{Name: name, In: "path", Required: true}
+			if field := fieldByPath(m.Input, name); field != nil {
+				param.Schema = fieldSchema(doc, field)
+				param.Description = comment(field.Comments.Leading)
+			}
+			op.Parameters = append(op.Parameters, param)
+		}
+	}
+
+	switch body := rt.binding.body; body {
+	case "":
+		queryParams(doc, m.Input, "", "", exclude, 0, &op.Parameters)
+	case "*":
+		op.RequestBody = &openapi.RequestBody{Required: true, Content: openapi.JSONContent(messageSchema(doc, m.Input))}
+	default:
+		exclude[body] = true
+		queryParams(doc, m.Input, "", "", exclude, 0, &op.Parameters)
+		op.RequestBody = &openapi.RequestBody{Required: true, Content: openapi.JSONContent(fieldSchema(doc, fieldByPath(m.Input, body)))}
+	}
+
+	var response *openapi.Schema
+	if rt.binding.responseBody != "" {
+		response = fieldSchema(doc, fieldByPath(m.Output, rt.binding.responseBody))
+	} else {
+		response = messageSchema(doc, m.Output)
+	}
+	op.Responses[strconv.Itoa(http.StatusOK)] = &openapi.Response{Description: "OK", Content: openapi.JSONContent(response)}
+	return op
+}
+
+// fieldByPath return field at dotted path of proto names, nil if not found.
+func fieldByPath(m *protogen.Message, path string) *protogen.Field {
+	var field *protogen.Field
+	for _, name := range strings.Split(path, ".") {
+		if m == nil {
+			return nil
+		}
+		field = nil
+		for _, f := range m.Fields {
+			if string(f.Desc.Name()) == name {
+				field = f
+				break
+			}
+		}
+		if field == nil {
+			return nil
+		}
+		m = field.Message
+	}
+	return field
+}
+
+// queryParams flatten fields of m not excluded into query params named by dotted JSON names,
+// excluded fields are named by dotted proto names, maps and repeated messages can't be bound from query.
+func queryParams(doc *openapi.Document, m *protogen.Message, prefix string, protoPrefix string, exclude map[string]bool, depth int, params *[]*openapi.Parameter) {
+	for _, f := range m.Fields {
+		name, protoName := prefix+pbjson.FieldName(f.Desc), protoPrefix+string(f.Desc.Name())
+		if exclude[protoName] || f.Desc.IsMap() {
+			continue
+		}
+		if f.Message != nil && !isWellKnownMessage(f.Message) {
+			if !f.Desc.IsList() && depth < maxQueryDepth {
+				queryParams(doc, f.Message, name+".", protoName+".", exclude, depth+1, params)
+			}
+			continue
+		}
+		*params = append(*params, &openapi.Parameter{
+			Name:        name,
+			In:          "query",
+			Description: comment(f.Comments.Leading),
+			Schema:      fieldSchema(doc, f),
+		})
+	}
+}
+
+func isWellKnownMessage(m *protogen.Message) bool {
+	_, ok := wellKnownSchemas[m.Desc.FullName()]
+	return ok
+}
+
+// messageSchema return schema of m, which refers to component schema unless m is a well-known type.
+func messageSchema(doc *openapi.Document, m *protogen.Message) *openapi.Schema {
+	if schema, ok := wellKnownSchemas[m.Desc.FullName()]; ok {
+		return &schema
+	}
+	name := string(m.Desc.FullName())
+	if _, ok := doc.Components.Schemas[name]; ok {
+		return openapi.RefOf(name)
+	}
+	schema := &openapi.Schema{
+		Type:        "object",
+		Description: comment(m.Comments.Leading),
+		Properties:  make(map[string]*openapi.Schema),
+	}
+	// registered before fields so that recursive messages refer to it.
+	doc.Components.Schemas[name] = schema
+	for _, f := range m.Fields {
+		jsonName := pbjson.FieldName(f.Desc)
+		schema.Properties[jsonName] = fieldSchema(doc, f)
+		if rules := fieldRules(f); rules.GetRequired() {
+			schema.Required = append(schema.Required, jsonName)
+		}
+	}
+	return openapi.RefOf(name)
+}
+
+// fieldSchema return schema of field with constraints of `rules`, `default_value` and leading comments.
+func fieldSchema(doc *openapi.Document, f *protogen.Field) *openapi.Schema {
+	if f == nil {
+		return &openapi.Schema{}
+	}
+	rules := fieldRules(f)
+	switch {
+	case f.Desc.IsMap():
+		schema := &openapi.Schema{Type: "object", AdditionalProperties: valueSchema(doc, f.Message.Fields[1], rules)}
+		schema.MinProperties, schema.MaxProperties = rules.MinLen, rules.MaxLen
+		return describe(schema, f)
+	case f.Desc.IsList():
+		schema := &openapi.Schema{Type: "array", Items: valueSchema(doc, f, rules)}
+		schema.MinItems, schema.MaxItems = rules.MinLen, rules.MaxLen
+		return describe(schema, f)
+	}
+	schema := valueSchema(doc, f, rules)
+	if schema.Ref != "" {
+		return schema
+	}
+	if f.Desc.Kind() == protoreflect.StringKind {
+		schema.MinLength, schema.MaxLength = rules.MinLen, rules.MaxLen
+	}
+	if opts, ok := f.Desc.Options().(proto.Message); ok {
+		if ext, err := proto.GetExtension(opts, thor_proto.E_DefaultValue); err == nil {
+			schema.Default = jsonValue(f.Desc, *ext.(*string))
+		}
+	}
+	return describe(schema, f)
+}
+
+// valueSchema return schema of a singular value of field, rules of value like min and pattern are applied.
+func valueSchema(doc *openapi.Document, f *protogen.Field, rules *thor_proto.FieldRules) *openapi.Schema {
+	fd := f.Desc
+	if f.Message != nil {
+		return messageSchema(doc, f.Message)
+	}
+	schema := &openapi.Schema{}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		schema.Type = "boolean"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		schema.Type, schema.Format = "integer", "int32"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		schema.Type, schema.Format = "integer", "int64"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		schema.Type, schema.Format = "string", "int64"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		schema.Type, schema.Format = "string", "uint64"
+	case protoreflect.FloatKind:
+		schema.Type, schema.Format = "number", "float"
+	case protoreflect.DoubleKind:
+		schema.Type, schema.Format = "number", "double"
+	case protoreflect.StringKind:
+		schema.Type = "string"
+		schema.Pattern = rules.GetPattern()
+		if rules.GetEmail() {
+			schema.Format = "email"
+		}
+	case protoreflect.BytesKind:
+		schema.Type, schema.Format = "string", "byte"
+	case protoreflect.EnumKind:
+		schema.Type = "string"
+		for _, v := range f.Enum.Values {
+			schema.Enum = append(schema.Enum, string(v.Desc.Name()))
+		}
+	}
+	schema.Minimum, schema.Maximum = rules.Min, rules.Max
+	if len(rules.GetIn()) > 0 {
+		schema.Enum = nil
+		for _, s := range rules.GetIn() {
+			schema.Enum = append(schema.Enum, jsonValue(fd, s))
+		}
+	}
+	return schema
+}
+
+// comment return text of proto comments, leading space of each line is trimmed.
+func comment(c protogen.Comments) string {
+	lines := strings.Split(strings.TrimSpace(string(c)), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func describe(schema *openapi.Schema, f *protogen.Field) *openapi.Schema {
+	schema.Description = comment(f.Comments.Leading)
+	return schema
+}
+
+func fieldRules(f *protogen.Field) *thor_proto.FieldRules {
+	if opts, ok := f.Desc.Options().(proto.Message); ok {
+		if ext, err := proto.GetExtension(opts, thor_proto.E_Rules); err == nil {
+			return ext.(*thor_proto.FieldRules)
+		}
+	}
+	return &thor_proto.FieldRules{}
+}
+
+// jsonValue convert text value of field option to its JSON value, numbers encoded as JSON strings are kept.
+func jsonValue(fd protoreflect.FieldDescriptor, s string) interface{} {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.FloatKind, protoreflect.DoubleKind:
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n
+		}
+	}
+	return s
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/compiler/protogen"
+
+	thor_proto "github.com/go-board/thor/proto"
+)
+
+const (
+	policyPackage = protogen.GoImportPath("github.com/go-board/thor/pkg/policy")
+	timePackage   = protogen.GoImportPath("time")
+)
+
+// generatePolicy generate method table of each service and register it by init.
+func generatePolicy(gen *protogen.Plugin, f *protogen.File) error {
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+"_policy.pb.go", f.GoImportPath)
+	header(g, f)
+	for _, s := range f.Services {
+		g.P("// ", s.GoName, "Policy is the method table of ", s.Desc.FullName(), ".")
+		g.P("var ", s.GoName, "Policy = ", policyPackage.Ident("Service"), "{")
+		for _, m := range s.Methods {
+			g.P(`"`, m.Desc.Name(), `": {`, methodPolicy(g, m), "},")
+		}
+		g.P("}")
+		g.P()
+	}
+	g.P("func init() {")
+	for _, s := range f.Services {
+		g.P(policyPackage.Ident("Register"), `("`, s.Desc.FullName(), `", `, s.GoName, "Policy)")
+	}
+	g.P("}")
+	return nil
+}
+
+// methodPolicy return fields of policy.Method literal.
+func methodPolicy(g *protogen.GeneratedFile, m *protogen.Method) string {
+	idempotent, maxRetries, p := declaredPolicy(m)
+	var fields []string
+	if idempotent {
+		fields = append(fields, "Idempotent: true")
+	}
+	if maxRetries > 0 {
+		fields = append(fields, "MaxRetries: "+strconv.FormatInt(int64(maxRetries), 10))
+	}
+	if p.GetTimeoutMs() > 0 {
+		fields = append(fields, "Timeout: "+millis(g, p.GetTimeoutMs()))
+	}
+	if h := p.GetHedge(); h != nil {
+		hedge := "Hedge: &" + g.QualifiedGoIdent(policyPackage.Ident("Hedge")) + "{MaxAttempts: " + strconv.FormatInt(int64(h.GetMaxAttempts()), 10)
+		if h.GetDelayMs() > 0 {
+			hedge += ", Delay: " + millis(g, h.GetDelayMs())
+		}
+		fields = append(fields, hedge+"}")
+	}
+	return strings.Join(fields, ", ")
+}
+
+func millis(g *protogen.GeneratedFile, ms uint32) string {
+	return strconv.FormatInt(int64(ms), 10) + " * " + g.QualifiedGoIdent(timePackage.Ident("Millisecond"))
+}
+
+// declaredPolicy return idempotency, max retries and `policy` option of method,
+// `policy` method option takes precedence over message options.
+func declaredPolicy(m *protogen.Method) (idempotent bool, maxRetries int32, p *thor_proto.MethodPolicy) {
+	if opts, ok := m.Input.Desc.Options().(proto.Message); ok {
+		if ext, err := proto.GetExtension(opts, thor_proto.E_IsIdempotent); err == nil {
+			idempotent = *ext.(*bool)
+		}
+		if ext, err := proto.GetExtension(opts, thor_proto.E_MaxRetries); err == nil {
+			maxRetries = *ext.(*int32)
+		}
+	}
+	p = &thor_proto.MethodPolicy{}
+	if opts, ok := m.Desc.Options().(proto.Message); ok {
+		if ext, err := proto.GetExtension(opts, thor_proto.E_Policy); err == nil {
+			p = ext.(*thor_proto.MethodPolicy)
+		}
+	}
+	if p.Idempotent != nil {
+		idempotent = p.GetIdempotent()
+	}
+	if p.MaxRetries != nil {
+		maxRetries = p.GetMaxRetries()
+	}
+	return idempotent, maxRetries, p
+}
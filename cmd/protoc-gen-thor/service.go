@@ -0,0 +1,195 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	servicePackage = protogen.GoImportPath("github.com/go-board/thor/pkg/service")
+	clientPackage  = protogen.GoImportPath("github.com/go-board/thor/pkg/client")
+	serverPackage  = protogen.GoImportPath("github.com/go-board/thor/pkg/server")
+	contextPackage = protogen.GoImportPath("context")
+	grpcPackage    = protogen.GoImportPath("google.golang.org/grpc")
+	codesPackage   = protogen.GoImportPath("google.golang.org/grpc/codes")
+	statusPackage  = protogen.GoImportPath("google.golang.org/grpc/status")
+)
+
+// signature is parameters, arguments and results of a method of generated client or server interface.
+type signature struct {
+	params  string
+	args    string
+	results string
+	// errorOnly is true if results is error only.
+	errorOnly bool
+}
+
+func streamKind(m *protogen.Method) string {
+	switch {
+	case m.Desc.IsStreamingClient() && m.Desc.IsStreamingServer():
+		return "BidiStream"
+	case m.Desc.IsStreamingClient():
+		return "ClientStream"
+	case m.Desc.IsStreamingServer():
+		return "ServerStream"
+	}
+	return "Unary"
+}
+
+// clientSignature return signature of method of `<Service>Client` generated by protoc-gen-go.
+func clientSignature(g *protogen.GeneratedFile, s *protogen.Service, m *protogen.Method) signature {
+	ctx := "ctx " + g.QualifiedGoIdent(contextPackage.Ident("Context"))
+	opts := "opts ..." + g.QualifiedGoIdent(grpcPackage.Ident("CallOption"))
+	stream := s.GoName + "_" + m.GoName + "Client"
+	switch {
+	case m.Desc.IsStreamingClient():
+		return signature{params: ctx + ", " + opts, args: "ctx, opts...", results: "(" + stream + ", error)"}
+	case m.Desc.IsStreamingServer():
+		return signature{params: ctx + ", in *" + g.QualifiedGoIdent(m.Input.GoIdent) + ", " + opts, args: "ctx, in, opts...", results: "(" + stream + ", error)"}
+	}
+	return signature{
+		params:  ctx + ", in *" + g.QualifiedGoIdent(m.Input.GoIdent) + ", " + opts,
+		args:    "ctx, in, opts...",
+		results: "(*" + g.QualifiedGoIdent(m.Output.GoIdent) + ", error)",
+	}
+}
+
+// serverSignature return signature of method of `<Service>Server` generated by protoc-gen-go.
+func serverSignature(g *protogen.GeneratedFile, s *protogen.Service, m *protogen.Method) signature {
+	stream := "stream " + s.GoName + "_" + m.GoName + "Server"
+	switch {
+	case m.Desc.IsStreamingClient():
+		return signature{params: stream, args: "stream", results: "error", errorOnly: true}
+	case m.Desc.IsStreamingServer():
+		return signature{params: "in *" + g.QualifiedGoIdent(m.Input.GoIdent) + ", " + stream, args: "in, stream", results: "error", errorOnly: true}
+	}
+	return signature{
+		params:  "ctx " + g.QualifiedGoIdent(contextPackage.Ident("Context")) + ", in *" + g.QualifiedGoIdent(m.Input.GoIdent),
+		args:    "ctx, in",
+		results: "(*" + g.QualifiedGoIdent(m.Output.GoIdent) + ", error)",
+	}
+}
+
+// generateService generate method metadata, client resolving service by name and server registration of each service.
+func generateService(gen *protogen.Plugin, f *protogen.File) error {
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+"_thor.pb.go", f.GoImportPath)
+	header(g, f)
+	for _, s := range f.Services {
+		g.P("// ", s.GoName, "ServiceName is the full name of ", s.Desc.FullName(), ".")
+		g.P("const ", s.GoName, `ServiceName = "`, s.Desc.FullName(), `"`)
+		g.P()
+		g.P("// ", s.GoName, "Methods is metadata of methods of ", s.Desc.FullName(), ".")
+		g.P("var ", s.GoName, "Methods = struct {")
+		for _, m := range s.Methods {
+			g.P(m.GoName, " ", servicePackage.Ident("Method"))
+		}
+		g.P("}{")
+		for _, m := range s.Methods {
+			idempotent, _, _ := declaredPolicy(m)
+			fields := "FullName: " + strconv.Quote("/"+string(s.Desc.FullName())+"/"+string(m.Desc.Name())) +
+				", Name: " + strconv.Quote(string(m.Desc.Name())) +
+				", Kind: " + g.QualifiedGoIdent(servicePackage.Ident(streamKind(m)))
+			if idempotent {
+				fields += ", Idempotent: true"
+			}
+			g.P(m.GoName, ": ", servicePackage.Ident("Method"), "{", fields, "},")
+		}
+		g.P("}")
+		g.P()
+
+		client := unexport(s.GoName) + "ThorClient"
+		g.P("// New", s.GoName, "ClientByName return ", s.GoName, "Client calling service registered as name,")
+		g.P("// connection is got from the default pool of pkg/client for each call, so it's safe to hold the client.")
+		g.P("func New", s.GoName, "ClientByName(name string) ", s.GoName, "Client {")
+		g.P("return &", client, "{name: name, get: ", clientPackage.Ident("Get"), "}")
+		g.P("}")
+		g.P()
+		g.P("// New", s.GoName, "ClientFromPool is New", s.GoName, "ClientByName with connections got from p.")
+		g.P("func New", s.GoName, "ClientFromPool(p *", clientPackage.Ident("Pool"), ", name string) ", s.GoName, "Client {")
+		g.P("return &", client, "{name: name, get: p.Get}")
+		g.P("}")
+		g.P()
+		g.P("type ", client, " struct {")
+		g.P("name string")
+		g.P("get func(ctx ", contextPackage.Ident("Context"), ", service string) (*", grpcPackage.Ident("ClientConn"), ", error)")
+		g.P("}")
+		g.P()
+		for _, m := range s.Methods {
+			sig := clientSignature(g, s, m)
+			g.P("func (c *", client, ") ", m.GoName, "(", sig.params, ") ", sig.results, " {")
+			g.P("cc, err := c.get(ctx, c.name)")
+			g.P("if err != nil {")
+			g.P("return nil, err")
+			g.P("}")
+			g.P("return New", s.GoName, "Client(cc).", m.GoName, "(", sig.args, ")")
+			g.P("}")
+			g.P()
+		}
+
+		g.P("// Register", s.GoName, "Service register srv onto s as ", s.Desc.FullName(), ".")
+		g.P("func Register", s.GoName, "Service(s *", serverPackage.Ident("Server"), ", srv ", s.GoName, "Server) {")
+		g.P("s.RegisterService(&_", s.GoName, "_serviceDesc, srv)")
+		g.P("}")
+		g.P()
+	}
+	g.P("func init() {")
+	for _, s := range f.Services {
+		var args string
+		for i, m := range s.Methods {
+			if i > 0 {
+				args += ", "
+			}
+			args += s.GoName + "Methods." + m.GoName
+		}
+		g.P(servicePackage.Ident("Register"), "(", args, ")")
+	}
+	g.P("}")
+	return nil
+}
+
+// generateMock generate mocks of client and server interfaces of each service for tests.
+func generateMock(gen *protogen.Plugin, f *protogen.File) error {
+	g := gen.NewGeneratedFile(f.GeneratedFilenamePrefix+"_mock.pb.go", f.GoImportPath)
+	header(g, f)
+	for _, s := range f.Services {
+		for _, side := range []string{"Client", "Server"} {
+			mock := "Mock" + s.GoName + side
+			signatureOf := clientSignature
+			if side == "Server" {
+				signatureOf = serverSignature
+			}
+			g.P("// ", mock, " is ", s.GoName, side, " for tests, methods call the func fields, or return Unimplemented if not set.")
+			g.P("type ", mock, " struct {")
+			for _, m := range s.Methods {
+				sig := signatureOf(g, s, m)
+				g.P(m.GoName, "Func func(", sig.params, ") ", sig.results)
+			}
+			g.P("}")
+			g.P()
+			g.P("var _ ", s.GoName, side, " = (*", mock, ")(nil)")
+			g.P()
+			for _, m := range s.Methods {
+				sig := signatureOf(g, s, m)
+				unimplemented := g.QualifiedGoIdent(statusPackage.Ident("Error")) + "(" + g.QualifiedGoIdent(codesPackage.Ident("Unimplemented")) + `, "method ` + m.GoName + ` not mocked")`
+				g.P("func (m *", mock, ") ", m.GoName, "(", sig.params, ") ", sig.results, " {")
+				g.P("if m.", m.GoName, "Func == nil {")
+				if sig.errorOnly {
+					g.P("return ", unimplemented)
+				} else {
+					g.P("return nil, ", unimplemented)
+				}
+				g.P("}")
+				g.P("return m.", m.GoName, "Func(", sig.args, ")")
+				g.P("}")
+				g.P()
+			}
+		}
+	}
+	return nil
+}
+
+func unexport(s string) string {
+	return strings.ToLower(s[:1]) + s[1:]
+}
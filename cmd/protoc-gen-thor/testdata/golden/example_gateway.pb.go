@@ -0,0 +1,71 @@
+// Code generated by protoc-gen-thor. DO NOT EDIT.
+// source: example.proto
+
+package example
+
+import (
+	gateway "github.com/go-board/thor/pkg/gateway"
+	web "github.com/go-board/thor/pkg/web"
+	xhttp "github.com/go-board/x-go/xnet/xhttp"
+	http "net/http"
+)
+
+// RegisterStoreHTTPHandler register HTTP handlers of example.Store declared by `google.api.http` onto r,
+// requests are forwarded to c.
+func RegisterStoreHTTPHandler(r web.Router, c StoreClient, middlewares ...xhttp.Middleware) {
+	r.Handle("GET", "/v1/items/:id", web.HandlerFunc(func(w http.ResponseWriter, req *http.Request) error {
+		in := &GetItemRequest{}
+		if err := gateway.Bind(req, in, ""); err != nil {
+			return err
+		}
+		out, err := c.GetItem(gateway.OutgoingContext(req), in)
+		if err != nil {
+			return err
+		}
+		return gateway.WriteResponse(w, out, "")
+	}), middlewares...)
+	r.Handle("GET", "/v1/kinds/:kind/items/*id", web.HandlerFunc(func(w http.ResponseWriter, req *http.Request) error {
+		in := &GetItemRequest{}
+		if err := gateway.Bind(req, in, ""); err != nil {
+			return err
+		}
+		out, err := c.GetItem(gateway.OutgoingContext(req), in)
+		if err != nil {
+			return err
+		}
+		return gateway.WriteResponse(w, out, "")
+	}), middlewares...)
+	r.Handle("POST", "/v1/shelves/:parent/items", web.HandlerFunc(func(w http.ResponseWriter, req *http.Request) error {
+		in := &CreateItemRequest{}
+		if err := gateway.Bind(req, in, "item"); err != nil {
+			return err
+		}
+		out, err := c.CreateItem(gateway.OutgoingContext(req), in)
+		if err != nil {
+			return err
+		}
+		return gateway.WriteResponse(w, out, "")
+	}), middlewares...)
+	r.Handle("PATCH", "/v1/items/:id", web.HandlerFunc(func(w http.ResponseWriter, req *http.Request) error {
+		in := &Item{}
+		if err := gateway.Bind(req, in, "*"); err != nil {
+			return err
+		}
+		out, err := c.UpdateItem(gateway.OutgoingContext(req), in)
+		if err != nil {
+			return err
+		}
+		return gateway.WriteResponse(w, out, "")
+	}), middlewares...)
+	r.Handle("GET", "/v1/items", web.HandlerFunc(func(w http.ResponseWriter, req *http.Request) error {
+		in := &ListItemsRequest{}
+		if err := gateway.Bind(req, in, ""); err != nil {
+			return err
+		}
+		out, err := c.ListItems(gateway.OutgoingContext(req), in)
+		if err != nil {
+			return err
+		}
+		return gateway.WriteResponse(w, out, "items")
+	}), middlewares...)
+}
@@ -0,0 +1,68 @@
+// Code generated by protoc-gen-thor. DO NOT EDIT.
+// source: example.proto
+
+package example
+
+import (
+	pbjson "github.com/go-board/thor/pkg/pbjson"
+)
+
+// MarshalJSON marshal x with field names declared by `json_tag` and `json_tag_mode` options.
+func (x *Item) MarshalJSON() ([]byte, error) {
+	return pbjson.Marshal(x)
+}
+
+// UnmarshalJSON unmarshal b into x, fields missing in b are set to `default_value` option.
+func (x *Item) UnmarshalJSON(b []byte) error {
+	return pbjson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, x)
+}
+
+// MarshalJSON marshal x with field names declared by `json_tag` and `json_tag_mode` options.
+func (x *GetItemRequest) MarshalJSON() ([]byte, error) {
+	return pbjson.Marshal(x)
+}
+
+// UnmarshalJSON unmarshal b into x, fields missing in b are set to `default_value` option.
+func (x *GetItemRequest) UnmarshalJSON(b []byte) error {
+	return pbjson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, x)
+}
+
+// MarshalJSON marshal x with field names declared by `json_tag` and `json_tag_mode` options.
+func (x *CreateItemRequest) MarshalJSON() ([]byte, error) {
+	return pbjson.Marshal(x)
+}
+
+// UnmarshalJSON unmarshal b into x, fields missing in b are set to `default_value` option.
+func (x *CreateItemRequest) UnmarshalJSON(b []byte) error {
+	return pbjson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, x)
+}
+
+// MarshalJSON marshal x with field names declared by `json_tag` and `json_tag_mode` options.
+func (x *ListItemsRequest) MarshalJSON() ([]byte, error) {
+	return pbjson.Marshal(x)
+}
+
+// UnmarshalJSON unmarshal b into x, fields missing in b are set to `default_value` option.
+func (x *ListItemsRequest) UnmarshalJSON(b []byte) error {
+	return pbjson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, x)
+}
+
+// MarshalJSON marshal x with field names declared by `json_tag` and `json_tag_mode` options.
+func (x *ListItemsResponse) MarshalJSON() ([]byte, error) {
+	return pbjson.Marshal(x)
+}
+
+// UnmarshalJSON unmarshal b into x, fields missing in b are set to `default_value` option.
+func (x *ListItemsResponse) UnmarshalJSON(b []byte) error {
+	return pbjson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, x)
+}
+
+// MarshalJSON marshal x with field names declared by `json_tag` and `json_tag_mode` options.
+func (x *ListItemsResponse_Page) MarshalJSON() ([]byte, error) {
+	return pbjson.Marshal(x)
+}
+
+// UnmarshalJSON unmarshal b into x, fields missing in b are set to `default_value` option.
+func (x *ListItemsResponse_Page) UnmarshalJSON(b []byte) error {
+	return pbjson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, x)
+}
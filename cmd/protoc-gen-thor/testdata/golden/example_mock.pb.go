@@ -0,0 +1,135 @@
+// Code generated by protoc-gen-thor. DO NOT EDIT.
+// source: example.proto
+
+package example
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// MockStoreClient is StoreClient for tests, methods call the func fields, or return Unimplemented if not set.
+type MockStoreClient struct {
+	GetItemFunc     func(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (*Item, error)
+	CreateItemFunc  func(ctx context.Context, in *CreateItemRequest, opts ...grpc.CallOption) (*Item, error)
+	UpdateItemFunc  func(ctx context.Context, in *Item, opts ...grpc.CallOption) (*Item, error)
+	ListItemsFunc   func(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (*ListItemsResponse, error)
+	WatchItemsFunc  func(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (Store_WatchItemsClient, error)
+	UploadItemsFunc func(ctx context.Context, opts ...grpc.CallOption) (Store_UploadItemsClient, error)
+	SyncItemsFunc   func(ctx context.Context, opts ...grpc.CallOption) (Store_SyncItemsClient, error)
+}
+
+var _ StoreClient = (*MockStoreClient)(nil)
+
+func (m *MockStoreClient) GetItem(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (*Item, error) {
+	if m.GetItemFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "method GetItem not mocked")
+	}
+	return m.GetItemFunc(ctx, in, opts...)
+}
+
+func (m *MockStoreClient) CreateItem(ctx context.Context, in *CreateItemRequest, opts ...grpc.CallOption) (*Item, error) {
+	if m.CreateItemFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "method CreateItem not mocked")
+	}
+	return m.CreateItemFunc(ctx, in, opts...)
+}
+
+func (m *MockStoreClient) UpdateItem(ctx context.Context, in *Item, opts ...grpc.CallOption) (*Item, error) {
+	if m.UpdateItemFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "method UpdateItem not mocked")
+	}
+	return m.UpdateItemFunc(ctx, in, opts...)
+}
+
+func (m *MockStoreClient) ListItems(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (*ListItemsResponse, error) {
+	if m.ListItemsFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "method ListItems not mocked")
+	}
+	return m.ListItemsFunc(ctx, in, opts...)
+}
+
+func (m *MockStoreClient) WatchItems(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (Store_WatchItemsClient, error) {
+	if m.WatchItemsFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "method WatchItems not mocked")
+	}
+	return m.WatchItemsFunc(ctx, in, opts...)
+}
+
+func (m *MockStoreClient) UploadItems(ctx context.Context, opts ...grpc.CallOption) (Store_UploadItemsClient, error) {
+	if m.UploadItemsFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "method UploadItems not mocked")
+	}
+	return m.UploadItemsFunc(ctx, opts...)
+}
+
+func (m *MockStoreClient) SyncItems(ctx context.Context, opts ...grpc.CallOption) (Store_SyncItemsClient, error) {
+	if m.SyncItemsFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "method SyncItems not mocked")
+	}
+	return m.SyncItemsFunc(ctx, opts...)
+}
+
+// MockStoreServer is StoreServer for tests, methods call the func fields, or return Unimplemented if not set.
+type MockStoreServer struct {
+	GetItemFunc     func(ctx context.Context, in *GetItemRequest) (*Item, error)
+	CreateItemFunc  func(ctx context.Context, in *CreateItemRequest) (*Item, error)
+	UpdateItemFunc  func(ctx context.Context, in *Item) (*Item, error)
+	ListItemsFunc   func(ctx context.Context, in *ListItemsRequest) (*ListItemsResponse, error)
+	WatchItemsFunc  func(in *GetItemRequest, stream Store_WatchItemsServer) error
+	UploadItemsFunc func(stream Store_UploadItemsServer) error
+	SyncItemsFunc   func(stream Store_SyncItemsServer) error
+}
+
+var _ StoreServer = (*MockStoreServer)(nil)
+
+func (m *MockStoreServer) GetItem(ctx context.Context, in *GetItemRequest) (*Item, error) {
+	if m.GetItemFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "method GetItem not mocked")
+	}
+	return m.GetItemFunc(ctx, in)
+}
+
+func (m *MockStoreServer) CreateItem(ctx context.Context, in *CreateItemRequest) (*Item, error) {
+	if m.CreateItemFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "method CreateItem not mocked")
+	}
+	return m.CreateItemFunc(ctx, in)
+}
+
+func (m *MockStoreServer) UpdateItem(ctx context.Context, in *Item) (*Item, error) {
+	if m.UpdateItemFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "method UpdateItem not mocked")
+	}
+	return m.UpdateItemFunc(ctx, in)
+}
+
+func (m *MockStoreServer) ListItems(ctx context.Context, in *ListItemsRequest) (*ListItemsResponse, error) {
+	if m.ListItemsFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "method ListItems not mocked")
+	}
+	return m.ListItemsFunc(ctx, in)
+}
+
+func (m *MockStoreServer) WatchItems(in *GetItemRequest, stream Store_WatchItemsServer) error {
+	if m.WatchItemsFunc == nil {
+		return status.Error(codes.Unimplemented, "method WatchItems not mocked")
+	}
+	return m.WatchItemsFunc(in, stream)
+}
+
+func (m *MockStoreServer) UploadItems(stream Store_UploadItemsServer) error {
+	if m.UploadItemsFunc == nil {
+		return status.Error(codes.Unimplemented, "method UploadItems not mocked")
+	}
+	return m.UploadItemsFunc(stream)
+}
+
+func (m *MockStoreServer) SyncItems(stream Store_SyncItemsServer) error {
+	if m.SyncItemsFunc == nil {
+		return status.Error(codes.Unimplemented, "method SyncItems not mocked")
+	}
+	return m.SyncItemsFunc(stream)
+}
@@ -0,0 +1,24 @@
+// Code generated by protoc-gen-thor. DO NOT EDIT.
+// source: example.proto
+
+package example
+
+import (
+	policy "github.com/go-board/thor/pkg/policy"
+	time "time"
+)
+
+// StorePolicy is the method table of example.Store.
+var StorePolicy = policy.Service{
+	"GetItem":     {Idempotent: true, MaxRetries: 2},
+	"CreateItem":  {Timeout: 1500 * time.Millisecond},
+	"UpdateItem":  {},
+	"ListItems":   {Idempotent: true, MaxRetries: 1, Hedge: &policy.Hedge{MaxAttempts: 3, Delay: 20 * time.Millisecond}},
+	"WatchItems":  {Idempotent: true},
+	"UploadItems": {},
+	"SyncItems":   {},
+}
+
+func init() {
+	policy.Register("example.Store", StorePolicy)
+}
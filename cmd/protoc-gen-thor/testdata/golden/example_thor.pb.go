@@ -0,0 +1,115 @@
+// Code generated by protoc-gen-thor. DO NOT EDIT.
+// source: example.proto
+
+package example
+
+import (
+	context "context"
+	client "github.com/go-board/thor/pkg/client"
+	server "github.com/go-board/thor/pkg/server"
+	service "github.com/go-board/thor/pkg/service"
+	grpc "google.golang.org/grpc"
+)
+
+// StoreServiceName is the full name of example.Store.
+const StoreServiceName = "example.Store"
+
+// StoreMethods is metadata of methods of example.Store.
+var StoreMethods = struct {
+	GetItem     service.Method
+	CreateItem  service.Method
+	UpdateItem  service.Method
+	ListItems   service.Method
+	WatchItems  service.Method
+	UploadItems service.Method
+	SyncItems   service.Method
+}{
+	GetItem:     service.Method{FullName: "/example.Store/GetItem", Name: "GetItem", Kind: service.Unary, Idempotent: true},
+	CreateItem:  service.Method{FullName: "/example.Store/CreateItem", Name: "CreateItem", Kind: service.Unary},
+	UpdateItem:  service.Method{FullName: "/example.Store/UpdateItem", Name: "UpdateItem", Kind: service.Unary},
+	ListItems:   service.Method{FullName: "/example.Store/ListItems", Name: "ListItems", Kind: service.Unary, Idempotent: true},
+	WatchItems:  service.Method{FullName: "/example.Store/WatchItems", Name: "WatchItems", Kind: service.ServerStream, Idempotent: true},
+	UploadItems: service.Method{FullName: "/example.Store/UploadItems", Name: "UploadItems", Kind: service.ClientStream},
+	SyncItems:   service.Method{FullName: "/example.Store/SyncItems", Name: "SyncItems", Kind: service.BidiStream},
+}
+
+// NewStoreClientByName return StoreClient calling service registered as name,
+// connection is got from the default pool of pkg/client for each call, so it's safe to hold the client.
+func NewStoreClientByName(name string) StoreClient {
+	return &storeThorClient{name: name, get: client.Get}
+}
+
+// NewStoreClientFromPool is NewStoreClientByName with connections got from p.
+func NewStoreClientFromPool(p *client.Pool, name string) StoreClient {
+	return &storeThorClient{name: name, get: p.Get}
+}
+
+type storeThorClient struct {
+	name string
+	get  func(ctx context.Context, service string) (*grpc.ClientConn, error)
+}
+
+func (c *storeThorClient) GetItem(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (*Item, error) {
+	cc, err := c.get(ctx, c.name)
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreClient(cc).GetItem(ctx, in, opts...)
+}
+
+func (c *storeThorClient) CreateItem(ctx context.Context, in *CreateItemRequest, opts ...grpc.CallOption) (*Item, error) {
+	cc, err := c.get(ctx, c.name)
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreClient(cc).CreateItem(ctx, in, opts...)
+}
+
+func (c *storeThorClient) UpdateItem(ctx context.Context, in *Item, opts ...grpc.CallOption) (*Item, error) {
+	cc, err := c.get(ctx, c.name)
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreClient(cc).UpdateItem(ctx, in, opts...)
+}
+
+func (c *storeThorClient) ListItems(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (*ListItemsResponse, error) {
+	cc, err := c.get(ctx, c.name)
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreClient(cc).ListItems(ctx, in, opts...)
+}
+
+func (c *storeThorClient) WatchItems(ctx context.Context, in *GetItemRequest, opts ...grpc.CallOption) (Store_WatchItemsClient, error) {
+	cc, err := c.get(ctx, c.name)
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreClient(cc).WatchItems(ctx, in, opts...)
+}
+
+func (c *storeThorClient) UploadItems(ctx context.Context, opts ...grpc.CallOption) (Store_UploadItemsClient, error) {
+	cc, err := c.get(ctx, c.name)
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreClient(cc).UploadItems(ctx, opts...)
+}
+
+func (c *storeThorClient) SyncItems(ctx context.Context, opts ...grpc.CallOption) (Store_SyncItemsClient, error) {
+	cc, err := c.get(ctx, c.name)
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreClient(cc).SyncItems(ctx, opts...)
+}
+
+// RegisterStoreService register srv onto s as example.Store.
+func RegisterStoreService(s *server.Server, srv StoreServer) {
+	s.RegisterService(&_Store_serviceDesc, srv)
+}
+
+func init() {
+	service.Register(StoreMethods.GetItem, StoreMethods.CreateItem, StoreMethods.UpdateItem, StoreMethods.ListItems, StoreMethods.WatchItems, StoreMethods.UploadItems, StoreMethods.SyncItems)
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/go-board/thor/pkg/interceptors"
+)
+
+const (
+	bulkheadReleaseKey     = "gorm_bulkhead_release"
+	defaultBulkheadTimeout = time.Second
+)
+
+// ApplyBulkhead bound concurrent sql executed by db, rejected sql fails with *interceptors.BulkheadFullError.
+// gorm calls carry no context, so sql waits at most timeout for a slot, default is 1s if timeout isn't positive.
+// It's registered by itself rather than as a Callback, which observes sql only.
+func ApplyBulkhead(db *gorm.DB, b *interceptors.Bulkhead, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultBulkheadTimeout
+	}
+	before := func(s *gorm.Scope) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		release, err := b.Acquire(ctx)
+		if err == context.DeadlineExceeded {
+			err = &interceptors.BulkheadFullError{Name: b.Name()}
+		}
+		if err != nil {
+			_ = s.Err(err)
+			return
+		}
+		s.Set(bulkheadReleaseKey, release)
+	}
+	after := func(s *gorm.Scope) {
+		if val, ok := s.Get(bulkheadReleaseKey); ok {
+			val.(func())()
+		}
+	}
+	for _, c := range []struct {
+		name      string
+		processor *gorm.CallbackProcessor
+		callback  string
+	}{
+		{"query", db.Callback().Query(), "gorm:query"},
+		{"rowquery", db.Callback().RowQuery(), "gorm:row_query"},
+		{"create", db.Callback().Create(), "gorm:create"},
+		{"update", db.Callback().Update(), "gorm:update"},
+		{"delete", db.Callback().Delete(), "gorm:delete"},
+	} {
+		c.processor.Before(c.callback).Register(fmt.Sprintf("before_%s_bulkhead", c.name), before)
+		c.processor.After(c.callback).Register(fmt.Sprintf("after_%s_bulkhead", c.name), after)
+	}
+}
@@ -26,20 +26,20 @@ type Callback interface {
 }
 
 func ApplyCallback(db *gorm.DB, callback Callback) {
-	db.Callback().Query().Before("gorm:query").Register(fmt.Sprintf("before_query_%s", callback.Name()), callback.Before)
-	db.Callback().Query().After("gorm:query").Register(fmt.Sprintf("after_query_%s", callback.Name()), callback.After)
+	db.Callback().Query().Before("gorm:query").Register(fmt.Sprintf("before_query_%s", callback.Name()), callback.BeforeQuery)
+	db.Callback().Query().After("gorm:query").Register(fmt.Sprintf("after_query_%s", callback.Name()), callback.AfterQuery)
 
-	db.Callback().RowQuery().Before("gorm:row_query").Register(fmt.Sprintf("before_rowquery_%s", callback.Name()), callback.Before)
-	db.Callback().RowQuery().After("gorm:row_query").Register(fmt.Sprintf("after_rowquery_%s", callback.Name()), callback.After)
+	db.Callback().RowQuery().Before("gorm:row_query").Register(fmt.Sprintf("before_rowquery_%s", callback.Name()), callback.BeforeRowQuery)
+	db.Callback().RowQuery().After("gorm:row_query").Register(fmt.Sprintf("after_rowquery_%s", callback.Name()), callback.AfterRowQuery)
 
-	db.Callback().Create().Before("gorm:create").Register(fmt.Sprintf("before_create_%s", callback.Name()), callback.Before)
-	db.Callback().Create().After("gorm:create").Register(fmt.Sprintf("after_create_%s", callback.Name()), callback.After)
+	db.Callback().Create().Before("gorm:create").Register(fmt.Sprintf("before_create_%s", callback.Name()), callback.BeforeCreate)
+	db.Callback().Create().After("gorm:create").Register(fmt.Sprintf("after_create_%s", callback.Name()), callback.AfterCreate)
 
-	db.Callback().Update().Before("gorm:update").Register(fmt.Sprintf("before_update_%s", callback.Name()), callback.Before)
-	db.Callback().Update().After("gorm:update").Register(fmt.Sprintf("after_update_%s", callback.Name()), callback.After)
+	db.Callback().Update().Before("gorm:update").Register(fmt.Sprintf("before_update_%s", callback.Name()), callback.BeforeUpdate)
+	db.Callback().Update().After("gorm:update").Register(fmt.Sprintf("after_update_%s", callback.Name()), callback.AfterUpdate)
 
-	db.Callback().Delete().Before("gorm:delete").Register(fmt.Sprintf("before_delete_%s", callback.Name()), callback.Before)
-	db.Callback().Delete().After("gorm:delete").Register(fmt.Sprintf("after_delete_%s", callback.Name()), callback.After)
+	db.Callback().Delete().Before("gorm:delete").Register(fmt.Sprintf("before_delete_%s", callback.Name()), callback.BeforeDelete)
+	db.Callback().Delete().After("gorm:delete").Register(fmt.Sprintf("after_delete_%s", callback.Name()), callback.AfterDelete)
 }
 
 type loggerCallback struct {
@@ -2,15 +2,27 @@ package thor
 
 import (
 	"log"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/go-board/x-go/metadata"
+	"github.com/go-board/x-go/xnet/xhttp"
+	"google.golang.org/grpc"
 	"gopkg.in/yaml.v2"
 
+	"github.com/go-board/thor/pkg/auth"
+	"github.com/go-board/thor/pkg/client"
+	"github.com/go-board/thor/pkg/fault"
+	"github.com/go-board/thor/pkg/gateway"
+	"github.com/go-board/thor/pkg/interceptors"
 	"github.com/go-board/thor/pkg/logger"
 	"github.com/go-board/thor/pkg/metric"
+	"github.com/go-board/thor/pkg/registry"
+	"github.com/go-board/thor/pkg/server"
+	"github.com/go-board/thor/pkg/slo"
 	"github.com/go-board/thor/pkg/trace"
+	"github.com/go-board/thor/pkg/web"
 )
 
 // Options is the thor application global configuration.
@@ -25,6 +37,20 @@ type Options struct {
 	Trace          TraceOption       `yaml:"trace"`
 	Registry       RegistryOption    `yaml:"registry"`
 	Resilience     ResilienceOption  `yaml:"resilience"`
+	SLO            []slo.Objective   `yaml:"slo"`
+	Auth           auth.Config       `yaml:"auth"`
+	// Fault is fault injection for chaos testing, it's disabled unless enabled here or by the admin handler,
+	// which is usable only if `fault.admin_token` is set.
+	Fault fault.Config `yaml:"fault"`
+	// Clients is keyed by client name, see NewClient.
+	Clients map[string]client.Config `yaml:"clients"`
+	Gateway GatewayOption            `yaml:"gateway"`
+}
+
+// GatewayOption configure HTTP handlers generated from `google.api.http` annotations.
+type GatewayOption struct {
+	// MaxBodyBytes limit size of request bodies, default is 4MB.
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
 }
 
 type ListenerOption struct {
@@ -82,7 +108,17 @@ const (
 )
 
 type ResilienceOption struct {
+	// RetryOnIdempotent enable retry of idempotent calls made by clients.
 	RetryOnIdempotent bool `yaml:"retry_on_idempotent"`
+	// DeadlineMargin is the default deadline margin of clients.
+	DeadlineMargin time.Duration              `yaml:"deadline_margin"`
+	CircuitBreaker interceptors.BreakerConfig `yaml:"circuit_breaker"`
+	// Bulkheads is keyed by dependency name, like service name of grpc clients,
+	// interceptors.DefaultBulkheadKey applies to each dependency not listed.
+	Bulkheads        map[string]interceptors.BulkheadConfig `yaml:"bulkheads"`
+	Deadline         server.DeadlineOption                  `yaml:"deadline"`
+	RateLimit        server.RateLimitOption                 `yaml:"rate_limit"`
+	ConcurrencyLimit server.ConcurrencyLimitOption          `yaml:"concurrency_limit"`
 }
 
 type LoggerOption struct {
@@ -99,6 +135,105 @@ var globalOptions = new(Options)
 
 func OptionReader() Options { return *globalOptions }
 
+// ServerOptions return grpc server options declared in global options.
+func ServerOptions() []server.Option {
+	options := []server.Option{
+		server.Deadline(globalOptions.Resilience.Deadline),
+		server.RateLimit(globalOptions.Resilience.RateLimit),
+		server.ConcurrencyLimit(globalOptions.Resilience.ConcurrencyLimit),
+	}
+	a, err := auth.New(globalOptions.Auth)
+	if err != nil {
+		log.Fatalf("create authenticator failed, %s\n", err)
+	}
+	if a != nil {
+		options = append(options, server.Auth(a, globalOptions.Auth.Policy))
+	}
+	if faultInjector != nil {
+		options = append(options, server.FaultInjection(faultInjector))
+	}
+	return options
+}
+
+// NewClient create client connection declared in `clients` section by name,
+// name is used as target if target isn't declared, options override the configuration.
+func NewClient(name string, options ...client.Option) (*grpc.ClientConn, error) {
+	c := globalOptions.Clients[name]
+	if c.Target == "" {
+		c.Target = name
+	}
+	configured, err := c.Options()
+	if err != nil {
+		return nil, err
+	}
+	configured = append(defaultClientOptions(), configured...)
+	return client.New(c.Target, append(configured, options...)...)
+}
+
+var bulkheads = interceptors.NewBulkheads(nil)
+
+// Bulkhead return bulkhead of dependency declared in `resilience.bulkheads` section, nil if it's not declared,
+// it can be used to bound calls of http clients, gorm and redis.
+func Bulkhead(name string) *interceptors.Bulkhead {
+	return bulkheads.Get(name)
+}
+
+var faultInjector *fault.Injector
+
+// FaultInjector return the injector created from `fault` section by Initialize, it's nil before.
+// Its Handler is the admin endpoint toggling faults at runtime, it's mounted by Admin and requires `fault.admin_token`,
+// web.FaultMiddleware applies the injector to http servers.
+func FaultInjector() *fault.Injector {
+	return faultInjector
+}
+
+// Admin mount admin endpoints onto r under path, r should be served on an internal listener only:
+//   - `<path>/slo` reports burn rates of objectives declared in `slo` section.
+//   - `<path>/fault` is the handler of FaultInjector, which requires `fault.admin_token`.
+func Admin(r web.Router, path string, middlewares ...xhttp.Middleware) {
+	r.Handle(http.MethodGet, path+"/slo", http.HandlerFunc(slo.ServeHTTP), middlewares...)
+	if faultInjector != nil {
+		h := faultInjector.Handler()
+		for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+			r.Handle(method, path+"/fault", h, middlewares...)
+		}
+	}
+}
+
+// defaultClientOptions return client options declared in `resilience` section.
+func defaultClientOptions() []client.Option {
+	options := []client.Option{client.DeadlineMargin(globalOptions.Resilience.DeadlineMargin)}
+	if globalOptions.Resilience.RetryOnIdempotent {
+		options = append(options, client.Retry())
+	}
+	if globalOptions.Resilience.CircuitBreaker.Enabled {
+		options = append(options, client.CircuitBreaker(globalOptions.Resilience.CircuitBreaker.Options()...))
+	}
+	if len(globalOptions.Resilience.Bulkheads) > 0 {
+		options = append(options, client.Bulkhead(bulkheads))
+	}
+	if faultInjector != nil {
+		options = append(options, client.FaultInjection(faultInjector))
+	}
+	return options
+}
+
+// InitializeClients create the client pool used by client.Get, services are resolved from r,
+// clients declared in `clients` section are dialed with their configuration.
+func InitializeClients(r registry.Registry, options ...client.PoolOption) {
+	client.Initialize(r, append([]client.PoolOption{
+		client.Configs(globalOptions.Clients),
+		client.ClientOptions(defaultClientOptions()...),
+	}, options...)...)
+}
+
+// Shutdown release resources created by thor, like pooled client connections.
+func Shutdown() {
+	if err := client.Close(); err != nil {
+		log.Printf("close clients failed, %s\n", err)
+	}
+}
+
 // Initialize create the whole world of the current application.
 func Initialize(options ...Option) {
 	f, err := os.Open("env")
@@ -119,4 +254,15 @@ func Initialize(options ...Option) {
 	logger.Initialize(globalOptions.Logger.Dir, globalOptions.Namespace, globalOptions.ServiceName, globalOptions.ServiceID)
 	trace.Initialize(globalOptions.ServiceName, globalOptions.Trace.TraceSampleType, globalOptions.Trace.TraceSampleParam)
 	metric.Initialize(globalOptions.Namespace, globalOptions.ServiceName, globalOptions.ServiceID, globalOptions.ServiceVersion)
+	if err := slo.Initialize(globalOptions.SLO); err != nil {
+		log.Fatalf("create slo tracker failed, %s\n", err)
+	}
+	if globalOptions.Gateway.MaxBodyBytes > 0 {
+		gateway.MaxBodyBytes = globalOptions.Gateway.MaxBodyBytes
+	}
+	bulkheads = interceptors.NewBulkheads(globalOptions.Resilience.Bulkheads)
+	faultInjector, err = fault.New(globalOptions.Fault)
+	if err != nil {
+		log.Fatalf("create fault injector failed, %s\n", err)
+	}
 }
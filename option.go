@@ -2,6 +2,8 @@ package thor
 
 import (
 	"github.com/go-board/x-go/metadata"
+
+	"github.com/go-board/thor/pkg/slo"
 )
 
 type Option func(o *Options)
@@ -53,3 +55,9 @@ func LogLevelFilter(l string) Option {
 		o.Logger.LevelFilter = l
 	}
 }
+
+func Objectives(objectives ...slo.Objective) Option {
+	return func(o *Options) {
+		o.SLO = append(o.SLO, objectives...)
+	}
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/go-board/thor/pkg/web"
+)
+
+const defaultAPIKeyHeader = "x-api-key"
+
+// APIKeyOption declares static api keys, Header default is `x-api-key`.
+type APIKeyOption struct {
+	Header string                 `yaml:"header"`
+	Keys   map[string]*Credential `yaml:"keys"`
+}
+
+type apiKey struct {
+	option APIKeyOption
+}
+
+// APIKey authenticate calls carrying one of the static keys.
+func APIKey(o APIKeyOption) Authenticator {
+	if o.Header == "" {
+		o.Header = defaultAPIKeyHeader
+	}
+	return &apiKey{option: o}
+}
+
+func (a *apiKey) Authenticate(ctx context.Context) (web.AuthResult, bool, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	key := firstValue(md, a.option.Header)
+	if key == "" {
+		return nil, false, ErrNoCredential
+	}
+	for k, c := range a.option.Keys {
+		if secureEqual(k, key) {
+			return c, true, nil
+		}
+	}
+	return nil, true, ErrInvalidCredential
+}
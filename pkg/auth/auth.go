@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/go-board/thor/pkg/web"
+)
+
+var (
+	ErrNoCredential      = errors.New("auth: no credential")
+	ErrInvalidCredential = errors.New("auth: invalid credential")
+)
+
+// Authenticator is the grpc counterpart of web.Authenticator, credentials are read from incoming metadata of ctx,
+// need is false if credentials of the authenticator are absent.
+type Authenticator interface {
+	Authenticate(ctx context.Context) (authResult web.AuthResult, need bool, err error)
+}
+
+// Principal is implemented by AuthResult carrying roles and scopes, which are checked by Policy.
+type Principal interface {
+	Roles() []string
+	Scopes() []string
+}
+
+// Credential describe who the credential belongs to, it's used as AuthResult by built-in authenticators.
+type Credential struct {
+	App       string   `yaml:"app_id" json:"app_id"`
+	Device    string   `yaml:"device_id" json:"device_id"`
+	User      string   `yaml:"user_id" json:"user_id"`
+	RoleList  []string `yaml:"roles" json:"roles"`
+	ScopeList []string `yaml:"scopes" json:"scopes"`
+}
+
+func (c *Credential) AppId() string    { return c.App }
+func (c *Credential) DeviceId() string { return c.Device }
+func (c *Credential) UserId() *string {
+	if c.User == "" {
+		return nil
+	}
+	return &c.User
+}
+func (c *Credential) Roles() []string  { return c.RoleList }
+func (c *Credential) Scopes() []string { return c.ScopeList }
+
+type authResultKey struct{}
+
+type requestKey struct{}
+
+// withRequest attach request of unary call to ctx, so that authenticators can verify it.
+func withRequest(ctx context.Context, req interface{}) context.Context {
+	return context.WithValue(ctx, requestKey{}, req)
+}
+
+// requestFromContext return request of unary call, false for streams.
+func requestFromContext(ctx context.Context) (interface{}, bool) {
+	req := ctx.Value(requestKey{})
+	return req, req != nil
+}
+
+func NewContext(ctx context.Context, authResult web.AuthResult) context.Context {
+	return context.WithValue(ctx, authResultKey{}, authResult)
+}
+
+// FromContext return AuthResult of the call, false if the call is anonymous.
+func FromContext(ctx context.Context) (web.AuthResult, bool) {
+	authResult, ok := ctx.Value(authResultKey{}).(web.AuthResult)
+	return authResult, ok && authResult != nil
+}
+
+type chain []Authenticator
+
+// Chain try authenticators in order, the first one need authentication decides the result.
+func Chain(authenticators ...Authenticator) Authenticator {
+	return chain(authenticators)
+}
+
+func (c chain) Authenticate(ctx context.Context) (web.AuthResult, bool, error) {
+	for _, a := range c {
+		authResult, need, err := a.Authenticate(ctx)
+		if need {
+			return authResult, need, err
+		}
+	}
+	return nil, false, ErrNoCredential
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
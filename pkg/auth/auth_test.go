@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/metadata"
+)
+
+const testMethod = "/grpc.testing.TestService/UnaryCall"
+
+// methodStream make grpc.Method of ctx return the test method.
+type methodStream struct{ grpc.ServerTransportStream }
+
+func (methodStream) Method() string { return testMethod }
+
+func TestHMAC(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	a := HMAC(HMACOption{Secrets: map[string]*HMACSecret{"app": {Secret: "secret", Credential: Credential{App: "app"}}}}).(*hmacAuth)
+	a.now = func() time.Time { return now }
+	req := &testpb.SimpleRequest{Payload: &testpb.Payload{Body: []byte("transfer 100")}}
+	call := func(req interface{}, nonce string, digest string) error {
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		md := metadata.Pairs(HMACAppIDKey, "app", HMACTimestampKey, timestamp, HMACNonceKey, nonce, HMACDigestKey, digest,
+			HMACSignatureKey, Sign("secret", testMethod, "app", timestamp, nonce, digest))
+		ctx := grpc.NewContextWithServerTransportStream(metadata.NewIncomingContext(context.Background(), md), methodStream{})
+		_, _, err := a.Authenticate(withRequest(ctx, req))
+		return err
+	}
+	digest, err := Digest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := call(req, "n1", digest); err != nil {
+		t.Fatalf("signed call: %v", err)
+	}
+	if err := call(req, "n1", digest); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("replayed nonce: err = %v, want invalid credential", err)
+	}
+	tampered := &testpb.SimpleRequest{Payload: &testpb.Payload{Body: []byte("transfer 999")}}
+	if err := call(tampered, "n2", digest); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("tampered body: err = %v, want invalid credential", err)
+	}
+	if err := call(req, "", digest); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("empty nonce: err = %v, want invalid credential", err)
+	}
+	// nonce can be reused once it's out of the skew.
+	now = now.Add(defaultHMACSkew * 2)
+	if err := call(req, "n1", digest); err != nil {
+		t.Errorf("nonce out of skew: %v", err)
+	}
+	if len(a.nonces) != 1 {
+		t.Errorf("nonces kept = %d, want expired ones pruned", len(a.nonces))
+	}
+}
+
+func TestJWTExpiration(t *testing.T) {
+	secret := []byte("jwt secret")
+	f, err := ioutil.TempFile("", "jwks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_ = json.NewEncoder(f).Encode(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "oct", "kid": "k1", "alg": "HS256", "k": base64.RawURLEncoding.EncodeToString(secret)},
+	}})
+	_ = f.Close()
+
+	now := time.Unix(1600000000, 0)
+	token := func(claims map[string]interface{}) string {
+		header, _ := json.Marshal(map[string]string{"alg": "HS256", "kid": "k1"})
+		payload, _ := json.Marshal(claims)
+		input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(input))
+		return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+	authenticate := func(o JWTOption, token string) error {
+		o.JWKSFile = f.Name()
+		a, err := JWT(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.(*jwtAuth).now = func() time.Time { return now }
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+		_, _, err = a.Authenticate(ctx)
+		return err
+	}
+	if err := authenticate(JWTOption{}, token(map[string]interface{}{"sub": "u1", "exp": now.Add(time.Minute).Unix()})); err != nil {
+		t.Errorf("valid token: %v", err)
+	}
+	if err := authenticate(JWTOption{}, token(map[string]interface{}{"sub": "u1", "exp": now.Add(-time.Minute).Unix()})); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("expired token: err = %v, want invalid credential", err)
+	}
+	if err := authenticate(JWTOption{}, token(map[string]interface{}{"sub": "u1"})); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("token without exp: err = %v, want invalid credential", err)
+	}
+	if err := authenticate(JWTOption{AllowMissingExp: true}, token(map[string]interface{}{"sub": "u1"})); err != nil {
+		t.Errorf("token without exp allowed: %v", err)
+	}
+}
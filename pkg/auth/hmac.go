@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	protov2 "google.golang.org/protobuf/proto"
+
+	"github.com/go-board/thor/pkg/web"
+)
+
+const (
+	HMACAppIDKey     = "x-app-id"
+	HMACTimestampKey = "x-timestamp"
+	HMACNonceKey     = "x-nonce"
+	HMACSignatureKey = "x-signature"
+	HMACDigestKey    = "x-content-sha256"
+
+	defaultHMACSkew = time.Minute * 5
+)
+
+// HMACSecret is a secret shared with an app.
+type HMACSecret struct {
+	Secret     string `yaml:"secret"`
+	Credential `yaml:",inline"`
+}
+
+// HMACOption declares secrets keyed by app id, Skew is the tolerance of timestamp, default is 5 minutes.
+type HMACOption struct {
+	Secrets map[string]*HMACSecret `yaml:"secrets"`
+	Skew    time.Duration          `yaml:"skew"`
+}
+
+type hmacAuth struct {
+	option HMACOption
+	now    func() time.Time
+
+	mu         sync.Mutex
+	nonces     map[string]time.Time // app id and nonce -> expiry
+	pruneAfter time.Time
+}
+
+// HMAC authenticate calls signed by secret of app, see Sign for the string to sign.
+// Nonce of app is rejected if it's seen within the skew, nonces are kept in memory of the process.
+func HMAC(o HMACOption) Authenticator {
+	if o.Skew <= 0 {
+		o.Skew = defaultHMACSkew
+	}
+	return &hmacAuth{option: o, now: time.Now, nonces: make(map[string]time.Time)}
+}
+
+func (h *hmacAuth) Authenticate(ctx context.Context) (web.AuthResult, bool, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	signature := firstValue(md, HMACSignatureKey)
+	if signature == "" {
+		return nil, false, ErrNoCredential
+	}
+	appID := firstValue(md, HMACAppIDKey)
+	secret, ok := h.option.Secrets[appID]
+	if !ok {
+		return nil, true, fmt.Errorf("%w: unknown app %s", ErrInvalidCredential, appID)
+	}
+	timestamp := firstValue(md, HMACTimestampKey)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, true, fmt.Errorf("%w: bad timestamp %s", ErrInvalidCredential, timestamp)
+	}
+	if skew := h.now().Sub(time.Unix(unix, 0)); skew > h.option.Skew || skew < -h.option.Skew {
+		return nil, true, fmt.Errorf("%w: timestamp skew %s", ErrInvalidCredential, skew)
+	}
+	nonce := firstValue(md, HMACNonceKey)
+	if nonce == "" {
+		return nil, true, fmt.Errorf("%w: nonce required", ErrInvalidCredential)
+	}
+	var digest string
+	if req, ok := requestFromContext(ctx); ok {
+		if digest, err = Digest(req); err != nil {
+			return nil, true, fmt.Errorf("%w: digest request failed, %s", ErrInvalidCredential, err)
+		}
+		if !hmac.Equal([]byte(digest), []byte(firstValue(md, HMACDigestKey))) {
+			return nil, true, fmt.Errorf("%w: digest mismatch", ErrInvalidCredential)
+		}
+	}
+	method, _ := grpc.Method(ctx)
+	expected := Sign(secret.Secret, method, appID, timestamp, nonce, digest)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, true, fmt.Errorf("%w: signature mismatch", ErrInvalidCredential)
+	}
+	// only nonces of valid signatures are recorded, so that forged calls can't burn nonces of others.
+	if !h.useNonce(appID+"\n"+nonce, time.Unix(unix, 0).Add(h.option.Skew)) {
+		return nil, true, fmt.Errorf("%w: nonce replayed", ErrInvalidCredential)
+	}
+	return &secret.Credential, true, nil
+}
+
+// useNonce record nonce until expiry, false if it's already recorded.
+func (h *hmacAuth) useNonce(nonce string, expiry time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	if now.After(h.pruneAfter) {
+		for n, e := range h.nonces {
+			if now.After(e) {
+				delete(h.nonces, n)
+			}
+		}
+		h.pruneAfter = now.Add(h.option.Skew)
+	}
+	if e, ok := h.nonces[nonce]; ok && !now.After(e) {
+		return false
+	}
+	h.nonces[nonce] = expiry
+	return true
+}
+
+// Sign return hex encoded HMAC-SHA256 of `method\nappID\ntimestamp\nnonce\ndigest`,
+// timestamp is unix seconds, digest is Digest of request of unary calls and empty for streams,
+// clients send them in metadata with the signature.
+func Sign(secret string, method string, appID string, timestamp string, nonce string, digest string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%s", method, appID, timestamp, nonce, digest)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Digest return hex encoded SHA256 of request marshaled deterministically, it's sent by `x-content-sha256`.
+func Digest(req interface{}) (string, error) {
+	m, ok := req.(proto.Message)
+	if !ok {
+		return "", fmt.Errorf("auth: request %T is not a proto message", req)
+	}
+	data, err := protov2.MarshalOptions{Deterministic: true}.Marshal(proto.MessageV2(m))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/go-board/thor/pkg/web"
+)
+
+const bearerPrefix = "bearer "
+
+// JWTOption declares how JWT in `authorization: Bearer <token>` is verified,
+// keys are loaded from the local JWKS file, both `oct` keys for HS and `RSA` keys for RS are supported.
+// Tokens without exp claim are rejected unless AllowMissingExp.
+type JWTOption struct {
+	JWKSFile        string        `yaml:"jwks_file"`
+	Issuer          string        `yaml:"issuer"`
+	Audience        string        `yaml:"audience"`
+	Leeway          time.Duration `yaml:"leeway"`
+	AllowMissingExp bool          `yaml:"allow_missing_exp"`
+}
+
+// jsonWebKey is a key of JWKS, see rfc7517.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwtKey struct {
+	alg    string
+	secret []byte
+	public *rsa.PublicKey
+}
+
+type claims struct {
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt int64           `json:"exp"`
+	NotBefore int64           `json:"nbf"`
+	AppID     string          `json:"app_id"`
+	DeviceID  string          `json:"device_id"`
+	Roles     []string        `json:"roles"`
+	Scope     string          `json:"scope"`
+}
+
+type jwtAuth struct {
+	option JWTOption
+	keys   map[string]*jwtKey
+	now    func() time.Time
+}
+
+// JWT authenticate calls by bearer token signed with HS256/384/512 or RS256/384/512,
+// sub, app_id and device_id claims fill AuthResult, roles and space separated scope claims fill Principal.
+func JWT(o JWTOption) (Authenticator, error) {
+	data, err := ioutil.ReadFile(o.JWKSFile)
+	if err != nil {
+		return nil, fmt.Errorf("auth: read jwks file failed, %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	return &jwtAuth{option: o, keys: keys, now: time.Now}, nil
+}
+
+func parseJWKS(data []byte) (map[string]*jwtKey, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("auth: parse jwks failed, %w", err)
+	}
+	keys := make(map[string]*jwtKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		key := &jwtKey{alg: k.Alg}
+		switch k.Kty {
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil {
+				return nil, fmt.Errorf("auth: bad oct key %s, %w", k.Kid, err)
+			}
+			key.secret = secret
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("auth: bad rsa key %s, %w", k.Kid, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("auth: bad rsa key %s, %w", k.Kid, err)
+			}
+			key.public = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		default:
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (j *jwtAuth) Authenticate(ctx context.Context) (web.AuthResult, bool, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	authorization := firstValue(md, "authorization")
+	if len(authorization) <= len(bearerPrefix) || !strings.EqualFold(authorization[:len(bearerPrefix)], bearerPrefix) {
+		return nil, false, ErrNoCredential
+	}
+	c, err := j.verify(authorization[len(bearerPrefix):])
+	if err != nil {
+		return nil, true, fmt.Errorf("%w: %s", ErrInvalidCredential, err)
+	}
+	return &Credential{
+		App:       c.AppID,
+		Device:    c.DeviceID,
+		User:      c.Subject,
+		RoleList:  c.Roles,
+		ScopeList: strings.Fields(c.Scope),
+	}, true, nil
+}
+
+func (j *jwtAuth) verify(token string) (*claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	key, ok := j.keys[header.Kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %s", header.Kid)
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, fmt.Errorf("alg %s not allowed for key %s", header.Alg, header.Kid)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("bad signature, %w", err)
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+	c := &claims{}
+	if err := decodeSegment(parts[1], c); err != nil {
+		return nil, err
+	}
+	return c, j.validate(c)
+}
+
+func verifySignature(alg string, key *jwtKey, signingInput string, signature []byte) error {
+	if len(alg) != 5 {
+		return fmt.Errorf("unsupported alg %s", alg)
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported alg %s", alg)
+	}
+	switch {
+	case strings.HasPrefix(alg, "HS") && key.secret != nil:
+		mac := hmac.New(hash.New, key.secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("signature mismatch")
+		}
+		return nil
+	case strings.HasPrefix(alg, "RS") && key.public != nil:
+		h := hash.New()
+		h.Write([]byte(signingInput))
+		return rsa.VerifyPKCS1v15(key.public, hash, h.Sum(nil), signature)
+	}
+	return fmt.Errorf("alg %s mismatch key type", alg)
+}
+
+func (j *jwtAuth) validate(c *claims) error {
+	now := j.now()
+	if c.ExpiresAt == 0 && !j.option.AllowMissingExp {
+		return fmt.Errorf("token without exp")
+	}
+	if c.ExpiresAt != 0 && now.After(time.Unix(c.ExpiresAt, 0).Add(j.option.Leeway)) {
+		return fmt.Errorf("token expired")
+	}
+	if c.NotBefore != 0 && now.Before(time.Unix(c.NotBefore, 0).Add(-j.option.Leeway)) {
+		return fmt.Errorf("token not valid yet")
+	}
+	if j.option.Issuer != "" && c.Issuer != j.option.Issuer {
+		return fmt.Errorf("unexpected issuer %s", c.Issuer)
+	}
+	if j.option.Audience != "" {
+		// aud is either a string or an array of strings.
+		var audiences []string
+		var audience string
+		if err := json.Unmarshal(c.Audience, &audience); err == nil {
+			audiences = []string{audience}
+		} else {
+			_ = json.Unmarshal(c.Audience, &audiences)
+		}
+		for _, aud := range audiences {
+			if aud == j.option.Audience {
+				return nil
+			}
+		}
+		return fmt.Errorf("unexpected audience %s", c.Audience)
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("bad segment, %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("bad segment, %w", err)
+	}
+	return nil
+}
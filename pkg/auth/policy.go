@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/go-board/thor/pkg/errors"
+)
+
+const (
+	ReasonUnauthenticated  = "UNAUTHENTICATED"
+	ReasonPermissionDenied = "PERMISSION_DENIED"
+
+	defaultPolicyKey = "*"
+	healthService    = "/grpc.health.v1.Health/"
+)
+
+// MethodPolicy is the authorization rule of methods, caller must have any of Roles and all of Scopes.
+type MethodPolicy struct {
+	Public bool     `yaml:"public"`
+	Roles  []string `yaml:"roles"`
+	Scopes []string `yaml:"scopes"`
+}
+
+// Policy declares authorization rules keyed by full method like `/pkg.Service/Method`,
+// service like `/pkg.Service/*` or `*` for the default, methods matched nothing need authentication only.
+// Methods in Public and health checks are allowed without credentials.
+type Policy struct {
+	Public  []string                `yaml:"public"`
+	Methods map[string]MethodPolicy `yaml:"methods"`
+}
+
+func (p Policy) lookup(method string) MethodPolicy {
+	if strings.HasPrefix(method, healthService) {
+		return MethodPolicy{Public: true}
+	}
+	service := method[:strings.LastIndex(method, "/")+1] + "*"
+	for _, public := range p.Public {
+		if public == method || public == service {
+			return MethodPolicy{Public: true}
+		}
+	}
+	for _, key := range []string{method, service, defaultPolicyKey} {
+		if mp, ok := p.Methods[key]; ok {
+			return mp
+		}
+	}
+	return MethodPolicy{}
+}
+
+// Config is the auth configuration, authenticators configured are chained in order of api key, hmac and jwt.
+type Config struct {
+	APIKey *APIKeyOption `yaml:"api_key"`
+	HMAC   *HMACOption   `yaml:"hmac"`
+	JWT    *JWTOption    `yaml:"jwt"`
+	Policy Policy        `yaml:"policy"`
+}
+
+// New create authenticator by c, nil is returned if nothing configured.
+func New(c Config) (Authenticator, error) {
+	var authenticators []Authenticator
+	if c.APIKey != nil {
+		authenticators = append(authenticators, APIKey(*c.APIKey))
+	}
+	if c.HMAC != nil {
+		authenticators = append(authenticators, HMAC(*c.HMAC))
+	}
+	if c.JWT != nil {
+		a, err := JWT(*c.JWT)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, a)
+	}
+	switch len(authenticators) {
+	case 0:
+		return nil, nil
+	case 1:
+		return authenticators[0], nil
+	}
+	return Chain(authenticators...), nil
+}
+
+// Interceptor authenticate calls and authorize them by policy, AuthResult is available by FromContext.
+type Interceptor struct {
+	authenticator Authenticator
+	policy        Policy
+}
+
+func NewInterceptor(a Authenticator, p Policy) *Interceptor {
+	return &Interceptor{authenticator: a, policy: p}
+}
+
+func (i *Interceptor) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := i.authorize(withRequest(ctx, req), info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (i *Interceptor) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := i.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authServerStream{ServerStream: ss, ctx: ctx})
+}
+
+func (i *Interceptor) authorize(ctx context.Context, method string) (context.Context, error) {
+	mp := i.policy.lookup(method)
+	authResult, need, err := i.authenticator.Authenticate(ctx)
+	if err != nil && need {
+		return nil, errors.Wrap(err, codes.Unauthenticated, ReasonUnauthenticated, "invalid credential")
+	}
+	if authResult == nil {
+		if mp.Public {
+			return ctx, nil
+		}
+		return nil, errors.New(codes.Unauthenticated, ReasonUnauthenticated, "credential required")
+	}
+	ctx = NewContext(ctx, authResult)
+	if mp.Public || (len(mp.Roles) == 0 && len(mp.Scopes) == 0) {
+		return ctx, nil
+	}
+	principal, _ := authResult.(Principal)
+	if principal == nil || !containsAny(principal.Roles(), mp.Roles) || !containsAll(principal.Scopes(), mp.Scopes) {
+		return nil, errors.Newf(codes.PermissionDenied, ReasonPermissionDenied, "permission denied for %s", method)
+	}
+	return ctx, nil
+}
+
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authServerStream) Context() context.Context { return s.ctx }
+
+func containsAny(have []string, want []string) bool {
+	if len(want) == 0 {
+		return true
+	}
+	for _, w := range want {
+		for _, h := range have {
+			if h == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsAll(have []string, want []string) bool {
+	for _, w := range want {
+		if !containsAny(have, []string{w}) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,15 +2,158 @@ package client
 
 import (
 	"context"
+	"fmt"
 
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	grpc_zap "github.com/grpc-ecosystem/go-grpc-middleware/logging/zap"
+	grpc_opentracing "github.com/grpc-ecosystem/go-grpc-middleware/tracing/opentracing"
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/encoding/gzip"
+
+	"github.com/go-board/thor/pkg/errors"
+	"github.com/go-board/thor/pkg/interceptors"
+	_ "github.com/go-board/thor/pkg/lb"
+	"github.com/go-board/thor/pkg/logger"
 )
 
+// New create a client connection to target, dial is non-blocking.
+// Interceptors are chained in order of prepended, built-in and appended,
+// built-in interceptors are errors, tracing, metadata propagation, logging, policy timeout, deadline, circuit breaker, bulkhead, retry, hedge, metrics and fault,
+// fault is the innermost one so that injected faults are seen by others like failures of servers,
+// circuit breaker is outside bulkhead so that rejected calls don't wait for a slot, and outside retry so that they aren't retried,
+// metrics is inside retry and hedge so that each attempt is counted.
+// Timeout, retry and hedge declared by method tables generated by protoc-gen-thor are applied per call unless DisablePolicy.
+func New(target string, options ...Option) (*grpc.ClientConn, error) {
+	return NewContext(context.Background(), target, options...)
+}
+
+// NewContext is New with ctx used to dial.
+func NewContext(ctx context.Context, target string, options ...Option) (*grpc.ClientConn, error) {
+	opts := &Options{}
+	for _, option := range options {
+		option(opts)
+	}
+	return grpc.DialContext(ctx, target, dialOptions(opts)...)
+}
+
+// Deprecated: use New instead.
 func NewClient(ctx context.Context, target string) (*grpc.ClientConn, error) {
-	return grpc.DialContext(
-		ctx,
-		target,
-		grpc.WithChainUnaryInterceptor(),
-		grpc.WithChainStreamInterceptor(),
-	)
+	return NewContext(ctx, target)
+}
+
+// policyOptions return retry and hedge options of o, if they're not set and policy isn't disabled,
+// interceptors that act only on methods declared by method tables are installed,
+// so that the declaration is looked up per call whatever name the conn is dialed by.
+func policyOptions(o *Options) ([]interceptors.RetryOption, []interceptors.HedgeOption) {
+	retryOptions, hedgeOptions := o.RetryOptions, o.HedgeOptions
+	if o.DisablePolicy {
+		return retryOptions, hedgeOptions
+	}
+	if retryOptions == nil {
+		retryOptions = []interceptors.RetryOption{interceptors.MaxRetries(0)}
+	}
+	if hedgeOptions == nil {
+		hedgeOptions = []interceptors.HedgeOption{interceptors.HedgeMaxAttempts(1)}
+	}
+	return retryOptions, hedgeOptions
+}
+
+func dialOptions(o *Options) []grpc.DialOption {
+	retryOptions, hedgeOptions := policyOptions(o)
+
+	unary := append([]grpc.UnaryClientInterceptor{}, o.PrependUnaryInterceptors...)
+	stream := append([]grpc.StreamClientInterceptor{}, o.PrependStreamInterceptors...)
+	if !o.DisableErrors {
+		unary = append(unary, errors.UnaryClientInterceptor)
+	}
+	if !o.DisableTracing {
+		unary = append(unary, grpc_opentracing.UnaryClientInterceptor())
+		stream = append(stream, grpc_opentracing.StreamClientInterceptor())
+	}
+	if len(o.PropagateKeys) > 0 {
+		p := newPropagator(o.PropagateKeys)
+		unary = append(unary, p.UnaryClientInterceptor)
+		stream = append(stream, p.StreamClientInterceptor)
+	}
+	if !o.DisableLogging {
+		l := o.Logger
+		if l == nil {
+			l = logger.Global()
+		}
+		unary = append(unary, grpc_zap.UnaryClientInterceptor(l))
+		stream = append(stream, grpc_zap.StreamClientInterceptor(l))
+	}
+	if !o.DisablePolicy {
+		unary = append(unary, policyUnaryInterceptor)
+		stream = append(stream, policyStreamInterceptor)
+	}
+	if o.DeadlineMargin > 0 || o.DefaultTimeout > 0 {
+		d := interceptors.NewGrpcDeadline(interceptors.DeadlineMargin(o.DeadlineMargin), interceptors.DefaultTimeout(o.DefaultTimeout))
+		unary = append(unary, d.UnaryClientInterceptor)
+		stream = append(stream, d.StreamClientInterceptor)
+	}
+	if o.BreakerOptions != nil {
+		b := interceptors.NewGrpcBreaker(o.BreakerOptions...)
+		unary = append(unary, b.UnaryClientInterceptor)
+		stream = append(stream, b.StreamClientInterceptor)
+	}
+	if o.Bulkheads != nil {
+		unary = append(unary, o.Bulkheads.UnaryClientInterceptor)
+		stream = append(stream, o.Bulkheads.StreamClientInterceptor)
+	}
+	if retryOptions != nil {
+		r := interceptors.NewGrpcRetry(retryOptions...)
+		unary = append(unary, r.UnaryClientInterceptor)
+		stream = append(stream, r.StreamClientInterceptor)
+	}
+	if hedgeOptions != nil {
+		h := interceptors.NewGrpcHedge(hedgeOptions...)
+		unary = append(unary, h.UnaryClientInterceptor)
+	}
+	if !o.DisableMetrics {
+		unary = append(unary, grpc_prometheus.UnaryClientInterceptor)
+		stream = append(stream, grpc_prometheus.StreamClientInterceptor)
+	}
+	if o.FaultInjector != nil {
+		unary = append(unary, o.FaultInjector.UnaryClientInterceptor)
+		stream = append(stream, o.FaultInjector.StreamClientInterceptor)
+	}
+	unary = append(unary, o.AppendUnaryInterceptors...)
+	stream = append(stream, o.AppendStreamInterceptors...)
+
+	dialOptions := []grpc.DialOption{
+		grpc.WithUnaryInterceptor(grpc_middleware.ChainUnaryClient(unary...)),
+		grpc.WithStreamInterceptor(grpc_middleware.ChainStreamClient(stream...)),
+	}
+	if o.Creds != nil {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(o.Creds))
+	} else {
+		dialOptions = append(dialOptions, grpc.WithInsecure())
+	}
+	if o.KeepaliveParams != nil {
+		dialOptions = append(dialOptions, grpc.WithKeepaliveParams(*o.KeepaliveParams))
+	}
+	balancer := o.Balancer
+	// hedges declared by method tables are spread well by pool, which uses `round_robin` by default.
+	if balancer == "" && o.HedgeOptions != nil {
+		balancer = "round_robin"
+	}
+	if balancer != "" {
+		dialOptions = append(dialOptions, grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingPolicy":%q}`, balancer)))
+	}
+	var callOptions []grpc.CallOption
+	if o.Compressor != "" {
+		callOptions = append(callOptions, grpc.UseCompressor(o.Compressor))
+	}
+	if o.MaxRecvMsgSize > 0 {
+		callOptions = append(callOptions, grpc.MaxCallRecvMsgSize(o.MaxRecvMsgSize))
+	}
+	if o.MaxSendMsgSize > 0 {
+		callOptions = append(callOptions, grpc.MaxCallSendMsgSize(o.MaxSendMsgSize))
+	}
+	if len(callOptions) > 0 {
+		dialOptions = append(dialOptions, grpc.WithDefaultCallOptions(callOptions...))
+	}
+	return append(dialOptions, o.DialOptions...)
 }
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/go-board/thor/pkg/errors"
+	"github.com/go-board/thor/pkg/interceptors"
+	"github.com/go-board/thor/pkg/policy"
+)
+
+func TestPolicyPerCall(t *testing.T) {
+	ln := bufconn.Listen(1 << 20)
+	s := &unavailableService{keys: make(chan []string, 1)}
+	srv := grpc.NewServer()
+	testpb.RegisterTestServiceServer(srv, s)
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Stop()
+	// the conn is dialed by a name other than the service, like clients of pool do.
+	cc, err := New("passthrough:///registered-name", DisableLogging(), DisableMetrics(), DisableTracing(),
+		DialOptions(grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return ln.Dial() })))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	client := testpb.NewTestServiceClient(cc)
+	attempts := func() int64 {
+		atomic.StoreInt64(&s.calls, 0)
+		_, _ = client.UnaryCall(context.Background(), &testpb.SimpleRequest{})
+		return atomic.LoadInt64(&s.calls)
+	}
+
+	// retries declared by another method don't affect the call.
+	policy.Register("grpc.testing.TestService", policy.Service{"EmptyCall": {Idempotent: true, MaxRetries: 2}})
+	defer policy.Register("grpc.testing.TestService", nil)
+	if n := attempts(); n != 1 {
+		t.Errorf("attempts of method not declared = %d, want 1", n)
+	}
+	policy.Register("grpc.testing.TestService", policy.Service{"UnaryCall": {Idempotent: true, MaxRetries: 2}})
+	if n := attempts(); n != 3 {
+		t.Errorf("attempts of method declared = %d, want 3", n)
+	}
+	policy.Register("grpc.testing.TestService", policy.Service{"UnaryCall": {Idempotent: true, Hedge: &policy.Hedge{MaxAttempts: 2}}})
+	if n := attempts(); n != 2 {
+		t.Errorf("attempts of method hedged = %d, want 2", n)
+	}
+}
+
+// unavailableService fail unary calls as unavailable, and record values of key propagated.
+type unavailableService struct {
+	testpb.UnimplementedTestServiceServer
+	calls int64
+	keys  chan []string
+}
+
+func (s *unavailableService) UnaryCall(ctx context.Context, req *testpb.SimpleRequest) (*testpb.SimpleResponse, error) {
+	atomic.AddInt64(&s.calls, 1)
+	md, _ := metadata.FromIncomingContext(ctx)
+	select {
+	case s.keys <- md.Get("x-key"):
+	default:
+	}
+	return nil, status.Error(codes.Unavailable, "unavailable")
+}
+
+func TestInterceptorOrder(t *testing.T) {
+	ln := bufconn.Listen(1 << 20)
+	s := &unavailableService{keys: make(chan []string, 1)}
+	srv := grpc.NewServer()
+	testpb.RegisterTestServiceServer(srv, s)
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Stop()
+
+	var outer, inner []error
+	var budget time.Duration
+	var keys []string
+	cc, err := New("bufnet", DisableLogging(), DisableMetrics(), DisableTracing(), DisablePolicy(),
+		PropagateKeys("x-key"),
+		DeadlineMargin(time.Millisecond*100),
+		Retry(interceptors.MaxRetries(2), interceptors.RetryBackoff(time.Millisecond, time.Millisecond)),
+		CircuitBreaker(interceptors.BreakerMinRequests(2), interceptors.BreakerErrorRate(0.5)),
+		PrependUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			outer = append(outer, err)
+			return err
+		}),
+		UnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			keys = md.Get("x-key")
+			if dl, ok := ctx.Deadline(); ok {
+				budget = time.Until(dl)
+			}
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			inner = append(inner, err)
+			return err
+		}),
+		DialOptions(grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return ln.Dial() })),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	client := testpb.NewTestServiceClient(cc)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-key", "value"))
+	ctx = metadata.AppendToOutgoingContext(ctx, interceptors.IdempotentKey, "1")
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		_, _ = client.UnaryCall(ctx, &testpb.SimpleRequest{})
+	}
+
+	// breaker is outside retry, so it counts each call once, and the third call is rejected without any attempt.
+	if calls := atomic.LoadInt64(&s.calls); calls != 6 {
+		t.Errorf("attempts = %d, want 6 of 2 calls retried twice", calls)
+	}
+	if len(outer) != 3 || len(inner) != 6 {
+		t.Fatalf("calls seen by outer = %d, inner = %d, want 3, 6", len(outer), len(inner))
+	}
+	// errors are converted outside all others, interceptors inside see status errors.
+	var e *errors.Error
+	for i, err := range outer {
+		if !stderrors.As(err, &e) || e.Code != codes.Unavailable {
+			t.Errorf("err of call %d seen by outer = %#v, want converted unavailable", i, err)
+		}
+	}
+	if stderrors.As(inner[0], &e) {
+		t.Errorf("err seen by inner = %#v, want status error", inner[0])
+	}
+	if len(keys) != 1 || keys[0] != "value" {
+		t.Errorf("keys seen by inner = %v, want propagated", keys)
+	}
+	if got := <-s.keys; len(got) != 1 || got[0] != "value" {
+		t.Errorf("keys seen by server = %v, want propagated", got)
+	}
+	if budget <= 0 || budget > time.Millisecond*900 {
+		t.Errorf("budget seen by inner = %s, want margin subtracted", budget)
+	}
+}
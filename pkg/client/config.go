@@ -0,0 +1,184 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/go-board/thor/pkg/interceptors"
+)
+
+// Config is a client declared in `clients` section of configuration.
+type Config struct {
+	// Target is the dial target, like `dns:///host:port`.
+	Target         string           `yaml:"target"`
+	DeadlineMargin time.Duration    `yaml:"deadline_margin"`
+	Timeout        time.Duration    `yaml:"timeout"`
+	Retry          *RetryConfig     `yaml:"retry"`
+	Hedge          *HedgeConfig     `yaml:"hedge"`
+	PropagateKeys  []string         `yaml:"propagate_keys"`
+	Keepalive      *KeepaliveConfig `yaml:"keepalive"`
+	TLS            *TLSFiles        `yaml:"tls"`
+	Compressor     string           `yaml:"compressor"`
+	Balancer       string           `yaml:"balancer"`
+	MaxRecvMsgSize int              `yaml:"max_recv_msg_size"`
+	MaxSendMsgSize int              `yaml:"max_send_msg_size"`
+}
+
+type RetryConfig struct {
+	MaxRetries        int           `yaml:"max_retries"`
+	Codes             []int         `yaml:"codes"`
+	Backoff           time.Duration `yaml:"backoff"`
+	MaxBackoff        time.Duration `yaml:"max_backoff"`
+	Jitter            float64       `yaml:"jitter"`
+	PerAttemptTimeout time.Duration `yaml:"per_attempt_timeout"`
+	// BudgetRatio limit retries to ratio of calls plus BudgetMinPerSecond, no budget if zero.
+	BudgetRatio        float64 `yaml:"budget_ratio"`
+	BudgetMinPerSecond int     `yaml:"budget_min_per_second"`
+	// StreamBuffer is the maximum bytes of sent messages buffered to replay a stream.
+	StreamBuffer int `yaml:"stream_buffer"`
+}
+
+// Options convert c to retry options, fields not set are skipped.
+func (c RetryConfig) Options() []interceptors.RetryOption {
+	var options []interceptors.RetryOption
+	if c.MaxRetries > 0 {
+		options = append(options, interceptors.MaxRetries(c.MaxRetries))
+	}
+	if len(c.Codes) > 0 {
+		options = append(options, interceptors.RetryCodes(c.Codes...))
+	}
+	if c.Backoff > 0 && c.MaxBackoff > 0 {
+		options = append(options, interceptors.RetryBackoff(c.Backoff, c.MaxBackoff))
+	}
+	if c.Jitter > 0 {
+		options = append(options, interceptors.RetryJitter(c.Jitter))
+	}
+	if c.PerAttemptTimeout > 0 {
+		options = append(options, interceptors.RetryPerAttemptTimeout(c.PerAttemptTimeout))
+	}
+	if c.BudgetRatio > 0 {
+		options = append(options, interceptors.RetryBudget(c.BudgetRatio, c.BudgetMinPerSecond))
+	}
+	if c.StreamBuffer > 0 {
+		options = append(options, interceptors.RetryStreamBuffer(c.StreamBuffer))
+	}
+	return options
+}
+
+type HedgeConfig struct {
+	// Delay before next attempt, Percentile of recent latencies is used instead if set, like 0.95.
+	Delay       time.Duration `yaml:"delay"`
+	Percentile  float64       `yaml:"percentile"`
+	MaxAttempts int           `yaml:"max_attempts"`
+	Codes       []int         `yaml:"codes"`
+	// BudgetRatio limit hedged attempts to ratio of calls plus BudgetMinPerSecond.
+	BudgetRatio        float64 `yaml:"budget_ratio"`
+	BudgetMinPerSecond int     `yaml:"budget_min_per_second"`
+}
+
+// Options convert c to hedge options, fields not set are skipped.
+func (c HedgeConfig) Options() []interceptors.HedgeOption {
+	var options []interceptors.HedgeOption
+	if c.Delay > 0 {
+		options = append(options, interceptors.HedgeDelay(c.Delay))
+	}
+	if c.Percentile > 0 {
+		options = append(options, interceptors.HedgePercentile(c.Percentile))
+	}
+	if c.MaxAttempts > 0 {
+		options = append(options, interceptors.HedgeMaxAttempts(c.MaxAttempts))
+	}
+	if len(c.Codes) > 0 {
+		options = append(options, interceptors.HedgeCodes(c.Codes...))
+	}
+	if c.BudgetRatio > 0 {
+		options = append(options, interceptors.HedgeBudget(c.BudgetRatio, c.BudgetMinPerSecond))
+	}
+	return options
+}
+
+type KeepaliveConfig struct {
+	Time                time.Duration `yaml:"time"`
+	Timeout             time.Duration `yaml:"timeout"`
+	PermitWithoutStream bool          `yaml:"permit_without_stream"`
+}
+
+type TLSFiles struct {
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// Options convert c to client options, fields not set are skipped so that they don't override defaults,
+// certificates are loaded here.
+func (c Config) Options() ([]Option, error) {
+	options := []Option{PropagateKeys(c.PropagateKeys...)}
+	if c.DeadlineMargin > 0 {
+		options = append(options, DeadlineMargin(c.DeadlineMargin))
+	}
+	if c.Timeout > 0 {
+		options = append(options, DefaultTimeout(c.Timeout))
+	}
+	if c.Compressor != "" {
+		options = append(options, Compressor(c.Compressor))
+	}
+	if c.Balancer != "" {
+		options = append(options, Balancer(c.Balancer))
+	}
+	if c.MaxRecvMsgSize > 0 {
+		options = append(options, MaxRecvMsgSize(c.MaxRecvMsgSize))
+	}
+	if c.MaxSendMsgSize > 0 {
+		options = append(options, MaxSendMsgSize(c.MaxSendMsgSize))
+	}
+	if c.Retry != nil {
+		options = append(options, Retry(c.Retry.Options()...))
+	}
+	if c.Hedge != nil {
+		options = append(options, Hedge(c.Hedge.Options()...))
+	}
+	if c.Keepalive != nil {
+		options = append(options, KeepaliveParams(keepalive.ClientParameters{
+			Time:                c.Keepalive.Time,
+			Timeout:             c.Keepalive.Timeout,
+			PermitWithoutStream: c.Keepalive.PermitWithoutStream,
+		}))
+	}
+	if c.TLS != nil {
+		cfg, err := c.TLS.Config()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, TLSConfig(cfg))
+	}
+	return options, nil
+}
+
+func (c TLSFiles) Config() (*tls.Config, error) {
+	cfg := &tls.Config{ServerName: c.ServerName, InsecureSkipVerify: c.InsecureSkipVerify}
+	if c.CAFile != "" {
+		ca, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("client: read ca file failed, %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("client: no certificate found in %s", c.CAFile)
+		}
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("client: load key pair failed, %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
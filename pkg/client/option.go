@@ -0,0 +1,236 @@
+package client
+
+import (
+	"crypto/tls"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/go-board/thor/pkg/fault"
+	"github.com/go-board/thor/pkg/interceptors"
+)
+
+type Option func(o *Options)
+
+// Options is the grpc client configuration.
+type Options struct {
+	// Interceptors chained before the built-in interceptors, the first one is the outermost.
+	PrependUnaryInterceptors  []grpc.UnaryClientInterceptor
+	PrependStreamInterceptors []grpc.StreamClientInterceptor
+	// Interceptors chained after the built-in interceptors, the last one is the innermost.
+	AppendUnaryInterceptors  []grpc.UnaryClientInterceptor
+	AppendStreamInterceptors []grpc.StreamClientInterceptor
+
+	DisableTracing bool
+	DisableLogging bool
+	DisableMetrics bool
+	DisableErrors  bool
+	// DisablePolicy ignore method table generated by protoc-gen-thor.
+	DisablePolicy bool
+
+	// Logger used by logging interceptor, if nil, zap.L() is read at every call.
+	Logger *zap.Logger
+
+	// DeadlineMargin is subtracted from deadline of ctx propagated to downstream, including deadline set by the caller,
+	// DefaultTimeout is applied to calls without deadline.
+	DeadlineMargin time.Duration
+	DefaultTimeout time.Duration
+	// RetryOptions enable retry of idempotent calls if not nil.
+	RetryOptions []interceptors.RetryOption
+	// HedgeOptions enable hedging of idempotent unary calls if not nil.
+	HedgeOptions []interceptors.HedgeOption
+	// BreakerOptions enable circuit breaker if not nil.
+	BreakerOptions []interceptors.BreakerOption
+	// Bulkheads bound concurrent calls per target if not nil.
+	Bulkheads *interceptors.Bulkheads
+	// FaultInjector inject faults into calls by its rules if not nil.
+	FaultInjector *fault.Injector
+	// PropagateKeys is incoming metadata keys copied to outgoing calls.
+	PropagateKeys []string
+
+	KeepaliveParams *keepalive.ClientParameters
+	// Creds is the transport credentials, insecure is used if nil.
+	Creds credentials.TransportCredentials
+	// Compressor is the name of registered compressor applied to all calls, like `gzip`.
+	Compressor string
+	// Balancer is the load balancing policy, like `pick_first`, `round_robin` and `shard`.
+	Balancer       string
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+
+	// DialOptions is passed to grpc.DialContext directly, after all other options.
+	DialOptions []grpc.DialOption
+}
+
+// UnaryInterceptor add interceptors after built-in interceptors.
+func UnaryInterceptor(interceptors ...grpc.UnaryClientInterceptor) Option {
+	return func(o *Options) {
+		o.AppendUnaryInterceptors = append(o.AppendUnaryInterceptors, interceptors...)
+	}
+}
+
+// StreamInterceptor add interceptors after built-in interceptors.
+func StreamInterceptor(interceptors ...grpc.StreamClientInterceptor) Option {
+	return func(o *Options) {
+		o.AppendStreamInterceptors = append(o.AppendStreamInterceptors, interceptors...)
+	}
+}
+
+// PrependUnaryInterceptor add interceptors before built-in interceptors.
+func PrependUnaryInterceptor(interceptors ...grpc.UnaryClientInterceptor) Option {
+	return func(o *Options) {
+		o.PrependUnaryInterceptors = append(o.PrependUnaryInterceptors, interceptors...)
+	}
+}
+
+// PrependStreamInterceptor add interceptors before built-in interceptors.
+func PrependStreamInterceptor(interceptors ...grpc.StreamClientInterceptor) Option {
+	return func(o *Options) {
+		o.PrependStreamInterceptors = append(o.PrependStreamInterceptors, interceptors...)
+	}
+}
+
+func DisableTracing() Option {
+	return func(o *Options) {
+		o.DisableTracing = true
+	}
+}
+
+func DisableLogging() Option {
+	return func(o *Options) {
+		o.DisableLogging = true
+	}
+}
+
+func DisableMetrics() Option {
+	return func(o *Options) {
+		o.DisableMetrics = true
+	}
+}
+
+// DisableErrors keep errors returned as grpc status instead of converting them to errors.Error.
+func DisableErrors() Option {
+	return func(o *Options) {
+		o.DisableErrors = true
+	}
+}
+
+// DisablePolicy don't apply timeout of method table generated by protoc-gen-thor,
+// nor enable retry and hedge for methods declared by it, the table is still read if Retry or Hedge is enabled.
+func DisablePolicy() Option {
+	return func(o *Options) {
+		o.DisablePolicy = true
+	}
+}
+
+func Logger(l *zap.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+func DeadlineMargin(margin time.Duration) Option {
+	return func(o *Options) {
+		o.DeadlineMargin = margin
+	}
+}
+
+func DefaultTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.DefaultTimeout = timeout
+	}
+}
+
+// Retry enable retry of idempotent calls.
+func Retry(options ...interceptors.RetryOption) Option {
+	return func(o *Options) {
+		o.RetryOptions = append(o.RetryOptions[:len(o.RetryOptions):len(o.RetryOptions)], options...)
+	}
+}
+
+// Hedge enable hedging of idempotent unary calls, `round_robin` balancer is used if Balancer isn't set,
+// so that attempts are sent to different sub connections.
+func Hedge(options ...interceptors.HedgeOption) Option {
+	return func(o *Options) {
+		o.HedgeOptions = append(o.HedgeOptions[:len(o.HedgeOptions):len(o.HedgeOptions)], options...)
+	}
+}
+
+// CircuitBreaker enable circuit breaker per method.
+func CircuitBreaker(options ...interceptors.BreakerOption) Option {
+	return func(o *Options) {
+		o.BreakerOptions = append(o.BreakerOptions[:len(o.BreakerOptions):len(o.BreakerOptions)], options...)
+	}
+}
+
+// Bulkhead bound concurrent calls per target with bulkheads of b.
+func Bulkhead(b *interceptors.Bulkheads) Option {
+	return func(o *Options) {
+		o.Bulkheads = b
+	}
+}
+
+// FaultInjection inject faults into calls by rules of i, rules of server side are ignored.
+func FaultInjection(i *fault.Injector) Option {
+	return func(o *Options) {
+		o.FaultInjector = i
+	}
+}
+
+func PropagateKeys(keys ...string) Option {
+	return func(o *Options) {
+		o.PropagateKeys = append(o.PropagateKeys, keys...)
+	}
+}
+
+func KeepaliveParams(p keepalive.ClientParameters) Option {
+	return func(o *Options) {
+		o.KeepaliveParams = &p
+	}
+}
+
+func Creds(c credentials.TransportCredentials) Option {
+	return func(o *Options) {
+		o.Creds = c
+	}
+}
+
+func TLSConfig(c *tls.Config) Option {
+	return func(o *Options) {
+		o.Creds = credentials.NewTLS(c)
+	}
+}
+
+func Compressor(name string) Option {
+	return func(o *Options) {
+		o.Compressor = name
+	}
+}
+
+func Balancer(name string) Option {
+	return func(o *Options) {
+		o.Balancer = name
+	}
+}
+
+func MaxRecvMsgSize(size int) Option {
+	return func(o *Options) {
+		o.MaxRecvMsgSize = size
+	}
+}
+
+func MaxSendMsgSize(size int) Option {
+	return func(o *Options) {
+		o.MaxSendMsgSize = size
+	}
+}
+
+// DialOptions add raw grpc dial options, which may override options above.
+func DialOptions(options ...grpc.DialOption) Option {
+	return func(o *Options) {
+		o.DialOptions = append(o.DialOptions, options...)
+	}
+}
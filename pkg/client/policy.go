@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/go-board/thor/pkg/policy"
+)
+
+// policyTimeout return timeout of method declared in method table, if it's shorter than deadline of ctx.
+func policyTimeout(ctx context.Context, method string) (time.Duration, bool) {
+	p, ok := policy.Lookup(method)
+	if !ok || p.Timeout <= 0 {
+		return 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= p.Timeout {
+		return 0, false
+	}
+	return p.Timeout, true
+}
+
+func policyUnaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if timeout, ok := policyTimeout(ctx, method); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func policyStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	timeout, ok := policyTimeout(ctx, method)
+	if !ok {
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		<-stream.Context().Done()
+		cancel()
+	}()
+	return stream, nil
+}
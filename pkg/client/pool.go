@@ -0,0 +1,321 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/resolver"
+
+	"github.com/go-board/thor/pkg/lb"
+	"github.com/go-board/thor/pkg/metric"
+	"github.com/go-board/thor/pkg/registry"
+)
+
+const (
+	defaultIdleTTL  = time.Minute * 10
+	defaultBalancer = "round_robin"
+)
+
+var (
+	ErrPoolClosed = errors.New("client: pool closed")
+
+	connStateGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "grpc_client_connections",
+		Help: "Number of pooled client connections per service in each connectivity state.",
+	}, []string{"service", "state"})
+	dialCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_dials_total",
+		Help: "Total number of pooled client connections dialed per service, including redials after shutdown or idle.",
+	}, []string{"service"})
+)
+
+type PoolOption func(p *Pool)
+
+// IdleTTL close connections not used for ttl, they are dialed again by next Get, default is 10 minutes.
+func IdleTTL(ttl time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.idleTTL = ttl
+	}
+}
+
+// ResolverOptions configure resolvers watching services from registry.
+func ResolverOptions(options ...lb.ResolverOption) PoolOption {
+	return func(p *Pool) {
+		p.resolverOptions = append(p.resolverOptions, options...)
+	}
+}
+
+// Configs declare clients by service name, Target of config is dialed instead of resolving from registry.
+func Configs(configs map[string]Config) PoolOption {
+	return func(p *Pool) {
+		p.configs = configs
+	}
+}
+
+// ClientOptions is applied to all pooled connections, before options of Configs,
+// `round_robin` balancer is used unless Balancer is set.
+func ClientOptions(options ...Option) PoolOption {
+	return func(p *Pool) {
+		p.options = append(p.options, options...)
+	}
+}
+
+type pooledConn struct {
+	service  string
+	cc       *grpc.ClientConn
+	lastUsed int64
+	inflight int64 // calls and streams not finished
+}
+
+func (c *pooledConn) touch() { atomic.StoreInt64(&c.lastUsed, time.Now().UnixNano()) }
+
+// idle report whether c has no inflight call and isn't used for ttl.
+func (c *pooledConn) idle(ttl time.Duration) bool {
+	return atomic.LoadInt64(&c.inflight) == 0 && time.Since(time.Unix(0, atomic.LoadInt64(&c.lastUsed))) > ttl
+}
+
+func (c *pooledConn) begin() {
+	atomic.AddInt64(&c.inflight, 1)
+	c.touch()
+}
+
+func (c *pooledConn) end() {
+	c.touch()
+	atomic.AddInt64(&c.inflight, -1)
+}
+
+func (c *pooledConn) unaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	c.begin()
+	defer c.end()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func (c *pooledConn) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	c.begin()
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		c.end()
+		return nil, err
+	}
+	s := &pooledStream{ClientStream: stream, end: c.end}
+	// stream is finished by error of RecvMsg, including io.EOF, and context of the stream is canceled by grpc
+	// once it's finished in any way, like CloseAndRecv of client streaming or cancellation of ctx.
+	go func() {
+		<-stream.Context().Done()
+		s.once.Do(s.end)
+	}()
+	return s, nil
+}
+
+type pooledStream struct {
+	grpc.ClientStream
+	once sync.Once
+	end  func()
+}
+
+func (s *pooledStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.once.Do(s.end)
+	}
+	return err
+}
+
+// watchState export connectivity state of c until it's shutdown.
+func (c *pooledConn) watchState() {
+	state := c.cc.GetState()
+	connStateGauge.WithLabelValues(c.service, strings.ToLower(state.String())).Inc()
+	for state != connectivity.Shutdown {
+		c.cc.WaitForStateChange(context.Background(), state)
+		next := c.cc.GetState()
+		connStateGauge.WithLabelValues(c.service, strings.ToLower(state.String())).Dec()
+		if next != connectivity.Shutdown {
+			connStateGauge.WithLabelValues(c.service, strings.ToLower(next.String())).Inc()
+		}
+		state = next
+	}
+}
+
+// Pool is a process-wide cache of client connections keyed by service name, connections are dialed lazily,
+// shared by callers and closed after idle for a while, connections with inflight calls or streams are never closed as idle.
+// Callers should Get connection for each call instead of holding it, which may be closed after idle.
+type Pool struct {
+	resolver        resolver.Builder
+	resolverOptions []lb.ResolverOption
+	configs         map[string]Config
+	options         []Option
+	idleTTL         time.Duration
+
+	mu     sync.Mutex
+	conns  map[string]*pooledConn
+	closed bool
+	cancel context.CancelFunc
+}
+
+// NewPool create pool resolving services by watching r.
+func NewPool(r registry.Registry, options ...PoolOption) *Pool {
+	metric.MustRegister(connStateGauge, dialCounter)
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		idleTTL: defaultIdleTTL,
+		conns:   make(map[string]*pooledConn),
+		cancel:  cancel,
+	}
+	for _, option := range options {
+		option(p)
+	}
+	if p.idleTTL <= 0 {
+		p.idleTTL = defaultIdleTTL
+	}
+	p.resolver = lb.NewConsulBuilder(r, p.resolverOptions...)
+	go p.closeIdle(ctx)
+	return p
+}
+
+// Get return connection of service, dial it if not exists or shutdown,
+// connection in transient failure is asked to reconnect immediately.
+func (p *Pool) Get(ctx context.Context, service string) (*grpc.ClientConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	if c, ok := p.conns[service]; ok {
+		switch c.cc.GetState() {
+		case connectivity.Shutdown:
+			delete(p.conns, service)
+		case connectivity.TransientFailure:
+			c.cc.ResetConnectBackoff()
+			fallthrough
+		default:
+			c.touch()
+			return c.cc, nil
+		}
+	}
+	c, err := p.dial(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	p.conns[service] = c
+	return c.cc, nil
+}
+
+func (p *Pool) dial(ctx context.Context, service string) (*pooledConn, error) {
+	c := &pooledConn{service: service}
+	c.touch()
+	target := lb.ConsulScheme + ":///" + service
+	options := append([]Option{Balancer(defaultBalancer)}, p.options...)
+	if config, ok := p.configs[service]; ok {
+		if config.Target != "" {
+			target = config.Target
+		}
+		configured, err := config.Options()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, configured...)
+	}
+	options = append(options,
+		PrependUnaryInterceptor(c.unaryInterceptor),
+		PrependStreamInterceptor(c.streamInterceptor),
+		DialOptions(grpc.WithResolvers(p.resolver)),
+	)
+	cc, err := NewContext(ctx, target, options...)
+	if err != nil {
+		return nil, err
+	}
+	c.cc = cc
+	dialCounter.WithLabelValues(service).Inc()
+	go c.watchState()
+	return c, nil
+}
+
+func (p *Pool) closeIdle(ctx context.Context) {
+	ticker := time.NewTicker(p.idleTTL / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p.mu.Lock()
+		for service, c := range p.conns {
+			if c.idle(p.idleTTL) {
+				_ = c.cc.Close()
+				delete(p.conns, service)
+			}
+		}
+		p.mu.Unlock()
+	}
+}
+
+// Close close all connections, Get after Close returns ErrPoolClosed.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	p.cancel()
+	var errs []string
+	for service, c := range p.conns {
+		if err := c.cc.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+		delete(p.conns, service)
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+var (
+	defaultPoolMu sync.RWMutex
+	defaultPool   *Pool
+)
+
+// Initialize create the default pool used by Get.
+func Initialize(r registry.Registry, options ...PoolOption) {
+	pool := NewPool(r, options...)
+	defaultPoolMu.Lock()
+	old := defaultPool
+	defaultPool = pool
+	defaultPoolMu.Unlock()
+	if old != nil {
+		_ = old.Close()
+	}
+}
+
+func getDefaultPool() *Pool {
+	defaultPoolMu.RLock()
+	defer defaultPoolMu.RUnlock()
+	return defaultPool
+}
+
+// Get return connection of service from the default pool.
+func Get(ctx context.Context, service string) (*grpc.ClientConn, error) {
+	pool := getDefaultPool()
+	if pool == nil {
+		return nil, errors.New("client: pool not initialized")
+	}
+	return pool.Get(ctx, service)
+}
+
+// Close close the default pool.
+func Close() error {
+	pool := getDefaultPool()
+	if pool == nil {
+		return nil
+	}
+	return pool.Close()
+}
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+
+	"github.com/go-board/thor/pkg/registry"
+)
+
+// countingService count unary calls, and echo messages of full duplex calls.
+type countingService struct {
+	testpb.UnimplementedTestServiceServer
+	calls int64
+}
+
+func (s *countingService) UnaryCall(ctx context.Context, req *testpb.SimpleRequest) (*testpb.SimpleResponse, error) {
+	atomic.AddInt64(&s.calls, 1)
+	return &testpb.SimpleResponse{}, nil
+}
+
+func (s *countingService) FullDuplexCall(stream testpb.TestService_FullDuplexCallServer) error {
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		if err := stream.Send(&testpb.StreamingOutputCallResponse{Payload: req.GetPayload()}); err != nil {
+			return err
+		}
+	}
+}
+
+// StreamingInputCall count messages until client close send.
+func (s *countingService) StreamingInputCall(stream testpb.TestService_StreamingInputCallServer) error {
+	n := 0
+	for {
+		if _, err := stream.Recv(); err != nil {
+			return stream.SendAndClose(&testpb.StreamingInputCallResponse{AggregatedPayloadSize: int32(n)})
+		}
+		n++
+	}
+}
+
+// startBackend serve s on a local port and register it as instance id of service test.
+func startBackend(t *testing.T, r *registry.Memory, id string, s *countingService) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	testpb.RegisterTestServiceServer(srv, s)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+	_ = r.Register(context.Background(), registry.Service{ServiceName: "test", ServiceID: id, ServiceAddr: ln.Addr().String()})
+}
+
+func newTestPool(r registry.Registry, options ...PoolOption) *Pool {
+	return NewPool(r, append([]PoolOption{ClientOptions(DisableLogging(), DisableMetrics(), DisableTracing())}, options...)...)
+}
+
+func TestPoolBalance(t *testing.T) {
+	r := registry.NewMemory()
+	backends := []*countingService{{}, {}}
+	startBackend(t, r, "a", backends[0])
+	startBackend(t, r, "b", backends[1])
+	p := newTestPool(r)
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	cc, err := p.Get(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := testpb.NewTestServiceClient(cc)
+	for i := 0; i < 20; i++ {
+		if _, err := client.UnaryCall(ctx, &testpb.SimpleRequest{}, grpc.WaitForReady(true)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// round_robin only picks ready backends, so the first calls may go to one of them.
+	for i, b := range backends {
+		if atomic.LoadInt64(&b.calls) == 0 {
+			t.Errorf("backend %d got no call", i)
+		}
+	}
+}
+
+func TestPoolIdle(t *testing.T) {
+	r := registry.NewMemory()
+	startBackend(t, r, "a", &countingService{})
+	ttl := time.Millisecond * 20
+	p := newTestPool(r, IdleTTL(ttl))
+	defer p.Close()
+	pooled := func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return len(p.conns) == 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	cc, err := p.Get(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := testpb.NewTestServiceClient(cc).FullDuplexCall(ctx, grpc.WaitForReady(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// connection with a stream open is kept though it's idle for ttl.
+	time.Sleep(ttl * 5)
+	if !pooled() {
+		t.Fatal("connection with inflight stream closed")
+	}
+	if err := stream.Send(&testpb.StreamingOutputCallRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	_ = stream.CloseSend()
+	if _, err := stream.Recv(); err == nil {
+		t.Fatal("stream not finished")
+	}
+	deadline := time.Now().Add(time.Second)
+	for pooled() {
+		if time.Now().After(deadline) {
+			t.Fatal("idle connection not closed")
+		}
+		time.Sleep(ttl)
+	}
+}
+
+func TestPoolOptions(t *testing.T) {
+	// non-positive ttl falls back to the default instead of panicking by ticker.
+	p := newTestPool(registry.NewMemory(), IdleTTL(0))
+	defer p.Close()
+	if p.idleTTL != defaultIdleTTL {
+		t.Errorf("idle ttl = %v, want %v", p.idleTTL, defaultIdleTTL)
+	}
+}
+
+func TestDefaultPool(t *testing.T) {
+	r := registry.NewMemory()
+	startBackend(t, r, "a", &countingService{})
+	defer Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			Initialize(r, ClientOptions(DisableLogging(), DisableMetrics(), DisableTracing()))
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = Get(context.Background(), "test")
+		}()
+	}
+	wg.Wait()
+	if _, err := Get(context.Background(), "test"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoolClientStreamEnd(t *testing.T) {
+	r := registry.NewMemory()
+	startBackend(t, r, "a", &countingService{})
+	p := newTestPool(r)
+	defer p.Close()
+	cc, err := p.Get(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// CloseAndRecv receives the response by RecvMsg of the underlying stream, not of the pooled one.
+	stream, err := testpb.NewTestServiceClient(cc).StreamingInputCall(context.Background(), grpc.WaitForReady(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&testpb.StreamingInputCallRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := stream.CloseAndRecv(); err != nil || resp.GetAggregatedPayloadSize() != 1 {
+		t.Fatalf("response = %v, %v", resp, err)
+	}
+	p.mu.Lock()
+	c := p.conns["test"]
+	p.mu.Unlock()
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt64(&c.inflight) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("client stream finished but still inflight")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// propagator copy incoming metadata of keys to outgoing calls, keys already set in outgoing metadata are kept.
+type propagator struct {
+	keys []string
+}
+
+func newPropagator(keys []string) *propagator { return &propagator{keys: keys} }
+
+func (p *propagator) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(p.propagate(ctx), method, req, reply, cc, opts...)
+}
+
+func (p *propagator) StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(p.propagate(ctx), desc, cc, method, opts...)
+}
+
+func (p *propagator) propagate(ctx context.Context) context.Context {
+	in, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	out, _ := metadata.FromOutgoingContext(ctx)
+	var kv []string
+	for _, key := range p.keys {
+		if len(out.Get(key)) > 0 {
+			continue
+		}
+		for _, value := range in.Get(key) {
+			kv = append(kv, key, value)
+		}
+	}
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
@@ -0,0 +1,241 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error is the application error shared by grpc and http, it's converted to `google.rpc.Status` with
+// ErrorInfo and RetryInfo details for grpc, and to http status with json body for http.
+type Error struct {
+	Code    codes.Code
+	Message string
+	// Reason is a constant in UPPER_SNAKE_CASE identify the cause, like `USER_NOT_FOUND`.
+	Reason string
+	// Domain is the logical group the reason belongs to, usually the service name.
+	Domain   string
+	Metadata map[string]string
+	// Retryable error hint caller to retry after RetryDelay.
+	Retryable  bool
+	RetryDelay time.Duration
+	// Details is other details of status like BadRequest, kept for grpc only.
+	Details []proto.Message
+	Cause   error
+}
+
+func New(code codes.Code, reason string, message string) *Error {
+	return &Error{Code: code, Reason: reason, Message: message}
+}
+
+func Newf(code codes.Code, reason string, format string, args ...interface{}) *Error {
+	return New(code, reason, fmt.Sprintf(format, args...))
+}
+
+// Wrap create error caused by cause, message of cause is kept out of response.
+func Wrap(cause error, code codes.Code, reason string, message string) *Error {
+	return &Error{Code: code, Reason: reason, Message: message, Cause: cause}
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("code = %s desc = %s", e.Code, e.Message)
+	if e.Reason != "" {
+		msg = fmt.Sprintf("code = %s reason = %s desc = %s", e.Code, e.Reason, e.Message)
+	}
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error { return e.Cause }
+
+// Is report whether target is an *Error with the same code and reason.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code && t.Reason == e.Reason
+}
+
+func (e *Error) WithDomain(domain string) *Error {
+	e.Domain = domain
+	return e
+}
+
+func (e *Error) WithMetadata(key string, value string) *Error {
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]string)
+	}
+	e.Metadata[key] = value
+	return e
+}
+
+func (e *Error) WithRetry(delay time.Duration) *Error {
+	e.Retryable = true
+	e.RetryDelay = delay
+	return e
+}
+
+func (e *Error) WithCause(cause error) *Error {
+	e.Cause = cause
+	return e
+}
+
+// GRPCStatus make grpc convert the error returned by handlers to status automatically.
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(e.Code, e.Message)
+	var details []proto.Message
+	if e.Reason != "" || e.Domain != "" || len(e.Metadata) > 0 {
+		details = append(details, &errdetails.ErrorInfo{Reason: e.Reason, Domain: e.Domain, Metadata: e.Metadata})
+	}
+	if e.Retryable {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(e.RetryDelay)})
+	}
+	details = append(details, e.Details...)
+	if len(details) == 0 {
+		return st
+	}
+	if ds, err := st.WithDetails(details...); err == nil {
+		return ds
+	}
+	return st
+}
+
+// HTTPStatus is the http status mapped from code.
+func (e *Error) HTTPStatus() int { return HTTPStatus(e.Code) }
+
+// Body is the json body of error in http response.
+type Body struct {
+	Code       codes.Code        `json:"code"`
+	Status     string            `json:"status"`
+	Msg        string            `json:"msg"`
+	Reason     string            `json:"reason,omitempty"`
+	Domain     string            `json:"domain,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Retryable  bool              `json:"retryable,omitempty"`
+	RetryDelay string            `json:"retry_delay,omitempty"`
+}
+
+func (e *Error) Body() *Body {
+	b := &Body{
+		Code:      e.Code,
+		Status:    e.Code.String(),
+		Msg:       e.Message,
+		Reason:    e.Reason,
+		Domain:    e.Domain,
+		Metadata:  e.Metadata,
+		Retryable: e.Retryable,
+	}
+	if e.Retryable {
+		b.RetryDelay = e.RetryDelay.String()
+	}
+	return b
+}
+
+func (e *Error) MarshalJSON() ([]byte, error) { return json.Marshal(e.Body()) }
+
+// messages of Unknown and Internal errors not created by this package, so that their causes,
+// which may carry implementation details, are kept out of response.
+const (
+	unknownMessage  = "unknown error"
+	internalMessage = "internal error"
+)
+
+// FromError convert any error to *Error, *Error wrapped in err is returned as is,
+// grpc status is parsed with its details, context errors are mapped to Canceled and DeadlineExceeded.
+// Other errors and Unknown or Internal status without reason get a generic message, err is kept as cause.
+func FromError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if stderrors.As(err, &e) {
+		return e
+	}
+	if st, ok := status.FromError(err); ok {
+		e := FromStatus(st)
+		if e.Reason == "" {
+			switch e.Code {
+			case codes.Unknown:
+				e.Message, e.Cause = unknownMessage, err
+			case codes.Internal:
+				e.Message, e.Cause = internalMessage, err
+			}
+		}
+		return e
+	}
+	switch {
+	case stderrors.Is(err, context.Canceled):
+		return Wrap(err, codes.Canceled, "", err.Error())
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return Wrap(err, codes.DeadlineExceeded, "", err.Error())
+	}
+	return Wrap(err, codes.Unknown, "", unknownMessage)
+}
+
+// FromStatus convert grpc status to *Error, ErrorInfo and RetryInfo details are recognized.
+func FromStatus(st *status.Status) *Error {
+	e := &Error{Code: st.Code(), Message: st.Message()}
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			e.Reason, e.Domain, e.Metadata = d.Reason, d.Domain, d.Metadata
+		case *errdetails.RetryInfo:
+			e.Retryable = true
+			e.RetryDelay, _ = ptypes.Duration(d.RetryDelay)
+		default:
+			if m, ok := d.(proto.Message); ok {
+				e.Details = append(e.Details, m)
+			}
+		}
+	}
+	return e
+}
+
+// FromHTTP convert http response with status and body to *Error, code is mapped from http status
+// if body isn't the json written by WriteError.
+func FromHTTP(httpStatus int, body []byte) *Error {
+	var b Body
+	if err := json.Unmarshal(body, &b); err != nil || b.Status == "" {
+		return New(CodeFromHTTP(httpStatus), "", http.StatusText(httpStatus))
+	}
+	e := &Error{
+		Code:      b.Code,
+		Message:   b.Msg,
+		Reason:    b.Reason,
+		Domain:    b.Domain,
+		Metadata:  b.Metadata,
+		Retryable: b.Retryable,
+	}
+	e.RetryDelay, _ = time.ParseDuration(b.RetryDelay)
+	return e
+}
+
+// Code return code of err, OK if err is nil.
+func Code(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	return FromError(err).Code
+}
+
+// Reason return reason of err, empty if err carries no reason.
+func Reason(err error) string {
+	if err == nil {
+		return ""
+	}
+	return FromError(err).Reason
+}
+
+// IsRetryable report whether err is marked retryable.
+func IsRetryable(err error) bool {
+	return err != nil && FromError(err).Retryable
+}
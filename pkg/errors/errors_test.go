@@ -0,0 +1,40 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFromError(t *testing.T) {
+	cause := stderrors.New("dial tcp 10.0.0.1:3306: connection refused")
+	thorErr := New(codes.Internal, "DB_DOWN", "database unavailable")
+	for _, c := range []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{"plain", cause, codes.Unknown, unknownMessage},
+		{"unknown status", status.Error(codes.Unknown, cause.Error()), codes.Unknown, unknownMessage},
+		{"internal status", status.Error(codes.Internal, cause.Error()), codes.Internal, internalMessage},
+		{"not found status", status.Error(codes.NotFound, "user not found"), codes.NotFound, "user not found"},
+		{"thor error", fmt.Errorf("query: %w", thorErr), codes.Internal, "database unavailable"},
+		{"thor status", thorErr.GRPCStatus().Err(), codes.Internal, "database unavailable"},
+		{"deadline", context.DeadlineExceeded, codes.DeadlineExceeded, context.DeadlineExceeded.Error()},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			e := FromError(c.err)
+			if e.Code != c.code || e.Message != c.message {
+				t.Errorf("FromError = %v, %q, want %v, %q", e.Code, e.Message, c.code, c.message)
+			}
+			if (c.message == unknownMessage || c.message == internalMessage) && e.Cause != c.err {
+				t.Errorf("cause = %v, want %v", e.Cause, c.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor convert error returned by handler to grpc status,
+// so that *Error wrapped by other errors is still sent with its details.
+func UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	return resp, toStatusError(err)
+}
+
+func StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return toStatusError(handler(srv, ss))
+}
+
+// UnaryClientInterceptor convert status returned by server to *Error, which still carries the status.
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+		return FromError(err)
+	}
+	return nil
+}
+
+// toStatusError convert *Error and context errors to status, status error is returned as is.
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if _, ok := status.FromError(err); ok && !stderrors.As(err, &e) {
+		return err
+	}
+	return FromError(err).GRPCStatus().Err()
+}
@@ -0,0 +1,251 @@
+// Package fault inject latency, errors and aborts into grpc calls and http requests by rules,
+// which can be changed at runtime through the admin handler, for chaos testing.
+package fault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+
+	"github.com/go-board/thor/pkg/metric"
+)
+
+// ReasonInjected is the reason of injected errors.
+const ReasonInjected = "FAULT_INJECTED"
+
+const (
+	SideServer = "server"
+	SideClient = "client"
+	SideHTTP   = "http"
+)
+
+var injectedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "fault_injected_total",
+	Help: "Total number of injected faults, fault is delay, error or abort.",
+}, []string{"side", "fault"})
+
+// Rule declares faults injected to matched calls, delay is injected before error or abort.
+type Rule struct {
+	// Method is full grpc method like `/pkg.Service/Method`, or http route like `GET /users/*` whose method can be omitted,
+	// trailing `*` matches any suffix, empty matches all.
+	Method string `yaml:"method"`
+	// Side restrict grpc rule to `server` or `client`, empty matches both, it's ignored by http.
+	Side string `yaml:"side"`
+	// Metadata must equal to incoming metadata on server, outgoing metadata on client, or http headers.
+	Metadata map[string]string `yaml:"metadata"`
+	// Percentage of matched calls injected, from 0 to 100, 0 injects none and 100 injects all.
+	Percentage float64       `yaml:"percentage"`
+	Delay      time.Duration `yaml:"delay"`
+	// Code is grpc code of injected error, it's mapped to http status if HTTPStatus isn't set.
+	Code int `yaml:"code"`
+	// HTTPStatus is http status of injected error, it's mapped to grpc code if Code isn't set.
+	HTTPStatus int `yaml:"http_status"`
+	// Abort drop the http connection, or fail the grpc call with codes.Unavailable like a reset connection.
+	Abort bool `yaml:"abort"`
+}
+
+type jsonRule struct {
+	Method     string            `json:"method,omitempty"`
+	Side       string            `json:"side,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Percentage float64           `json:"percentage,omitempty"`
+	Delay      string            `json:"delay,omitempty"`
+	Code       int               `json:"code,omitempty"`
+	HTTPStatus int               `json:"http_status,omitempty"`
+	Abort      bool              `json:"abort,omitempty"`
+}
+
+// MarshalJSON encode delay as duration string like `100ms`.
+func (r Rule) MarshalJSON() ([]byte, error) {
+	j := jsonRule{
+		Method:     r.Method,
+		Side:       r.Side,
+		Metadata:   r.Metadata,
+		Percentage: r.Percentage,
+		Code:       r.Code,
+		HTTPStatus: r.HTTPStatus,
+		Abort:      r.Abort,
+	}
+	if r.Delay > 0 {
+		j.Delay = r.Delay.String()
+	}
+	return json.Marshal(j)
+}
+
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	var j jsonRule
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	var delay time.Duration
+	if j.Delay != "" {
+		d, err := time.ParseDuration(j.Delay)
+		if err != nil {
+			return err
+		}
+		delay = d
+	}
+	*r = Rule{
+		Method:     j.Method,
+		Side:       j.Side,
+		Metadata:   j.Metadata,
+		Percentage: j.Percentage,
+		Delay:      delay,
+		Code:       j.Code,
+		HTTPStatus: j.HTTPStatus,
+		Abort:      j.Abort,
+	}
+	return nil
+}
+
+func (r Rule) validate() error {
+	if r.Percentage < 0 || r.Percentage > 100 {
+		return fmt.Errorf("fault: percentage of %q out of range [0, 100]", r.Method)
+	}
+	if r.Side != "" && r.Side != SideServer && r.Side != SideClient {
+		return fmt.Errorf("fault: unknown side %q of %q", r.Side, r.Method)
+	}
+	if r.Delay < 0 {
+		return fmt.Errorf("fault: negative delay of %q", r.Method)
+	}
+	if r.Code < int(codes.OK) || r.Code > int(codes.Unauthenticated) {
+		return fmt.Errorf("fault: code %d of %q out of range [0, 16]", r.Code, r.Method)
+	}
+	if r.HTTPStatus != 0 && (r.HTTPStatus < 400 || r.HTTPStatus > 599) {
+		return fmt.Errorf("fault: http status %d of %q isn't an error status", r.HTTPStatus, r.Method)
+	}
+	return nil
+}
+
+// match report whether rule applies to method on side, md return metadata value of key.
+func (r Rule) match(side string, method string, md func(key string) string) bool {
+	if side != SideHTTP && r.Side != "" && r.Side != side {
+		return false
+	}
+	if !matchMethod(r.Method, method) {
+		return false
+	}
+	for key, value := range r.Metadata {
+		if md(key) != value {
+			return false
+		}
+	}
+	return rand.Float64()*100 < r.Percentage
+}
+
+// matchMethod match pattern against grpc method, or http route `METHOD /path` whose method is optional in pattern.
+func matchMethod(pattern string, method string) bool {
+	if pattern == "" {
+		return true
+	}
+	if strings.HasPrefix(pattern, "/") && !strings.HasPrefix(method, "/") {
+		method = method[strings.Index(method, " ")+1:]
+	}
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(method, pattern[:len(pattern)-1])
+	}
+	return pattern == method
+}
+
+// Config is fault injection declared in configuration, it's disabled by default.
+// AdminToken is the bearer token required by the admin handler, which rejects all requests if it's empty,
+// it's only read by New and never exposed by the handler.
+type Config struct {
+	Enabled    bool   `yaml:"enabled" json:"enabled"`
+	Rules      []Rule `yaml:"rules" json:"rules"`
+	AdminToken string `yaml:"admin_token" json:"-"`
+}
+
+func (c Config) validate() error {
+	for _, r := range c.Rules {
+		if err := r.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Injector inject faults by the first matched rule while enabled.
+type Injector struct {
+	adminToken string
+
+	mu     sync.RWMutex
+	config Config
+}
+
+func New(c Config) (*Injector, error) {
+	metric.MustRegister(injectedCounter)
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return &Injector{adminToken: c.AdminToken, config: Config{Enabled: c.Enabled, Rules: c.Rules}}, nil
+}
+
+// Config return a copy of current config without admin token.
+func (i *Injector) Config() Config {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return Config{Enabled: i.config.Enabled, Rules: append([]Rule{}, i.config.Rules...)}
+}
+
+// Update replace enabled and rules of config, it takes effect for calls started after, admin token isn't changed.
+func (i *Injector) Update(c Config) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.config = Config{Enabled: c.Enabled, Rules: c.Rules}
+	return nil
+}
+
+func (i *Injector) Enabled() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.config.Enabled
+}
+
+// SetEnabled turn injection on or off, rules are kept.
+func (i *Injector) SetEnabled(enabled bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.config.Enabled = enabled
+}
+
+// match return the first rule matched while enabled.
+func (i *Injector) match(side string, method string, md func(key string) string) (Rule, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if !i.config.Enabled {
+		return Rule{}, false
+	}
+	for _, r := range i.config.Rules {
+		if r.match(side, method, md) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// delay sleep for delay of r, context error is returned if ctx is done before.
+func delay(ctx context.Context, side string, r Rule) error {
+	if r.Delay <= 0 {
+		return nil
+	}
+	injectedCounter.WithLabelValues(side, "delay").Inc()
+	timer := time.NewTimer(r.Delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRulePercentage(t *testing.T) {
+	md := func(string) string { return "" }
+	for _, c := range []struct {
+		percentage float64
+		want       int
+	}{{0, 0}, {100, 100}} {
+		injected := 0
+		for n := 0; n < 100; n++ {
+			if (Rule{Percentage: c.percentage}).match(SideServer, "/pkg.Service/Method", md) {
+				injected++
+			}
+		}
+		if injected != c.want {
+			t.Errorf("percentage %v injected %d of 100, want %d", c.percentage, injected, c.want)
+		}
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	for _, r := range []Rule{
+		{Code: -1},
+		{Code: 17},
+		{HTTPStatus: 200},
+		{HTTPStatus: 600},
+		{Percentage: 101},
+	} {
+		if err := r.validate(); err == nil {
+			t.Errorf("rule %+v is valid, want error", r)
+		}
+	}
+	for _, r := range []Rule{{Code: 16}, {HTTPStatus: 503}, {Percentage: 100}} {
+		if err := r.validate(); err != nil {
+			t.Errorf("rule %+v: %v", r, err)
+		}
+	}
+}
+
+func TestHandlerAuth(t *testing.T) {
+	serve := func(i *Injector, method string, authorization string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/admin/fault", strings.NewReader(body))
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		i.Handler().ServeHTTP(w, r)
+		return w
+	}
+	enable := `{"enabled":true,"rules":[{"percentage":100,"code":14}]}`
+
+	noToken, _ := New(Config{})
+	if w := serve(noToken, http.MethodPut, "Bearer ", enable); w.Code != http.StatusForbidden || noToken.Enabled() {
+		t.Errorf("handler without admin token: status = %d, enabled = %v", w.Code, noToken.Enabled())
+	}
+
+	i, _ := New(Config{AdminToken: "secret"})
+	if w := serve(i, http.MethodPut, "Bearer wrong", enable); w.Code != http.StatusUnauthorized || i.Enabled() {
+		t.Errorf("wrong token: status = %d, enabled = %v", w.Code, i.Enabled())
+	}
+	w := serve(i, http.MethodPut, "Bearer secret", enable)
+	if w.Code != http.StatusOK || !i.Enabled() {
+		t.Fatalf("valid token: status = %d, enabled = %v", w.Code, i.Enabled())
+	}
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("admin token exposed: %s", w.Body.String())
+	}
+	// admin token can't be replaced by updates.
+	_ = serve(i, http.MethodPut, "Bearer secret", `{"enabled":true,"admin_token":"other"}`)
+	if w := serve(i, http.MethodGet, "Bearer secret", ""); w.Code != http.StatusOK {
+		t.Errorf("admin token changed by update, status = %d", w.Code)
+	}
+}
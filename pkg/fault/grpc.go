@@ -0,0 +1,72 @@
+package fault
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/go-board/thor/pkg/errors"
+)
+
+func (i *Injector) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := i.injectGRPC(ctx, SideServer, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (i *Injector) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := i.injectGRPC(ss.Context(), SideServer, info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (i *Injector) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if err := i.injectGRPC(ctx, SideClient, method); err != nil {
+		return err
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func (i *Injector) StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if err := i.injectGRPC(ctx, SideClient, method); err != nil {
+		return nil, err
+	}
+	return streamer(ctx, desc, cc, method, opts...)
+}
+
+// injectGRPC delay the call and return injected error by the matched rule.
+func (i *Injector) injectGRPC(ctx context.Context, side string, method string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if side == SideClient {
+		md, _ = metadata.FromOutgoingContext(ctx)
+	}
+	r, ok := i.match(side, method, func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	})
+	if !ok {
+		return nil
+	}
+	if err := delay(ctx, side, r); err != nil {
+		return errors.Wrap(err, errors.Code(err), ReasonInjected, "fault: delay interrupted")
+	}
+	switch {
+	case r.Abort:
+		injectedCounter.WithLabelValues(side, "abort").Inc()
+		return errors.New(codes.Unavailable, ReasonInjected, "fault: connection aborted")
+	case r.Code != 0 || r.HTTPStatus != 0:
+		injectedCounter.WithLabelValues(side, "error").Inc()
+		code := codes.Code(r.Code)
+		if r.Code == 0 {
+			code = errors.CodeFromHTTP(r.HTTPStatus)
+		}
+		return errors.New(code, ReasonInjected, "fault: injected error")
+	}
+	return nil
+}
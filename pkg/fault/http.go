@@ -0,0 +1,97 @@
+package fault
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/go-board/thor/pkg/errors"
+)
+
+const bearerPrefix = "bearer "
+
+// HTTPFault is the fault injected to a http request.
+type HTTPFault struct {
+	// Abort is true if the connection should be dropped.
+	Abort bool
+	// Err is the injected error written with Status.
+	Err    *errors.Error
+	Status int
+}
+
+// InjectHTTP delay the request and return injected fault by the matched rule,
+// rules are matched against `METHOD /path` of request.
+func (i *Injector) InjectHTTP(r *http.Request) HTTPFault {
+	rule, ok := i.match(SideHTTP, r.Method+" "+r.URL.Path, r.Header.Get)
+	if !ok {
+		return HTTPFault{}
+	}
+	if err := delay(r.Context(), SideHTTP, rule); err != nil {
+		return HTTPFault{Abort: true}
+	}
+	switch {
+	case rule.Abort:
+		injectedCounter.WithLabelValues(SideHTTP, "abort").Inc()
+		return HTTPFault{Abort: true}
+	case rule.Code != 0 || rule.HTTPStatus != 0:
+		injectedCounter.WithLabelValues(SideHTTP, "error").Inc()
+		code, status := codes.Code(rule.Code), rule.HTTPStatus
+		if rule.Code == 0 {
+			code = errors.CodeFromHTTP(status)
+		}
+		if status == 0 {
+			status = errors.HTTPStatus(code)
+		}
+		return HTTPFault{Err: errors.New(code, ReasonInjected, "fault: injected error"), Status: status}
+	}
+	return HTTPFault{}
+}
+
+// Handler is the admin endpoint of i, GET return the config, PUT replace it with json body,
+// and DELETE disable injection and remove all rules.
+// Requests must carry `Authorization: Bearer <admin_token>`, all of them are forbidden if admin token isn't configured.
+// It should still be served on an internal listener only.
+func (i *Injector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if i.adminToken == "" {
+			writeJSON(w, http.StatusForbidden, map[string]string{"msg": "fault: admin token not configured"})
+			return
+		}
+		token := r.Header.Get("Authorization")
+		if len(token) <= len(bearerPrefix) || !strings.EqualFold(token[:len(bearerPrefix)], bearerPrefix) ||
+			subtle.ConstantTimeCompare([]byte(token[len(bearerPrefix):]), []byte(i.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"msg": "fault: invalid admin token"})
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var c Config
+			if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"msg": err.Error()})
+				return
+			}
+			if err := i.Update(c); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"msg": err.Error()})
+				return
+			}
+		case http.MethodDelete:
+			_ = i.Update(Config{})
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"msg": "method not allowed"})
+			return
+		}
+		writeJSON(w, http.StatusOK, i.Config())
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,181 @@
+// Package gateway is the runtime of HTTP handlers generated by protoc-gen-thor from `google.api.http` annotations,
+// which bind requests into proto messages, call grpc clients and write responses as JSON.
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-board/x-go/xnet/xhttp"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/go-board/thor/pkg/errors"
+	"github.com/go-board/thor/pkg/pbjson"
+	"github.com/go-board/thor/pkg/web"
+)
+
+// ReasonBadRequest is the reason of errors binding requests.
+const ReasonBadRequest = "BAD_REQUEST"
+
+var (
+	// MarshalOptions is used to write responses.
+	MarshalOptions = pbjson.MarshalOptions{EmitUnpopulated: true}
+	// UnmarshalOptions is used to bind request bodies, unknown fields are discarded like UnmarshalJSON
+	// generated by protoc-gen-thor, so that clients may send fields of newer versions.
+	UnmarshalOptions = pbjson.UnmarshalOptions{DiscardUnknown: true}
+	// MaxBodyBytes limit size of request bodies, requests with larger bodies are rejected.
+	MaxBodyBytes int64 = 4 << 20
+)
+
+// Bind bind r into msg, query params are bound first unless body is `*`,
+// then request body is bound into msg if body is `*` or into the field named body,
+// path params are bound last so that they take precedence.
+// Params are named by dotted field paths like `user.id`, unknown query params and body fields are ignored.
+// Body larger than MaxBodyBytes is rejected.
+func Bind(r *http.Request, msg proto.Message, body string) error {
+	m := proto.MessageReflect(msg)
+	if body != "*" {
+		for key, values := range r.URL.Query() {
+			if err := setField(m, key, values, true); err != nil {
+				return err
+			}
+		}
+	}
+	if body != "" && r.Body != nil {
+		b, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, MaxBodyBytes))
+		if err != nil {
+			if int64(len(b)) >= MaxBodyBytes {
+				return errors.Newf(codes.InvalidArgument, ReasonBadRequest, "body exceeds %d bytes", MaxBodyBytes)
+			}
+			return errors.Wrap(err, codes.InvalidArgument, ReasonBadRequest, "read body failed")
+		}
+		if b = bytes.TrimSpace(b); len(b) > 0 {
+			if body != "*" {
+				// wrap body as the field, so that it's decoded like a field of message.
+				b = append([]byte(`{"`+body+`":`), append(b, '}')...)
+			}
+			// merge body so that query params bound before are kept.
+			opts := UnmarshalOptions
+			opts.Merge = true
+			if err := opts.Unmarshal(b, msg); err != nil {
+				return errors.New(codes.InvalidArgument, ReasonBadRequest, err.Error())
+			}
+		}
+	}
+	for _, param := range web.PathParams(r) {
+		// value of catch-all param starts with `/`.
+		if err := setField(m, param.Key, []string{strings.TrimPrefix(param.Value, "/")}, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetField set field at dotted path of m to values parsed from text, values are appended to repeated fields.
+func SetField(m proto.Message, path string, values ...string) error {
+	return setField(proto.MessageReflect(m), path, values, false)
+}
+
+func setField(m protoreflect.Message, path string, values []string, ignoreUnknown bool) error {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := pbjson.FieldByName(m.Descriptor(), name)
+		if fd == nil {
+			if ignoreUnknown {
+				return nil
+			}
+			return errors.Newf(codes.InvalidArgument, ReasonBadRequest, "unknown field %q of %s", path, m.Descriptor().FullName())
+		}
+		if i < len(names)-1 {
+			if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+				return errors.Newf(codes.InvalidArgument, ReasonBadRequest, "field %q isn't a message", path)
+			}
+			m = m.Mutable(fd).Message()
+			continue
+		}
+		if fd.IsMap() {
+			return errors.Newf(codes.InvalidArgument, ReasonBadRequest, "map field %q can't be set from text", path)
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		if fd.IsList() {
+			list := m.Mutable(fd).List()
+			for _, value := range values {
+				v, err := parseValue(fd, value, list.NewElement)
+				if err != nil {
+					return err
+				}
+				list.Append(v)
+			}
+			return nil
+		}
+		v, err := parseValue(fd, values[len(values)-1], func() protoreflect.Value { return m.NewField(fd) })
+		if err != nil {
+			return err
+		}
+		m.Set(fd, v)
+	}
+	return nil
+}
+
+// parseValue parse scalar from text, or well-known message like Timestamp from its JSON string.
+func parseValue(fd protoreflect.FieldDescriptor, s string, newValue func() protoreflect.Value) (protoreflect.Value, error) {
+	if fd.Message() == nil {
+		v, err := pbjson.ParseValue(fd, s)
+		if err != nil {
+			return v, errors.New(codes.InvalidArgument, ReasonBadRequest, err.Error())
+		}
+		return v, nil
+	}
+	v := newValue()
+	if fd.Message().ParentFile().Package() == "google.protobuf" {
+		if err := protojson.Unmarshal([]byte(strconv.Quote(s)), v.Message().Interface()); err == nil {
+			return v, nil
+		}
+		// wrappers of bool and numbers are unquoted.
+		if err := protojson.Unmarshal([]byte(s), v.Message().Interface()); err == nil {
+			return v, nil
+		}
+	}
+	return v, errors.Newf(codes.InvalidArgument, ReasonBadRequest, "invalid value %q of %s", s, fd.FullName())
+}
+
+// OutgoingContext return context of r with `Authorization` and `X-*` headers forwarded as outgoing metadata.
+func OutgoingContext(r *http.Request) context.Context {
+	md := metadata.MD{}
+	for key, values := range r.Header {
+		if key == "Authorization" || strings.HasPrefix(key, "X-") {
+			md.Append(strings.ToLower(key), values...)
+		}
+	}
+	return metadata.NewOutgoingContext(r.Context(), md)
+}
+
+// WriteResponse write resp as JSON, or only the field named by responseBody if it isn't empty.
+func WriteResponse(w http.ResponseWriter, resp proto.Message, responseBody string) error {
+	var (
+		b   []byte
+		err error
+	)
+	if responseBody != "" {
+		b, err = MarshalOptions.MarshalField(resp, responseBody)
+	} else {
+		b, err = MarshalOptions.Marshal(resp)
+	}
+	if err != nil {
+		return errors.Wrap(err, codes.Internal, "", "marshal response failed")
+	}
+	w.Header().Set(xhttp.HeaderContentType, xhttp.MIMEApplicationJSON)
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,28 @@
+package gateway
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/status"
+)
+
+func TestBindBody(t *testing.T) {
+	defer func(n int64) { MaxBodyBytes = n }(MaxBodyBytes)
+	MaxBodyBytes = 64
+
+	req := &testpb.SimpleRequest{}
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"responseSize": 10, "unknown": true}`))
+	if err := Bind(r, req, "*"); err != nil || req.ResponseSize != 10 {
+		t.Fatalf("bind = %v, request = %v, want unknown field discarded", err, req)
+	}
+
+	r = httptest.NewRequest("POST", "/", strings.NewReader(`{"responseSize": 10, "fillUsername": true, "padding": "`+strings.Repeat("x", 64)+`"}`))
+	err := Bind(r, &testpb.SimpleRequest{}, "*")
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "exceeds 64 bytes") {
+		t.Fatalf("bind = %v, want body too large", err)
+	}
+}
@@ -0,0 +1,217 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/go-board/thor/pkg/web"
+)
+
+// itemRequest is built once, since pbjson caches fields of message types by full name.
+var itemRequest = newItemRequest()
+
+// newItemRequest return descriptor of message like:
+//
+//	message Filter { string name = 1; }
+//	enum Kind { KIND_UNSPECIFIED = 0; BOOK = 1; }
+//	message ItemRequest {
+//	  string id = 1; string kind_name = 2; repeated string tags = 3; Kind kind = 4;
+//	  google.protobuf.Timestamp created_at = 5; Filter filter = 6; Filter item = 7; repeated Filter items = 8;
+//	}
+func newItemRequest() protoreflect.MessageDescriptor {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, repeated bool) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Label: label.Enum(), Type: typ.Enum()}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	const (
+		str = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		msg = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("thor/gateway/test.proto"),
+		Package:    proto.String("thor.gateway.test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Kind"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("KIND_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("BOOK"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Filter"), Field: []*descriptorpb.FieldDescriptorProto{field("name", 1, str, "", false)}},
+			{Name: proto.String("ItemRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, str, "", false),
+				field("kind_name", 2, str, "", false),
+				field("tags", 3, str, "", true),
+				field("kind", 4, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".thor.gateway.test.Kind", false),
+				field("created_at", 5, msg, ".google.protobuf.Timestamp", false),
+				field("filter", 6, msg, ".thor.gateway.test.Filter", false),
+				field("item", 7, msg, ".thor.gateway.test.Filter", false),
+				field("items", 8, msg, ".thor.gateway.test.Filter", true),
+			}},
+		},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	return fd.Messages().ByName("ItemRequest")
+}
+
+// newGateway return a server with handlers like generated by protoc-gen-thor, which call echo with bound requests.
+func newGateway(echo func(in protoreflect.Message) (proto.Message, error)) *web.Server {
+	s := web.New()
+	handle := func(method, path, body, responseBody string) {
+		s.Handle(method, path, web.HandlerFunc(func(w http.ResponseWriter, req *http.Request) error {
+			in := dynamicpb.NewMessage(itemRequest)
+			if err := Bind(req, proto.MessageV1(in), body); err != nil {
+				return err
+			}
+			out, err := echo(in)
+			if err != nil {
+				return err
+			}
+			return WriteResponse(w, out, responseBody)
+		}))
+	}
+	handle("GET", "/v1/items/:id", "", "")
+	handle("GET", "/v1/kinds/:kind_name/items/*id", "", "")
+	handle("PATCH", "/v1/items/:id", "*", "")
+	handle("POST", "/v1/shelves/:kind_name/items", "item", "")
+	handle("GET", "/v1/items", "", "items")
+	return s
+}
+
+func echo(in protoreflect.Message) (proto.Message, error) { return proto.MessageV1(in), nil }
+
+// serve r by s and decode the JSON response.
+func serve(t *testing.T, s http.Handler, r *http.Request) (int, map[string]interface{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	var resp map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("response %s: %v", w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+func TestHandlerPathParams(t *testing.T) {
+	s := newGateway(echo)
+	code, resp := serve(t, s, httptest.NewRequest("GET", "/v1/items/42", nil))
+	if code != http.StatusOK || resp["id"] != "42" {
+		t.Errorf("path param: %d %v", code, resp)
+	}
+	// catch-all param `{id=**}` keeps slashes but not the leading one.
+	code, resp = serve(t, s, httptest.NewRequest("GET", "/v1/kinds/book/items/a/b/c", nil))
+	if code != http.StatusOK || resp["id"] != "a/b/c" || resp["kindName"] != "book" {
+		t.Errorf("catch-all param: %d %v", code, resp)
+	}
+}
+
+func TestHandlerQuery(t *testing.T) {
+	s := newGateway(echo)
+	code, resp := serve(t, s, httptest.NewRequest("GET", "/v1/items/42?tags=a&tags=b&kind=BOOK&createdAt=2020-01-02T03:04:05Z&filter.name=x&unknown=1", nil))
+	if code != http.StatusOK {
+		t.Fatalf("code = %d, response = %v", code, resp)
+	}
+	tags, _ := resp["tags"].([]interface{})
+	if len(tags) != 2 || tags[0] != "a" || tags[1] != "b" {
+		t.Errorf("repeated = %v, want [a b]", resp["tags"])
+	}
+	if resp["kind"] != "BOOK" {
+		t.Errorf("enum = %v, want BOOK", resp["kind"])
+	}
+	if resp["createdAt"] != "2020-01-02T03:04:05Z" {
+		t.Errorf("timestamp = %v", resp["createdAt"])
+	}
+	if filter, _ := resp["filter"].(map[string]interface{}); filter["name"] != "x" {
+		t.Errorf("nested = %v, want name x", resp["filter"])
+	}
+	// path params take precedence over query.
+	if _, resp := serve(t, s, httptest.NewRequest("GET", "/v1/items/42?id=7&kind=1", nil)); resp["id"] != "42" || resp["kind"] != "BOOK" {
+		t.Errorf("id = %v, kind = %v, want path param and enum by number", resp["id"], resp["kind"])
+	}
+	if code, _ := serve(t, s, httptest.NewRequest("GET", "/v1/items/42?kind=NOVEL", nil)); code != http.StatusBadRequest {
+		t.Errorf("code of invalid enum = %d, want 400", code)
+	}
+}
+
+func TestHandlerBody(t *testing.T) {
+	s := newGateway(echo)
+	// query isn't bound under `body: "*"`, and path overrides body.
+	r := httptest.NewRequest("PATCH", "/v1/items/42?kindName=query", strings.NewReader(`{"id": "7", "tags": ["a"]}`))
+	code, resp := serve(t, s, r)
+	if code != http.StatusOK || resp["id"] != "42" || resp["kindName"] != "" || len(resp["tags"].([]interface{})) != 1 {
+		t.Errorf("body *: %d %v", code, resp)
+	}
+	// body is wrapped as the field named by `body`, other fields come from path and query.
+	r = httptest.NewRequest("POST", "/v1/shelves/book/items?id=7", strings.NewReader(`{"name": "x"}`))
+	code, resp = serve(t, s, r)
+	if item, _ := resp["item"].(map[string]interface{}); code != http.StatusOK || item["name"] != "x" || resp["id"] != "7" || resp["kindName"] != "book" {
+		t.Errorf("body item: %d %v", code, resp)
+	}
+	r = httptest.NewRequest("POST", "/v1/shelves/book/items", strings.NewReader(`{"name": 1}`))
+	if code, _ := serve(t, s, r); code != http.StatusBadRequest {
+		t.Errorf("code of invalid body = %d, want 400", code)
+	}
+}
+
+func TestHandlerResponseBody(t *testing.T) {
+	s := newGateway(func(in protoreflect.Message) (proto.Message, error) {
+		items := in.Mutable(in.Descriptor().Fields().ByName("items")).List()
+		for _, name := range []string{"a", "b"} {
+			item := items.NewElement()
+			item.Message().Set(item.Message().Descriptor().Fields().ByName("name"), protoreflect.ValueOfString(name))
+			items.Append(item)
+		}
+		return proto.MessageV1(in), nil
+	})
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/v1/items", nil))
+	var items []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil || len(items) != 2 || items[1]["name"] != "b" {
+		t.Fatalf("response = %s, want items only", w.Body.String())
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	for c, want := range map[codes.Code]int{
+		codes.InvalidArgument:  http.StatusBadRequest,
+		codes.Unauthenticated:  http.StatusUnauthorized,
+		codes.PermissionDenied: http.StatusForbidden,
+		codes.NotFound:         http.StatusNotFound,
+		codes.AlreadyExists:    http.StatusConflict,
+		codes.Unavailable:      http.StatusServiceUnavailable,
+		codes.DeadlineExceeded: http.StatusGatewayTimeout,
+		codes.Internal:         http.StatusInternalServerError,
+	} {
+		s := newGateway(func(protoreflect.Message) (proto.Message, error) {
+			return nil, status.Error(c, "failed")
+		})
+		if code, resp := serve(t, s, httptest.NewRequest("GET", "/v1/items/42", nil)); code != want {
+			t.Errorf("http status of %s = %d, want %d, response = %v", c, code, want, resp)
+		}
+	}
+}
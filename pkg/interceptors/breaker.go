@@ -0,0 +1,442 @@
+package interceptors
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-board/x-go/xslice"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/go-board/thor/pkg/metric"
+)
+
+var (
+	breakerStateGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "grpc_client_breaker_state",
+		Help: "State of circuit breaker, 0 is closed, 1 is open and 2 is half open.",
+	}, []string{"grpc_target", "grpc_method"})
+	breakerTransitionCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_breaker_transitions_total",
+		Help: "Total number of circuit breaker state transitions.",
+	}, []string{"grpc_target", "grpc_method", "from", "to"})
+	breakerRejectedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_breaker_rejected_total",
+		Help: "Total number of calls rejected by circuit breaker.",
+	}, []string{"grpc_target", "grpc_method"})
+)
+
+const (
+	defaultBreakerWindow           = time.Second * 10
+	defaultBreakerBuckets          = 10
+	defaultBreakerHalfOpenRequests = 5
+)
+
+// BreakerState is the state of circuit breaker.
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	}
+	return "closed"
+}
+
+// BreakerEvent is emitted on state transition of circuit breaker.
+type BreakerEvent struct {
+	Target string
+	Method string
+	From   BreakerState
+	To     BreakerState
+	At     time.Time
+}
+
+// Fallback is called with the error when call is rejected by circuit breaker or failed,
+// it may fill reply and return nil to recover the call.
+type Fallback func(ctx context.Context, method string, req, reply interface{}, err error) error
+
+// Clock provides current time, it's replaceable for tests.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+type BreakerOption func(b *GrpcBreaker)
+
+// BreakerWindow set the sliding window of length divided into buckets, default is 10s of 10 buckets,
+// which is used if buckets isn't positive or window is shorter than buckets nanoseconds.
+func BreakerWindow(window time.Duration, buckets int) BreakerOption {
+	return func(b *GrpcBreaker) {
+		b.window = window
+		b.buckets = buckets
+	}
+}
+
+// BreakerMinRequests is the minimum calls in the window before rates are evaluated, default is 20.
+func BreakerMinRequests(n int) BreakerOption {
+	return func(b *GrpcBreaker) {
+		b.minRequests = n
+	}
+}
+
+// BreakerErrorRate open the breaker if failure rate of the window reach rate, default is 0.5.
+func BreakerErrorRate(rate float64) BreakerOption {
+	return func(b *GrpcBreaker) {
+		b.errorRate = rate
+	}
+}
+
+// BreakerSlowCall open the breaker if rate of calls slower than duration reach rate, disabled by default.
+func BreakerSlowCall(duration time.Duration, rate float64) BreakerOption {
+	return func(b *GrpcBreaker) {
+		b.slowCallDuration = duration
+		b.slowCallRate = rate
+	}
+}
+
+// BreakerOpenTimeout is how long the breaker stay open before half open, default is 30s.
+func BreakerOpenTimeout(timeout time.Duration) BreakerOption {
+	return func(b *GrpcBreaker) {
+		b.openTimeout = timeout
+	}
+}
+
+// BreakerHalfOpenRequests is the number of probe calls allowed in half open,
+// the breaker closes if all of them succeed, default is 5, which is used if n isn't positive.
+func BreakerHalfOpenRequests(n int) BreakerOption {
+	return func(b *GrpcBreaker) {
+		b.halfOpenRequests = n
+	}
+}
+
+// BreakerCodes is codes counted as failure, default is Unknown, DeadlineExceeded, Internal, Unavailable and DataLoss.
+func BreakerCodes(codes ...int) BreakerOption {
+	return func(b *GrpcBreaker) {
+		b.failureCodes = codes
+	}
+}
+
+func BreakerFallback(f Fallback) BreakerOption {
+	return func(b *GrpcBreaker) {
+		b.fallback = f
+	}
+}
+
+// BreakerListener is called synchronously on state transitions, out of lock of the breaker,
+// events of a breaker may be delivered out of order if it transits concurrently.
+func BreakerListener(l func(e BreakerEvent)) BreakerOption {
+	return func(b *GrpcBreaker) {
+		b.listeners = append(b.listeners, l)
+	}
+}
+
+func BreakerClock(c Clock) BreakerOption {
+	return func(b *GrpcBreaker) {
+		b.clock = c
+	}
+}
+
+// BreakerConfig is circuit breaker declared in configuration.
+type BreakerConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	Window           time.Duration `yaml:"window"`
+	Buckets          int           `yaml:"buckets"`
+	MinRequests      int           `yaml:"min_requests"`
+	ErrorRate        float64       `yaml:"error_rate"`
+	SlowCallDuration time.Duration `yaml:"slow_call_duration"`
+	SlowCallRate     float64       `yaml:"slow_call_rate"`
+	OpenTimeout      time.Duration `yaml:"open_timeout"`
+	HalfOpenRequests int           `yaml:"half_open_requests"`
+	Codes            []int         `yaml:"codes"`
+}
+
+// Options convert c to breaker options, fields not set are skipped.
+func (c BreakerConfig) Options() []BreakerOption {
+	var options []BreakerOption
+	if c.Window > 0 && c.Buckets > 0 {
+		options = append(options, BreakerWindow(c.Window, c.Buckets))
+	}
+	if c.MinRequests > 0 {
+		options = append(options, BreakerMinRequests(c.MinRequests))
+	}
+	if c.ErrorRate > 0 {
+		options = append(options, BreakerErrorRate(c.ErrorRate))
+	}
+	if c.SlowCallDuration > 0 && c.SlowCallRate > 0 {
+		options = append(options, BreakerSlowCall(c.SlowCallDuration, c.SlowCallRate))
+	}
+	if c.OpenTimeout > 0 {
+		options = append(options, BreakerOpenTimeout(c.OpenTimeout))
+	}
+	if c.HalfOpenRequests > 0 {
+		options = append(options, BreakerHalfOpenRequests(c.HalfOpenRequests))
+	}
+	if len(c.Codes) > 0 {
+		options = append(options, BreakerCodes(c.Codes...))
+	}
+	return options
+}
+
+// GrpcBreaker keep a circuit breaker per target and method,
+// calls are rejected with codes.Unavailable while the breaker is open.
+type GrpcBreaker struct {
+	window           time.Duration
+	buckets          int
+	minRequests      int
+	errorRate        float64
+	slowCallDuration time.Duration
+	slowCallRate     float64
+	openTimeout      time.Duration
+	halfOpenRequests int
+	failureCodes     []int
+	fallback         Fallback
+	listeners        []func(e BreakerEvent)
+	clock            Clock
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+func NewGrpcBreaker(options ...BreakerOption) *GrpcBreaker {
+	metric.MustRegister(breakerStateGauge, breakerTransitionCounter, breakerRejectedCounter)
+	b := &GrpcBreaker{
+		window:           defaultBreakerWindow,
+		buckets:          defaultBreakerBuckets,
+		minRequests:      20,
+		errorRate:        0.5,
+		openTimeout:      time.Second * 30,
+		halfOpenRequests: defaultBreakerHalfOpenRequests,
+		failureCodes: []int{
+			int(codes.Unknown), int(codes.DeadlineExceeded), int(codes.Internal), int(codes.Unavailable), int(codes.DataLoss),
+		},
+		clock:    systemClock{},
+		breakers: make(map[string]*breaker),
+	}
+	for _, option := range options {
+		option(b)
+	}
+	// width of buckets must be positive, otherwise buckets can't be located.
+	if b.buckets <= 0 || b.window < time.Duration(b.buckets) {
+		zap.L().Warn("invalid circuit breaker window, use default",
+			zap.Duration("window", b.window), zap.Int("buckets", b.buckets))
+		b.window, b.buckets = defaultBreakerWindow, defaultBreakerBuckets
+	}
+	// breaker stuck in half open if no probe is allowed.
+	if b.halfOpenRequests <= 0 {
+		b.halfOpenRequests = defaultBreakerHalfOpenRequests
+	}
+	return b
+}
+
+func (g *GrpcBreaker) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	b := g.breaker(cc.Target(), method)
+	done, err := b.allow()
+	if err == nil {
+		start := g.clock.Now()
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		done(g.isFailure(err), g.clock.Now().Sub(start))
+	}
+	if err != nil && g.fallback != nil && (err == errBreakerOpen || g.isFailure(err)) {
+		return g.fallback(ctx, method, req, reply, err)
+	}
+	return err
+}
+
+// StreamClientInterceptor guard stream creation only, errors of messages are not counted.
+func (g *GrpcBreaker) StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	b := g.breaker(cc.Target(), method)
+	done, err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+	start := g.clock.Now()
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	done(g.isFailure(err), g.clock.Now().Sub(start))
+	return stream, err
+}
+
+func (g *GrpcBreaker) isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	return xslice.ContainsInt(g.failureCodes, int(status.Code(err)))
+}
+
+func (g *GrpcBreaker) breaker(target string, method string) *breaker {
+	key := target + method
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	b, ok := g.breakers[key]
+	if !ok {
+		b = &breaker{
+			GrpcBreaker: g,
+			target:      target,
+			method:      method,
+			counts:      make([]bucket, g.buckets),
+		}
+		breakerStateGauge.WithLabelValues(target, method).Set(float64(BreakerClosed))
+		g.breakers[key] = b
+	}
+	return b
+}
+
+var errBreakerOpen = status.Error(codes.Unavailable, "circuit breaker is open")
+
+type bucket struct {
+	start    time.Time
+	total    int
+	failures int
+	slow     int
+}
+
+// breaker is the circuit breaker of a target and method.
+type breaker struct {
+	*GrpcBreaker
+	target string
+	method string
+
+	mu       sync.Mutex
+	state    BreakerState
+	openedAt time.Time
+	counts   []bucket
+	// probes is calls allowed in half open, successes is those succeeded.
+	probes    int
+	successes int
+	// generation is bumped on every transition, results of calls allowed in previous generations are dropped.
+	generation uint64
+	// events is transitions to notify listeners once mu is released.
+	events []BreakerEvent
+}
+
+// allow return done to record the result of the call if it's allowed.
+func (b *breaker) allow() (func(failure bool, elapsed time.Duration), error) {
+	b.mu.Lock()
+	defer b.unlock()
+	now := b.clock.Now()
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.openTimeout {
+		b.transit(BreakerHalfOpen, now)
+	}
+	switch b.state {
+	case BreakerOpen:
+		breakerRejectedCounter.WithLabelValues(b.target, b.method).Inc()
+		return nil, errBreakerOpen
+	case BreakerHalfOpen:
+		if b.probes >= b.halfOpenRequests {
+			breakerRejectedCounter.WithLabelValues(b.target, b.method).Inc()
+			return nil, errBreakerOpen
+		}
+		b.probes++
+	}
+	generation := b.generation
+	return func(failure bool, elapsed time.Duration) { b.record(generation, failure, elapsed) }, nil
+}
+
+func (b *breaker) record(generation uint64, failure bool, elapsed time.Duration) {
+	b.mu.Lock()
+	defer b.unlock()
+	now := b.clock.Now()
+	if generation != b.generation {
+		// result of call allowed before the last transition, even if the state is the same again.
+		return
+	}
+	slow := b.slowCallDuration > 0 && elapsed >= b.slowCallDuration
+	switch b.state {
+	case BreakerHalfOpen:
+		if failure || slow {
+			b.transit(BreakerOpen, now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.halfOpenRequests {
+			b.transit(BreakerClosed, now)
+		}
+	case BreakerClosed:
+		c := b.current(now)
+		c.total++
+		if failure {
+			c.failures++
+		}
+		if slow {
+			c.slow++
+		}
+		if b.tripped(now) {
+			b.transit(BreakerOpen, now)
+		}
+	}
+}
+
+// current return the bucket of now, which is reset if it's out of window.
+func (b *breaker) current(now time.Time) *bucket {
+	width := b.window / time.Duration(b.buckets)
+	start := now.Truncate(width)
+	c := &b.counts[int(start.UnixNano()/int64(width))%b.buckets]
+	if !c.start.Equal(start) {
+		*c = bucket{start: start}
+	}
+	return c
+}
+
+func (b *breaker) tripped(now time.Time) bool {
+	var total, failures, slow int
+	for _, c := range b.counts {
+		if now.Sub(c.start) < b.window {
+			total += c.total
+			failures += c.failures
+			slow += c.slow
+		}
+	}
+	if total == 0 || total < b.minRequests {
+		return false
+	}
+	if float64(failures)/float64(total) >= b.errorRate {
+		return true
+	}
+	return b.slowCallRate > 0 && float64(slow)/float64(total) >= b.slowCallRate
+}
+
+func (b *breaker) transit(to BreakerState, now time.Time) {
+	from := b.state
+	b.state = to
+	switch to {
+	case BreakerOpen:
+		b.openedAt = now
+	case BreakerHalfOpen:
+		b.probes, b.successes = 0, 0
+	case BreakerClosed:
+		for i := range b.counts {
+			b.counts[i] = bucket{}
+		}
+	}
+	breakerStateGauge.WithLabelValues(b.target, b.method).Set(float64(to))
+	breakerTransitionCounter.WithLabelValues(b.target, b.method, from.String(), to.String()).Inc()
+	b.generation++
+	b.events = append(b.events, BreakerEvent{Target: b.target, Method: b.method, From: from, To: to, At: now})
+}
+
+// unlock release mu and notify listeners of transitions out of lock, so that listeners may call the breaker.
+func (b *breaker) unlock() {
+	events := b.events
+	b.events = nil
+	b.mu.Unlock()
+	for _, e := range events {
+		for _, l := range b.listeners {
+			l(e)
+		}
+	}
+}
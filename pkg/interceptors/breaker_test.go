@@ -0,0 +1,179 @@
+package interceptors
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestBreakerStates(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	var transitions []BreakerState
+	g := NewGrpcBreaker(
+		BreakerClock(clock),
+		BreakerMinRequests(4),
+		BreakerErrorRate(0.5),
+		BreakerOpenTimeout(time.Second*30),
+		BreakerHalfOpenRequests(2),
+		BreakerListener(func(e BreakerEvent) { transitions = append(transitions, e.To) }),
+	)
+	b := g.breaker("target", "/test/Method")
+	call := func(failure bool) error {
+		done, err := b.allow()
+		if err == nil {
+			done(failure, time.Millisecond)
+		}
+		return err
+	}
+	expect := func(state BreakerState) {
+		t.Helper()
+		if b.state != state {
+			t.Fatalf("state = %s, want %s", b.state, state)
+		}
+	}
+
+	// closed -> open after failure rate reaches 0.5 of min requests.
+	for _, failure := range []bool{false, true, false} {
+		if err := call(failure); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect(BreakerClosed)
+	_ = call(true)
+	expect(BreakerOpen)
+	if err := call(false); err != errBreakerOpen {
+		t.Fatalf("call in open = %v, want rejected", err)
+	}
+
+	// open -> half open after timeout, only probes are allowed, a failed probe opens again.
+	clock.Add(time.Second * 30)
+	if err := call(true); err != nil {
+		t.Fatalf("probe = %v", err)
+	}
+	expect(BreakerOpen)
+
+	// half open -> closed after all probes succeed.
+	clock.Add(time.Second * 30)
+	done1, err1 := b.allow()
+	done2, err2 := b.allow()
+	if err1 != nil || err2 != nil {
+		t.Fatalf("probes = %v, %v", err1, err2)
+	}
+	if err := call(false); err != errBreakerOpen {
+		t.Fatalf("call over probes = %v, want rejected", err)
+	}
+	done1(false, time.Millisecond)
+	expect(BreakerHalfOpen)
+	done2(false, time.Millisecond)
+	expect(BreakerClosed)
+
+	// counts of window are reset by closing, old failures don't trip it.
+	_ = call(true)
+	expect(BreakerClosed)
+
+	want := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", transitions, want)
+		}
+	}
+}
+
+func TestBreakerGenerations(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	var g *GrpcBreaker
+	var states []BreakerState
+	g = NewGrpcBreaker(
+		BreakerClock(clock),
+		BreakerMinRequests(1),
+		BreakerOpenTimeout(time.Second),
+		BreakerHalfOpenRequests(1),
+		// listeners may call the breaker, they're run out of its lock.
+		BreakerListener(func(e BreakerEvent) {
+			b := g.breaker(e.Target, e.Method)
+			b.mu.Lock()
+			states = append(states, b.state)
+			b.mu.Unlock()
+		}),
+	)
+	b := g.breaker("target", "/test/Method")
+	stale, _ := b.allow()
+	done, _ := b.allow()
+	done(true, time.Millisecond)
+	clock.Add(time.Second)
+	probe, err := b.allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	probe(false, time.Millisecond)
+	if b.state != BreakerClosed {
+		t.Fatalf("state = %s, want closed", b.state)
+	}
+	// the call allowed in the previous closed state doesn't count in the current one.
+	stale(true, time.Millisecond)
+	if b.state != BreakerClosed {
+		t.Fatalf("state after stale failure = %s, want closed", b.state)
+	}
+	if len(states) != 3 {
+		t.Fatalf("states seen by listener = %v, want 3 transitions", states)
+	}
+}
+
+func TestBreakerWindowSlides(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	g := NewGrpcBreaker(BreakerClock(clock), BreakerWindow(time.Second*10, 10), BreakerMinRequests(2))
+	b := g.breaker("target", "/test/Method")
+	done, _ := b.allow()
+	done(true, time.Millisecond)
+	// the failure is out of window, so a single failure doesn't reach min requests.
+	clock.Add(time.Second * 11)
+	done, _ = b.allow()
+	done(true, time.Millisecond)
+	if b.state != BreakerClosed {
+		t.Fatalf("state = %s, want closed", b.state)
+	}
+	done, _ = b.allow()
+	done(true, time.Millisecond)
+	if b.state != BreakerOpen {
+		t.Fatalf("state = %s, want open", b.state)
+	}
+}
+
+func TestBreakerInvalidOptions(t *testing.T) {
+	for _, option := range []BreakerOption{
+		BreakerWindow(time.Second, 0),
+		BreakerWindow(time.Second, -1),
+		BreakerWindow(5, 10),
+	} {
+		g := NewGrpcBreaker(option, BreakerHalfOpenRequests(0))
+		if g.window != defaultBreakerWindow || g.buckets != defaultBreakerBuckets || g.halfOpenRequests != defaultBreakerHalfOpenRequests {
+			t.Errorf("window = %v, buckets = %d, half open requests = %d, want defaults", g.window, g.buckets, g.halfOpenRequests)
+		}
+		// recording must not divide by zero.
+		done, err := g.breaker("target", "/test/Method").allow()
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(true, time.Millisecond)
+	}
+}
@@ -0,0 +1,295 @@
+package interceptors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/go-board/thor/pkg/metric"
+)
+
+// DefaultBulkheadKey is the key of bulkhead config applied to dependencies not listed.
+const DefaultBulkheadKey = "*"
+
+var (
+	bulkheadInflightGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bulkhead_inflight",
+		Help: "Number of calls holding a slot of bulkhead.",
+	}, []string{"name"})
+	bulkheadQueuedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bulkhead_queued",
+		Help: "Number of calls waiting for a slot of bulkhead.",
+	}, []string{"name"})
+	bulkheadRejectedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bulkhead_rejected_total",
+		Help: "Total number of calls rejected by bulkhead, reason is queue_full or timeout.",
+	}, []string{"name", "reason"})
+)
+
+// BulkheadFullError is returned when a call is rejected by bulkhead, it's mapped to codes.ResourceExhausted.
+type BulkheadFullError struct {
+	Name string
+}
+
+func (e *BulkheadFullError) Error() string {
+	return fmt.Sprintf("bulkhead: %s is full", e.Name)
+}
+
+func (e *BulkheadFullError) GRPCStatus() *status.Status {
+	return status.New(codes.ResourceExhausted, e.Error())
+}
+
+type BulkheadOption func(b *Bulkhead)
+
+// BulkheadMaxConcurrent is the maximum concurrent calls, default is 16.
+func BulkheadMaxConcurrent(n int) BulkheadOption {
+	return func(b *Bulkhead) {
+		b.maxConcurrent = n
+	}
+}
+
+// BulkheadMaxQueue is the maximum calls waiting for a slot, calls beyond are rejected at once, default is 0.
+func BulkheadMaxQueue(n int) BulkheadOption {
+	return func(b *Bulkhead) {
+		b.maxQueue = n
+	}
+}
+
+// BulkheadQueueTimeout is the maximum wait for a slot, calls wait until context done if zero.
+func BulkheadQueueTimeout(timeout time.Duration) BulkheadOption {
+	return func(b *Bulkhead) {
+		b.queueTimeout = timeout
+	}
+}
+
+// BulkheadConfig is bulkhead of a dependency declared in configuration.
+type BulkheadConfig struct {
+	MaxConcurrent int           `yaml:"max_concurrent"`
+	MaxQueue      int           `yaml:"max_queue"`
+	QueueTimeout  time.Duration `yaml:"queue_timeout"`
+}
+
+// Options convert c to bulkhead options, fields not set are skipped.
+func (c BulkheadConfig) Options() []BulkheadOption {
+	var options []BulkheadOption
+	if c.MaxConcurrent > 0 {
+		options = append(options, BulkheadMaxConcurrent(c.MaxConcurrent))
+	}
+	if c.MaxQueue > 0 {
+		options = append(options, BulkheadMaxQueue(c.MaxQueue))
+	}
+	if c.QueueTimeout > 0 {
+		options = append(options, BulkheadQueueTimeout(c.QueueTimeout))
+	}
+	return options
+}
+
+// Bulkhead bound concurrent calls to a dependency with a semaphore and a bounded wait queue,
+// so that a slow dependency can't take all goroutines.
+type Bulkhead struct {
+	name          string
+	maxConcurrent int
+	maxQueue      int
+	queueTimeout  time.Duration
+
+	sem     chan struct{}
+	waiting int64
+}
+
+func NewBulkhead(name string, options ...BulkheadOption) *Bulkhead {
+	metric.MustRegister(bulkheadInflightGauge, bulkheadQueuedGauge, bulkheadRejectedCounter)
+	b := &Bulkhead{name: name, maxConcurrent: 16}
+	for _, option := range options {
+		option(b)
+	}
+	b.sem = make(chan struct{}, b.maxConcurrent)
+	return b
+}
+
+func (b *Bulkhead) Name() string { return b.name }
+
+// Acquire take a slot, release must be called once the call is done.
+// *BulkheadFullError is returned if the queue is full or timed out, or context error if ctx is done.
+func (b *Bulkhead) Acquire(ctx context.Context) (release func(), err error) {
+	select {
+	case b.sem <- struct{}{}:
+		return b.acquired(), nil
+	default:
+	}
+	if atomic.AddInt64(&b.waiting, 1) > int64(b.maxQueue) {
+		atomic.AddInt64(&b.waiting, -1)
+		bulkheadRejectedCounter.WithLabelValues(b.name, "queue_full").Inc()
+		return nil, &BulkheadFullError{Name: b.name}
+	}
+	bulkheadQueuedGauge.WithLabelValues(b.name).Inc()
+	defer func() {
+		atomic.AddInt64(&b.waiting, -1)
+		bulkheadQueuedGauge.WithLabelValues(b.name).Dec()
+	}()
+	var timeout <-chan time.Time
+	if b.queueTimeout > 0 {
+		timer := time.NewTimer(b.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case b.sem <- struct{}{}:
+		return b.acquired(), nil
+	case <-timeout:
+		bulkheadRejectedCounter.WithLabelValues(b.name, "timeout").Inc()
+		return nil, &BulkheadFullError{Name: b.name}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *Bulkhead) acquired() func() {
+	bulkheadInflightGauge.WithLabelValues(b.name).Inc()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-b.sem
+			bulkheadInflightGauge.WithLabelValues(b.name).Dec()
+		})
+	}
+}
+
+// Do call f holding a slot.
+func (b *Bulkhead) Do(ctx context.Context, f func(ctx context.Context) error) error {
+	release, err := b.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return f(ctx)
+}
+
+// RoundTripper bound concurrent http requests sent by next, slot is held until response body is closed.
+func (b *Bulkhead) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		release, err := b.Acquire(r.Context())
+		if err != nil {
+			return nil, err
+		}
+		resp, err := next.RoundTrip(r)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+		return resp, nil
+	})
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// Bulkheads hold a bulkhead per dependency, created lazily from configs keyed by dependency name,
+// DefaultBulkheadKey applies to each dependency not listed, dependency without config isn't bounded.
+type Bulkheads struct {
+	configs map[string]BulkheadConfig
+
+	mu        sync.Mutex
+	bulkheads map[string]*Bulkhead
+}
+
+func NewBulkheads(configs map[string]BulkheadConfig) *Bulkheads {
+	return &Bulkheads{configs: configs, bulkheads: make(map[string]*Bulkhead)}
+}
+
+// Get return bulkhead of dependency, nil if it's not configured.
+func (b *Bulkheads) Get(name string) *Bulkhead {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if bulkhead, ok := b.bulkheads[name]; ok {
+		return bulkhead
+	}
+	c, ok := b.configs[name]
+	if !ok {
+		if c, ok = b.configs[DefaultBulkheadKey]; !ok {
+			return nil
+		}
+	}
+	bulkhead := NewBulkhead(name, c.Options()...)
+	b.bulkheads[name] = bulkhead
+	return bulkhead
+}
+
+// UnaryClientInterceptor bound concurrent calls per target, target is named without scheme,
+// e.g. `consul:///user` is named `user`.
+func (b *Bulkheads) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	bulkhead := b.Get(targetName(cc.Target()))
+	if bulkhead == nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	release, err := bulkhead.Acquire(ctx)
+	if err != nil {
+		return toStatusError(err)
+	}
+	defer release()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// StreamClientInterceptor bound concurrent streams per target, slot is held until the stream is done.
+func (b *Bulkheads) StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	bulkhead := b.Get(targetName(cc.Target()))
+	if bulkhead == nil {
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+	release, err := bulkhead.Acquire(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	// context of client stream is canceled once the stream is done.
+	go func() {
+		<-stream.Context().Done()
+		release()
+	}()
+	return stream, nil
+}
+
+func toStatusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.FromContextError(err).Err()
+}
+
+func targetName(target string) string {
+	if i := strings.Index(target, ":///"); i >= 0 {
+		return target[i+4:]
+	}
+	return target
+}
@@ -0,0 +1,104 @@
+package interceptors
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/go-board/thor/pkg/metric"
+)
+
+var clientDeadlineExceededCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "grpc_client_deadline_exceeded_total",
+	Help: "Total number of RPCs exceeded deadline on the client, hop is local if no budget left to send.",
+}, []string{"grpc_method", "hop"})
+
+type DeadlineOption func(d *GrpcDeadline)
+
+// DeadlineMargin reserve time for the caller to handle the response of downstream,
+// it's subtracted from any deadline of ctx, whether propagated from the incoming call or set by the caller itself,
+// since either way the caller has to reply before it. Deadline of DefaultTimeout is not subtracted.
+func DeadlineMargin(margin time.Duration) DeadlineOption {
+	return func(d *GrpcDeadline) {
+		d.margin = margin
+	}
+}
+
+// DefaultTimeout is applied to calls without deadline.
+func DefaultTimeout(timeout time.Duration) DeadlineOption {
+	return func(d *GrpcDeadline) {
+		d.defaultTimeout = timeout
+	}
+}
+
+// GrpcDeadline propagate deadline of ctx to outgoing calls with a safety margin subtracted.
+type GrpcDeadline struct {
+	margin         time.Duration
+	defaultTimeout time.Duration
+}
+
+func NewGrpcDeadline(options ...DeadlineOption) *GrpcDeadline {
+	metric.MustRegister(clientDeadlineExceededCounter)
+	deadline := &GrpcDeadline{}
+	for _, option := range options {
+		option(deadline)
+	}
+	return deadline
+}
+
+func (g *GrpcDeadline) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, cancel, err := g.budget(ctx, method)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	err = invoker(ctx, method, req, reply, cc, opts...)
+	if status.Code(err) == codes.DeadlineExceeded {
+		clientDeadlineExceededCounter.WithLabelValues(method, "remote").Inc()
+	}
+	return err
+}
+
+func (g *GrpcDeadline) StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, cancel, err := g.budget(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		// release timer of the stream context once the stream is done.
+		<-stream.Context().Done()
+		cancel()
+	}()
+	return stream, nil
+}
+
+// budget shrink deadline of ctx by margin, fail fast if nothing left.
+func (g *GrpcDeadline) budget(ctx context.Context, method string) (context.Context, context.CancelFunc, error) {
+	dl, ok := ctx.Deadline()
+	if !ok {
+		if g.defaultTimeout <= 0 {
+			return ctx, func() {}, nil
+		}
+		ctx, cancel := context.WithTimeout(ctx, g.defaultTimeout)
+		return ctx, cancel, nil
+	}
+	if g.margin <= 0 {
+		return ctx, func() {}, nil
+	}
+	remaining := time.Until(dl) - g.margin
+	if remaining <= 0 {
+		clientDeadlineExceededCounter.WithLabelValues(method, "local").Inc()
+		return nil, nil, status.Errorf(codes.DeadlineExceeded, "no budget left for %s after margin %s", method, g.margin)
+	}
+	ctx, cancel := context.WithTimeout(ctx, remaining)
+	return ctx, cancel, nil
+}
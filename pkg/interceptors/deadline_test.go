@@ -0,0 +1,59 @@
+package interceptors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGrpcDeadlineMargin(t *testing.T) {
+	const method = "/pkg.Service/Deadline"
+	d := NewGrpcDeadline(DeadlineMargin(time.Millisecond*100), DefaultTimeout(time.Second))
+	var deadline time.Time
+	var sent bool
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		deadline, sent = ctx.Deadline()
+		return status.Error(codes.DeadlineExceeded, "remote")
+	}
+	counter := func(hop string) float64 {
+		return testutil.ToFloat64(clientDeadlineExceededCounter.WithLabelValues(method, hop))
+	}
+	local, remote := counter("local"), counter("remote")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	want, _ := ctx.Deadline()
+	_ = d.UnaryClientInterceptor(ctx, method, nil, nil, nil, invoker)
+	// the margin is subtracted from remaining time, so the deadline drifts by time spent in between.
+	if want = want.Add(-time.Millisecond * 100); !sent || deadline.Before(want) || deadline.Sub(want) > time.Millisecond*10 {
+		t.Errorf("deadline = %v, want %v with margin subtracted", deadline, want)
+	}
+	if got := counter("remote"); got != remote+1 {
+		t.Errorf("remote deadline exceeded = %v, want %v", got, remote+1)
+	}
+
+	// the default timeout applies to calls without deadline, and margin isn't subtracted from it.
+	sent = false
+	start := time.Now()
+	_ = d.UnaryClientInterceptor(context.Background(), method, nil, nil, nil, invoker)
+	if timeout := deadline.Sub(start); !sent || timeout < time.Second || timeout > time.Second+time.Millisecond*10 {
+		t.Errorf("deadline of call without deadline = %s later, want default timeout", timeout)
+	}
+
+	// nothing left after margin, the call fails locally.
+	sent = false
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	err := d.UnaryClientInterceptor(ctx, method, nil, nil, nil, invoker)
+	if status.Code(err) != codes.DeadlineExceeded || sent {
+		t.Errorf("err = %v, sent = %v, want deadline exceeded without sending", err, sent)
+	}
+	if got := counter("local"); got != local+1 {
+		t.Errorf("local deadline exceeded = %v, want %v", got, local+1)
+	}
+}
@@ -0,0 +1,296 @@
+package interceptors
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-board/x-go/xslice"
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/go-board/thor/pkg/metric"
+	"github.com/go-board/thor/pkg/policy"
+)
+
+const (
+	hedgeLatencySamples    = 128
+	hedgeMinLatencySamples = 20
+)
+
+var (
+	hedgeCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_hedges_total",
+		Help: "Total number of hedged attempts, result is sent, or budget_exhausted if hedge is dropped by budget.",
+	}, []string{"grpc_method", "result"})
+	hedgeWinCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_hedge_wins_total",
+		Help: "Total number of hedged calls succeeded, winner is original if the first attempt won, or hedge otherwise.",
+	}, []string{"grpc_method", "winner"})
+)
+
+type HedgeOption func(h *GrpcHedge)
+
+// HedgeDelay is the delay before sending next attempt, default is 50ms,
+// it's used before enough latencies are sampled if HedgePercentile is set.
+func HedgeDelay(delay time.Duration) HedgeOption {
+	return func(h *GrpcHedge) {
+		h.delay = delay
+	}
+}
+
+// HedgePercentile use percentile of recent latencies of the method as delay, like 0.95.
+func HedgePercentile(p float64) HedgeOption {
+	return func(h *GrpcHedge) {
+		h.percentile = p
+	}
+}
+
+// HedgeMaxAttempts is the maximum attempts including the first one, default is 2.
+func HedgeMaxAttempts(n int) HedgeOption {
+	return func(h *GrpcHedge) {
+		h.maxAttempts = n
+	}
+}
+
+// HedgeCodes is codes of failed attempts that don't fail the call, next attempt is sent immediately,
+// default is Unavailable, other failures are returned at once.
+func HedgeCodes(codes ...int) HedgeOption {
+	return func(h *GrpcHedge) {
+		h.nonFatalCodes = codes
+	}
+}
+
+// HedgeBudget limit hedged attempts in the last 10 seconds to ratio of calls plus minPerSecond per second,
+// default is 0.1 and 1.
+func HedgeBudget(ratio float64, minPerSecond int) HedgeOption {
+	return func(h *GrpcHedge) {
+		h.budget = &retryBudget{ratio: ratio, minPerSecond: minPerSecond}
+	}
+}
+
+// GrpcHedge send idempotent unary calls again if no response is received after delay,
+// the first success wins and other attempts are canceled.
+// Hedge policy of method table generated by protoc-gen-thor overrides max attempts and delay.
+// Attempts are spread over sub connections by the balancer, so `round_robin` is preferred to `pick_first`.
+type GrpcHedge struct {
+	delay         time.Duration
+	percentile    float64
+	maxAttempts   int
+	nonFatalCodes []int
+	budget        *retryBudget
+
+	latencies sync.Map // method -> *latencyWindow
+}
+
+func NewGrpcHedge(options ...HedgeOption) *GrpcHedge {
+	metric.MustRegister(hedgeCounter, hedgeWinCounter)
+	hedge := &GrpcHedge{
+		delay:         time.Millisecond * 50,
+		maxAttempts:   2,
+		nonFatalCodes: []int{int(codes.Unavailable)},
+		budget:        &retryBudget{ratio: 0.1, minPerSecond: 1},
+	}
+	for _, option := range options {
+		option(hedge)
+	}
+	return hedge
+}
+
+type hedgeResult struct {
+	attempt int
+	reply   proto.Message
+	header  metadata.MD
+	trailer metadata.MD
+	peer    peer.Peer
+	latency time.Duration
+	err     error
+}
+
+func (h *GrpcHedge) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	maxAttempts, delay := h.maxAttempts, time.Duration(0)
+	if p, ok := policy.Lookup(method); ok && p.Hedge != nil {
+		maxAttempts, delay = p.Hedge.MaxAttempts, p.Hedge.Delay
+	}
+	template, ok := reply.(proto.Message)
+	if !ok || maxAttempts < 2 || !isIdempotent(ctx, method, req) {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	// only calls that can be hedged earn budget, so that calls never hedged don't inflate it.
+	h.budget.deposit()
+	if delay <= 0 {
+		delay = h.hedgeDelay(method)
+	}
+	opts, header, trailer, p := splitCallOptions(opts)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan *hedgeResult, maxAttempts)
+	sent := 0
+	send := func() {
+		r := &hedgeResult{attempt: sent, reply: proto.Clone(template)}
+		r.reply.Reset()
+		sent++
+		go func() {
+			start := time.Now()
+			// opts is capped so that each attempt appends to its own slice.
+			r.err = invoker(ctx, method, req, r.reply, cc, append(opts[:len(opts):len(opts)], grpc.Header(&r.header), grpc.Trailer(&r.trailer), grpc.Peer(&r.peer))...)
+			r.latency = time.Since(start)
+			results <- r
+		}()
+	}
+	// hedge send next attempt if allowed by max attempts and budget.
+	hedge := func() bool {
+		if sent >= maxAttempts {
+			return false
+		}
+		if !h.budget.withdraw() {
+			hedgeCounter.WithLabelValues(method, "budget_exhausted").Inc()
+			sent = maxAttempts
+			return false
+		}
+		hedgeCounter.WithLabelValues(method, "sent").Inc()
+		send()
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	send()
+	var last *hedgeResult
+	for pending := 1; pending > 0; {
+		select {
+		case r := <-results:
+			pending--
+			last = r
+			if r.err == nil {
+				h.observe(method, r.latency)
+				if r.attempt == 0 {
+					hedgeWinCounter.WithLabelValues(method, "original").Inc()
+				} else {
+					hedgeWinCounter.WithLabelValues(method, "hedge").Inc()
+				}
+				template.Reset()
+				proto.Merge(template, r.reply)
+				copyCallResult(r, header, trailer, p)
+				return nil
+			}
+			if !xslice.ContainsInt(h.nonFatalCodes, int(status.Code(r.err))) {
+				copyCallResult(r, header, trailer, p)
+				return r.err
+			}
+			if hedge() {
+				pending++
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(delay)
+			}
+		case <-timer.C:
+			if hedge() {
+				pending++
+				timer.Reset(delay)
+			}
+		}
+	}
+	copyCallResult(last, header, trailer, p)
+	return last.err
+}
+
+// hedgeDelay return percentile of sampled latencies if enabled and sampled enough, or the static delay.
+func (h *GrpcHedge) hedgeDelay(method string) time.Duration {
+	if h.percentile <= 0 {
+		return h.delay
+	}
+	if w, ok := h.latencies.Load(method); ok {
+		if d, ok := w.(*latencyWindow).percentile(h.percentile); ok {
+			return d
+		}
+	}
+	return h.delay
+}
+
+func (h *GrpcHedge) observe(method string, latency time.Duration) {
+	if h.percentile <= 0 {
+		return
+	}
+	w, _ := h.latencies.LoadOrStore(method, &latencyWindow{})
+	w.(*latencyWindow).add(latency)
+}
+
+// splitCallOptions remove options written by the call, which are filled by the winning attempt instead.
+func splitCallOptions(opts []grpc.CallOption) ([]grpc.CallOption, *metadata.MD, *metadata.MD, *peer.Peer) {
+	var (
+		rest    = make([]grpc.CallOption, 0, len(opts))
+		header  *metadata.MD
+		trailer *metadata.MD
+		p       *peer.Peer
+	)
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case grpc.HeaderCallOption:
+			header = o.HeaderAddr
+		case grpc.TrailerCallOption:
+			trailer = o.TrailerAddr
+		case grpc.PeerCallOption:
+			p = o.PeerAddr
+		default:
+			rest = append(rest, opt)
+		}
+	}
+	return rest, header, trailer, p
+}
+
+func copyCallResult(r *hedgeResult, header *metadata.MD, trailer *metadata.MD, p *peer.Peer) {
+	if header != nil {
+		*header = r.header
+	}
+	if trailer != nil {
+		*trailer = r.trailer
+	}
+	if p != nil {
+		*p = r.peer
+	}
+}
+
+// latencyWindow keep recent latencies of successful calls.
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples [hedgeLatencySamples]time.Duration
+	n       int
+}
+
+func (w *latencyWindow) add(latency time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.samples[w.n%len(w.samples)] = latency
+	w.n++
+}
+
+func (w *latencyWindow) percentile(p float64) (time.Duration, bool) {
+	w.mu.Lock()
+	n := w.n
+	if n > len(w.samples) {
+		n = len(w.samples)
+	}
+	samples := append([]time.Duration{}, w.samples[:n]...)
+	w.mu.Unlock()
+	if n < hedgeMinLatencySamples {
+		return 0, false
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	i := int(p * float64(n))
+	if i >= n {
+		i = n - 1
+	}
+	return samples[i], true
+}
@@ -0,0 +1,181 @@
+package interceptors
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// slowService respond attempt i after latencies[i] or fail it with errs[i] if set,
+// the attempt number is sent as header and payload, attempts canceled are sent to canceled.
+type slowService struct {
+	testpb.UnimplementedTestServiceServer
+	latencies []time.Duration
+	errs      []error
+	attempts  int64
+	canceled  chan int
+}
+
+func (s *slowService) UnaryCall(ctx context.Context, req *testpb.SimpleRequest) (*testpb.SimpleResponse, error) {
+	i := int(atomic.AddInt64(&s.attempts, 1) - 1)
+	_ = grpc.SetHeader(ctx, metadata.Pairs("attempt", strconv.Itoa(i)))
+	if i < len(s.errs) && s.errs[i] != nil {
+		return nil, s.errs[i]
+	}
+	if i < len(s.latencies) {
+		select {
+		case <-time.After(s.latencies[i]):
+		case <-ctx.Done():
+			s.canceled <- i
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	return &testpb.SimpleResponse{Payload: &testpb.Payload{Body: []byte(strconv.Itoa(i))}}, nil
+}
+
+// hedgeCall call s hedged by h idempotently, and return the attempt won, or the error.
+func hedgeCall(t *testing.T, s *slowService, h *GrpcHedge) (string, error) {
+	if s.canceled == nil {
+		s.canceled = make(chan int, 8)
+	}
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	testpb.RegisterTestServiceServer(srv, s)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+	cc, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithUnaryInterceptor(h.UnaryClientInterceptor),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return ln.Dial() }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cc.Close() })
+	// options written by the call are removed, which leaves spare capacity that attempts must not share.
+	var header, trailer metadata.MD
+	var p peer.Peer
+	resp, err := testpb.NewTestServiceClient(cc).UnaryCall(idempotentContext(t), &testpb.SimpleRequest{},
+		grpc.WaitForReady(true), grpc.Header(&header), grpc.Trailer(&trailer), grpc.Peer(&p))
+	if err != nil {
+		return "", err
+	}
+	if got := header.Get("attempt"); len(got) != 1 || got[0] != string(resp.GetPayload().GetBody()) {
+		t.Errorf("header = %v, want header of attempt %s won", header, resp.GetPayload().GetBody())
+	}
+	if p.Addr == nil {
+		t.Error("peer of the attempt won isn't set")
+	}
+	return string(resp.GetPayload().GetBody()), nil
+}
+
+func TestHedgeFirstSuccessWins(t *testing.T) {
+	wins := func(winner string) float64 {
+		return testutil.ToFloat64(hedgeWinCounter.WithLabelValues(unaryCallMethod, winner))
+	}
+	original, hedged := wins("original"), wins("hedge")
+	h := NewGrpcHedge(HedgeDelay(time.Millisecond*20), HedgeBudget(1, 10))
+
+	s := &slowService{latencies: []time.Duration{time.Second * 5}}
+	start := time.Now()
+	if winner, err := hedgeCall(t, s, h); err != nil || winner != "1" {
+		t.Fatalf("winner = %s, err = %v, want the hedge won", winner, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("elapsed = %s, want the slow attempt not waited", elapsed)
+	}
+	select {
+	case i := <-s.canceled:
+		if i != 0 {
+			t.Errorf("canceled attempt = %d, want 0", i)
+		}
+	case <-time.After(time.Second):
+		t.Error("the slow attempt isn't canceled")
+	}
+
+	s = &slowService{}
+	if winner, err := hedgeCall(t, s, h); err != nil || winner != "0" || atomic.LoadInt64(&s.attempts) != 1 {
+		t.Fatalf("winner = %s, err = %v, attempts = %d, want the original won without hedge", winner, err, s.attempts)
+	}
+	if got := wins("hedge"); got != hedged+1 {
+		t.Errorf("hedge wins = %v, want %v", got, hedged+1)
+	}
+	if got := wins("original"); got != original+1 {
+		t.Errorf("original wins = %v, want %v", got, original+1)
+	}
+}
+
+func TestHedgeDelay(t *testing.T) {
+	const method = "/pkg.Service/Hedged"
+	static := NewGrpcHedge(HedgeDelay(time.Millisecond * 50))
+	percentile := NewGrpcHedge(HedgeDelay(time.Millisecond*50), HedgePercentile(0.9))
+	for i := 0; i < hedgeMinLatencySamples-1; i++ {
+		static.observe(method, time.Millisecond*time.Duration(i+1))
+		percentile.observe(method, time.Millisecond*time.Duration(i+1))
+	}
+	// the static delay is used until enough latencies are sampled.
+	if d := percentile.hedgeDelay(method); d != time.Millisecond*50 {
+		t.Errorf("delay of few samples = %s, want static delay", d)
+	}
+	static.observe(method, time.Millisecond*20)
+	percentile.observe(method, time.Millisecond*20)
+	if d := percentile.hedgeDelay(method); d != time.Millisecond*19 {
+		t.Errorf("delay of percentile = %s, want 19ms", d)
+	}
+	if d := static.hedgeDelay(method); d != time.Millisecond*50 {
+		t.Errorf("delay without percentile = %s, want static delay", d)
+	}
+}
+
+func TestHedgeBudget(t *testing.T) {
+	exhausted := testutil.ToFloat64(hedgeCounter.WithLabelValues(unaryCallMethod, "budget_exhausted"))
+	h := NewGrpcHedge(HedgeDelay(time.Millisecond*10), HedgeBudget(0, 0))
+	s := &slowService{latencies: []time.Duration{time.Millisecond * 100}}
+	if winner, err := hedgeCall(t, s, h); err != nil || winner != "0" || atomic.LoadInt64(&s.attempts) != 1 {
+		t.Fatalf("winner = %s, err = %v, attempts = %d, want no hedge out of budget", winner, err, s.attempts)
+	}
+	if got := testutil.ToFloat64(hedgeCounter.WithLabelValues(unaryCallMethod, "budget_exhausted")); got != exhausted+1 {
+		t.Errorf("budget exhausted = %v, want %v", got, exhausted+1)
+	}
+}
+
+func TestHedgeCodes(t *testing.T) {
+	h := NewGrpcHedge(HedgeDelay(time.Second*5), HedgeBudget(1, 10))
+	// the non-fatal failure sends the hedge at once instead of after the delay.
+	s := &slowService{errs: []error{status.Error(codes.Unavailable, "unavailable")}}
+	start := time.Now()
+	if winner, err := hedgeCall(t, s, h); err != nil || winner != "1" {
+		t.Fatalf("winner = %s, err = %v, want the hedge won", winner, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("elapsed = %s, want the hedge sent immediately", elapsed)
+	}
+
+	s = &slowService{errs: []error{status.Error(codes.Internal, "internal")}}
+	if _, err := hedgeCall(t, s, h); status.Code(err) != codes.Internal || atomic.LoadInt64(&s.attempts) != 1 {
+		t.Fatalf("err = %v, attempts = %d, want the fatal failure returned at once", err, s.attempts)
+	}
+}
+
+func TestHedgeBudgetDeposit(t *testing.T) {
+	h := NewGrpcHedge()
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return nil
+	}
+	// calls not idempotent are never hedged, so they don't earn budget.
+	_ = h.UnaryClientInterceptor(context.Background(), unaryCallMethod, &testpb.SimpleRequest{}, &testpb.SimpleResponse{}, nil, invoker)
+	for _, b := range h.budget.buckets {
+		if b.calls != 0 {
+			t.Fatalf("calls deposited = %d, want 0", b.calls)
+		}
+	}
+}
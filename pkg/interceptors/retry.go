@@ -3,29 +3,116 @@ package interceptors
 import (
 	"context"
 	"errors"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/go-board/x-go/xslice"
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+
+	thorerrors "github.com/go-board/thor/pkg/errors"
+	"github.com/go-board/thor/pkg/metric"
+	"github.com/go-board/thor/pkg/policy"
+	thor_proto "github.com/go-board/thor/proto"
+)
+
+const (
+	// IdempotentKey is the outgoing metadata key marking the call idempotent if value is `1` or `true`.
+	IdempotentKey = "is_idempotent"
+	// RetryPushbackKey is the trailer key of server pushback in milliseconds, negative value means don't retry.
+	RetryPushbackKey = "grpc-retry-pushback-ms"
 )
 
+var retryCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "grpc_client_retries_total",
+	Help: "Total number of retries, result is retried, or budget_exhausted if retry is dropped by budget.",
+}, []string{"grpc_method", "result"})
+
 type RetryOption func(r *GrpcRetry)
 
+// RetryCodes is codes to retry, default is Unavailable.
 func RetryCodes(codes ...int) RetryOption {
 	return func(r *GrpcRetry) {
 		r.retryCodes = codes
 	}
 }
 
+// RetryErrors is errors to retry, matched by errors.Is.
+func RetryErrors(errs ...error) RetryOption {
+	return func(r *GrpcRetry) {
+		r.retryErrors = errs
+	}
+}
+
+// MaxRetries is the maximum retries after the first attempt, default is 2,
+// `max_retries` of method policy or request message takes precedence.
+func MaxRetries(n int) RetryOption {
+	return func(r *GrpcRetry) {
+		r.maxRetries = n
+	}
+}
+
+// RetryBackoff set the exponential backoff, delay of nth retry is base * 2^n capped by max,
+// default is 50ms and 1s.
+func RetryBackoff(base time.Duration, max time.Duration) RetryOption {
+	return func(r *GrpcRetry) {
+		r.backoffBase = base
+		r.backoffMax = max
+	}
+}
+
+// RetryJitter randomize backoff delay by ±fraction, default is 0.2.
+func RetryJitter(fraction float64) RetryOption {
+	return func(r *GrpcRetry) {
+		r.jitter = fraction
+	}
+}
+
+// RetryPerAttemptTimeout bound each attempt, attempts timed out are retried if the call deadline isn't exceeded.
+func RetryPerAttemptTimeout(timeout time.Duration) RetryOption {
+	return func(r *GrpcRetry) {
+		r.perAttemptTimeout = timeout
+	}
+}
+
+// RetryBudget limit retries in the last 10 seconds to ratio of calls plus minPerSecond per second,
+// so that retries don't amplify an outage.
+func RetryBudget(ratio float64, minPerSecond int) RetryOption {
+	return func(r *GrpcRetry) {
+		r.budget = &retryBudget{ratio: ratio, minPerSecond: minPerSecond}
+	}
+}
+
+// GrpcRetry retry idempotent calls, a call is idempotent if IdempotentKey is set in outgoing metadata,
+// or declared by method table generated by protoc-gen-thor, or `is_idempotent` option of request message is true.
 type GrpcRetry struct {
-	retryCodes  []int
-	retryErrors []error
-	maxRetries  int
+	retryCodes        []int
+	retryErrors       []error
+	maxRetries        int
+	backoffBase       time.Duration
+	backoffMax        time.Duration
+	jitter            float64
+	perAttemptTimeout time.Duration
+	budget            *retryBudget
+	streamBuffer      int
 }
 
 func NewGrpcRetry(options ...RetryOption) *GrpcRetry {
-	retry := &GrpcRetry{}
+	metric.MustRegister(retryCounter)
+	retry := &GrpcRetry{
+		retryCodes:   []int{int(codes.Unavailable)},
+		maxRetries:   2,
+		backoffBase:  time.Millisecond * 50,
+		backoffMax:   time.Second,
+		jitter:       0.2,
+		streamBuffer: defaultRetryStreamBuffer,
+	}
 	for _, option := range options {
 		option(retry)
 	}
@@ -33,55 +120,196 @@ func NewGrpcRetry(options ...RetryOption) *GrpcRetry {
 }
 
 func (g *GrpcRetry) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
-	if !isIdempotent(ctx) {
+	if g.budget != nil {
+		g.budget.deposit()
+	}
+	if !isIdempotent(ctx, method, req) {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	maxRetries := g.maxRetries
+	if p, ok := policy.Lookup(method); ok && p.MaxRetries > 0 {
+		maxRetries = p.MaxRetries
+	} else if n, ok := messageMaxRetries(req); ok {
+		maxRetries = n
+	}
+	for attempt := 0; ; attempt++ {
+		var trailer metadata.MD
+		err := g.invoke(ctx, method, req, reply, cc, invoker, append(opts, grpc.Trailer(&trailer))...)
+		if err == nil || attempt >= maxRetries || ctx.Err() != nil {
+			return err
+		}
+		delay, pushed, allowed := pushback(err, trailer)
+		if !allowed || (!pushed && !g.retryable(err)) {
+			return err
+		}
+		if delay <= 0 {
+			delay = g.backoff(attempt)
+		}
+		if g.budget != nil && !g.budget.withdraw() {
+			retryCounter.WithLabelValues(method, "budget_exhausted").Inc()
+			return err
+		}
+		retryCounter.WithLabelValues(method, "retried").Inc()
+		timer := time.NewTimer(delay)
 		select {
-		default:
 		case <-ctx.Done():
-			return ctx.Err()
+			timer.Stop()
+			return err
+		case <-timer.C:
 		}
+	}
+}
+
+func (g *GrpcRetry) invoke(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if g.perAttemptTimeout <= 0 {
 		return invoker(ctx, method, req, reply, cc, opts...)
 	}
+	ctx, cancel := context.WithTimeout(ctx, g.perAttemptTimeout)
+	defer cancel()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// StreamClientInterceptor retry idempotent streams failed before any response is received,
+// by replaying sent messages buffered up to RetryStreamBuffer.
+// Per attempt timeout isn't applied to streams.
+func (g *GrpcRetry) StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if g.budget != nil {
+		g.budget.deposit()
+	}
+	if !isIdempotent(ctx, method, nil) {
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+	return g.newRetryStream(ctx, desc, cc, method, streamer, opts...)
+}
 
-	var err error
-	for i := 0; i < g.maxRetries; i++ {
-		select {
-		default:
-		case <-ctx.Done():
-			return ctx.Err()
+// retryable report whether err is retryable, attempts timed out are retryable.
+func (g *GrpcRetry) retryable(err error) bool {
+	code := status.Code(err)
+	if code == codes.DeadlineExceeded && g.perAttemptTimeout > 0 {
+		return true
+	}
+	if xslice.ContainsInt(g.retryCodes, int(code)) {
+		return true
+	}
+	for _, retryErr := range g.retryErrors {
+		if errors.Is(err, retryErr) {
+			return true
 		}
-		err = invoker(ctx, method, req, reply, cc, opts...)
-		if err != nil {
-			if sts, ok := status.FromError(err); ok {
-				if xslice.ContainsInt(g.retryCodes, int(sts.Code())) {
-					continue
-				}
-			}
-			for _, checkErr := range g.retryErrors {
-				if errors.Is(err, checkErr) {
-					continue
-				}
-			}
+	}
+	return false
+}
+
+func (g *GrpcRetry) backoff(attempt int) time.Duration {
+	delay := g.backoffMax
+	if attempt < 32 && g.backoffBase<<uint(attempt) < g.backoffMax {
+		delay = g.backoffBase << uint(attempt)
+	}
+	if g.jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + g.jitter*(2*rand.Float64()-1)))
+	}
+	return delay
+}
+
+// pushback return delay asked by server with RetryPushbackKey trailer or RetryInfo detail,
+// pushed is true if server asked to retry, which makes the error retryable,
+// allowed is false if server asked not to retry.
+func pushback(err error, trailer metadata.MD) (delay time.Duration, pushed bool, allowed bool) {
+	if values := trailer.Get(RetryPushbackKey); len(values) > 0 {
+		ms, e := strconv.ParseInt(values[0], 10, 64)
+		if e != nil || ms < 0 {
+			return 0, false, false
 		}
+		return time.Duration(ms) * time.Millisecond, true, true
+	}
+	if e := thorerrors.FromError(err); e.Retryable {
+		return e.RetryDelay, true, true
 	}
-	return err
+	return 0, false, true
 }
 
-func (g *GrpcRetry) StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
-	if !isIdempotent(ctx) {
-		return streamer(ctx, desc, cc, method, opts...)
+// isIdempotent check outgoing metadata, method table generated by protoc-gen-thor, then message option in order.
+func isIdempotent(ctx context.Context, method string, req interface{}) bool {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		if values := md.Get(IdempotentKey); len(values) > 0 {
+			return values[0] == "1" || values[0] == "true"
+		}
 	}
-	// todo: handle stream client retry
-	return streamer(ctx, desc, cc, method, opts...)
+	if p, ok := policy.Lookup(method); ok {
+		return p.Idempotent
+	}
+	opts := messageOptions(req)
+	if opts == nil {
+		return false
+	}
+	ext, err := proto.GetExtension(opts, thor_proto.E_IsIdempotent)
+	return err == nil && *ext.(*bool)
+}
+
+func messageMaxRetries(req interface{}) (int, bool) {
+	opts := messageOptions(req)
+	if opts == nil {
+		return 0, false
+	}
+	ext, err := proto.GetExtension(opts, thor_proto.E_MaxRetries)
+	if err != nil {
+		return 0, false
+	}
+	return int(*ext.(*int32)), true
+}
+
+func messageOptions(req interface{}) proto.Message {
+	m, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+	opts, _ := proto.MessageReflect(m).Descriptor().Options().(proto.Message)
+	return opts
+}
+
+type budgetBucket struct {
+	second  int64
+	calls   int
+	retries int
+}
+
+// retryBudget count calls and retries in 10 buckets of a second.
+type retryBudget struct {
+	ratio        float64
+	minPerSecond int
+
+	mu      sync.Mutex
+	buckets [10]budgetBucket
+}
+
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.current().calls++
 }
 
-func isIdempotent(ctx context.Context) bool {
-	isIdempotent := false
-	if md, ok := metadata.FromIncomingContext(ctx); ok {
-		if val, ok := md["is_idempotent"]; ok {
-			if len(val) > 0 && val[0] == "1" {
-				isIdempotent = true
-			}
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now().Unix()
+	var calls, retries int
+	for _, bucket := range b.buckets {
+		if now-bucket.second < int64(len(b.buckets)) {
+			calls += bucket.calls
+			retries += bucket.retries
 		}
 	}
-	return isIdempotent
+	if float64(retries) >= b.ratio*float64(calls)+float64(b.minPerSecond*len(b.buckets)) {
+		return false
+	}
+	b.current().retries++
+	return true
+}
+
+func (b *retryBudget) current() *budgetBucket {
+	now := time.Now().Unix()
+	bucket := &b.buckets[now%int64(len(b.buckets))]
+	if bucket.second != now {
+		bucket.second, bucket.calls, bucket.retries = now, 0, 0
+	}
+	return bucket
 }
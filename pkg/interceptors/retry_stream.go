@@ -0,0 +1,267 @@
+package interceptors
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/go-board/thor/pkg/policy"
+)
+
+const defaultRetryStreamBuffer = 64 << 10
+
+// RetryStreamBuffer is the maximum bytes of sent messages buffered for replay, default is 64KB,
+// stream isn't retried once the buffer overflowed.
+func RetryStreamBuffer(size int) RetryOption {
+	return func(r *GrpcRetry) {
+		r.streamBuffer = size
+	}
+}
+
+// retryStream replay buffered messages on a new stream if the stream failed before committed,
+// it's committed once a response or header is received, or sent messages overflowed the buffer.
+// Failure of SendMsg is reported by RecvMsg, so it returns nil before committed.
+// Max retries is resolved like unary calls, `max_retries` of the first request message applies
+// unless method policy declares it.
+type retryStream struct {
+	*GrpcRetry
+	ctx      context.Context
+	desc     *grpc.StreamDesc
+	cc       *grpc.ClientConn
+	method   string
+	streamer grpc.Streamer
+	opts     []grpc.CallOption
+
+	mu sync.Mutex
+	// maxRetries shadow the one of GrpcRetry, it's resolved per method.
+	maxRetries int
+	byPolicy   bool
+	stream     grpc.ClientStream
+	trailer    metadata.MD
+	attempt    int
+	committed  bool
+	closeSent  bool
+	buffer     []proto.Message
+	bufferSize int
+	sent       bool
+	retrying   chan struct{} // closed when retrying in progress is done
+}
+
+func (g *GrpcRetry) newRetryStream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	s := &retryStream{
+		GrpcRetry:  g,
+		ctx:        ctx,
+		desc:       desc,
+		cc:         cc,
+		method:     method,
+		streamer:   streamer,
+		opts:       opts,
+		maxRetries: g.maxRetries,
+	}
+	if p, ok := policy.Lookup(method); ok && p.MaxRetries > 0 {
+		s.maxRetries, s.byPolicy = p.MaxRetries, true
+	}
+	for {
+		stream, trailer, err := s.newStream()
+		if err == nil {
+			s.stream, s.trailer = stream, trailer
+			return s, nil
+		}
+		if s.attempt >= s.maxRetries {
+			return nil, err
+		}
+		delay, ok := s.delay(err, trailer)
+		if !ok || !s.wait(delay) {
+			return nil, err
+		}
+	}
+}
+
+// newStream create a stream and replay buffered messages on it,
+// replay failed by broken stream is reported by RecvMsg of the new stream.
+func (s *retryStream) newStream() (grpc.ClientStream, metadata.MD, error) {
+	trailer := metadata.MD{}
+	stream, err := s.streamer(s.ctx, s.desc, s.cc, s.method, append(s.opts, grpc.Trailer(&trailer))...)
+	if err != nil {
+		return nil, trailer, err
+	}
+	for _, m := range s.buffer {
+		if err := stream.SendMsg(m); err == io.EOF {
+			return stream, trailer, nil
+		} else if err != nil {
+			return nil, trailer, err
+		}
+	}
+	if s.closeSent {
+		if err := stream.CloseSend(); err != nil {
+			return nil, trailer, err
+		}
+	}
+	return stream, trailer, nil
+}
+
+// delay return delay before next attempt and count the attempt, false if err isn't retryable.
+func (s *retryStream) delay(err error, trailer metadata.MD) (time.Duration, bool) {
+	if s.ctx.Err() != nil {
+		return 0, false
+	}
+	delay, pushed, allowed := pushback(err, trailer)
+	if !allowed || (!pushed && !s.retryable(err)) {
+		return 0, false
+	}
+	if delay <= 0 {
+		delay = s.GrpcRetry.backoff(s.attempt)
+	}
+	if s.budget != nil && !s.budget.withdraw() {
+		retryCounter.WithLabelValues(s.method, "budget_exhausted").Inc()
+		return 0, false
+	}
+	retryCounter.WithLabelValues(s.method, "retried").Inc()
+	s.attempt++
+	return delay, true
+}
+
+// wait for delay, false if ctx is done.
+func (s *retryStream) wait(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-s.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// retry replace failed stream with a new one, stream is nil if the call gave up.
+// s.mu isn't held while waiting out the backoff, so that SendMsg keeps buffering messages for replay,
+// concurrent retries of the same stream wait for the one in progress.
+func (s *retryStream) retry(failed grpc.ClientStream, err error) (grpc.ClientStream, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.retrying != nil {
+		retrying := s.retrying
+		s.mu.Unlock()
+		<-retrying
+		s.mu.Lock()
+	}
+	if s.stream != failed {
+		// replaced by another goroutine already.
+		return s.stream, nil
+	}
+	s.retrying = make(chan struct{})
+	defer func() {
+		close(s.retrying)
+		s.retrying = nil
+	}()
+	for !s.committed && s.attempt < s.maxRetries {
+		delay, ok := s.delay(err, s.trailer)
+		if !ok {
+			break
+		}
+		s.mu.Unlock()
+		waited := s.wait(delay)
+		s.mu.Lock()
+		if !waited || s.committed {
+			break
+		}
+		stream, trailer, e := s.newStream()
+		if e == nil {
+			s.stream, s.trailer = stream, trailer
+			return stream, nil
+		}
+		s.trailer, err = trailer, e
+	}
+	s.commit()
+	return nil, err
+}
+
+func (s *retryStream) commit() {
+	s.committed = true
+	s.buffer = nil
+}
+
+func (s *retryStream) current() grpc.ClientStream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stream
+}
+
+func (s *retryStream) SendMsg(m interface{}) error {
+	s.mu.Lock()
+	if !s.sent {
+		s.sent = true
+		if n, ok := messageMaxRetries(m); ok && !s.byPolicy {
+			s.maxRetries = n
+		}
+	}
+	if !s.committed {
+		msg, ok := m.(proto.Message)
+		if ok {
+			s.bufferSize += proto.Size(msg)
+		}
+		if !ok || s.bufferSize > s.streamBuffer {
+			s.commit()
+		} else {
+			s.buffer = append(s.buffer, proto.Clone(msg))
+		}
+	}
+	stream, buffered := s.stream, !s.committed
+	s.mu.Unlock()
+	if err := stream.SendMsg(m); err != io.EOF || !buffered {
+		return err
+	}
+	return nil
+}
+
+func (s *retryStream) RecvMsg(m interface{}) error {
+	stream := s.current()
+	for {
+		err := stream.RecvMsg(m)
+		if err == nil {
+			s.mu.Lock()
+			s.commit()
+			s.mu.Unlock()
+			return nil
+		}
+		if err == io.EOF {
+			return err
+		}
+		if stream, err = s.retry(stream, err); stream == nil {
+			return err
+		}
+	}
+}
+
+func (s *retryStream) CloseSend() error {
+	s.mu.Lock()
+	s.closeSent = true
+	stream := s.stream
+	s.mu.Unlock()
+	return stream.CloseSend()
+}
+
+func (s *retryStream) Header() (metadata.MD, error) {
+	stream := s.current()
+	for {
+		md, err := stream.Header()
+		if err == nil {
+			s.mu.Lock()
+			s.commit()
+			s.mu.Unlock()
+			return md, nil
+		}
+		if stream, err = s.retry(stream, err); stream == nil {
+			return md, err
+		}
+	}
+}
+
+func (s *retryStream) Trailer() metadata.MD { return s.current().Trailer() }
+
+func (s *retryStream) Context() context.Context { return s.current().Context() }
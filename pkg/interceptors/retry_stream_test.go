@@ -0,0 +1,120 @@
+package interceptors
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/go-board/thor/pkg/policy"
+)
+
+// flakyService fail the first failures streams with Unavailable after receiving a message.
+type flakyService struct {
+	testpb.UnimplementedTestServiceServer
+	failures int64
+	attempts int64
+}
+
+func (s *flakyService) FullDuplexCall(stream testpb.TestService_FullDuplexCallServer) error {
+	attempt := atomic.AddInt64(&s.attempts, 1)
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		if attempt <= s.failures {
+			return status.Error(codes.Unavailable, "injected failure")
+		}
+		if err := stream.Send(&testpb.StreamingOutputCallResponse{Payload: req.GetPayload()}); err != nil {
+			return err
+		}
+	}
+}
+
+// dialRetry serve s on bufconn and return a client retrying streams by g.
+func dialRetry(t *testing.T, s *flakyService, g *GrpcRetry) testpb.TestServiceClient {
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	testpb.RegisterTestServiceServer(srv, s)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+	cc, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithStreamInterceptor(g.StreamClientInterceptor),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return ln.Dial() }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cc.Close() })
+	return testpb.NewTestServiceClient(cc)
+}
+
+func payload(body string) *testpb.StreamingOutputCallRequest {
+	return &testpb.StreamingOutputCallRequest{Payload: &testpb.Payload{Body: []byte(body)}}
+}
+
+func TestRetryStreamMaxRetriesOfPolicy(t *testing.T) {
+	// the client disables retries except methods declared by policy, like client.New does.
+	g := NewGrpcRetry(MaxRetries(0), RetryBackoff(time.Millisecond, time.Millisecond))
+
+	s := &flakyService{failures: 2}
+	stream, err := dialRetry(t, s, g).FullDuplexCall(idempotentContext(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = stream.Send(payload("a"))
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable || atomic.LoadInt64(&s.attempts) != 1 {
+		t.Fatalf("err = %v, attempts = %d, want failure without retry", err, s.attempts)
+	}
+
+	policy.Register("grpc.testing.TestService", policy.Service{"FullDuplexCall": {Idempotent: true, MaxRetries: 2}})
+	defer policy.Register("grpc.testing.TestService", nil)
+	s = &flakyService{failures: 2}
+	stream, err = dialRetry(t, s, g).FullDuplexCall(idempotentContext(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = stream.Send(payload("a"))
+	resp, err := stream.Recv()
+	if err != nil || string(resp.GetPayload().GetBody()) != "a" || atomic.LoadInt64(&s.attempts) != 3 {
+		t.Fatalf("resp = %v, err = %v, attempts = %d, want success of the third attempt", resp, err, s.attempts)
+	}
+}
+
+func TestRetryStreamSendDuringBackoff(t *testing.T) {
+	backoff := time.Millisecond * 200
+	g := NewGrpcRetry(MaxRetries(1), RetryBackoff(backoff, backoff), RetryJitter(0))
+	s := &flakyService{failures: 1}
+	stream, err := dialRetry(t, s, g).FullDuplexCall(idempotentContext(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = stream.Send(payload("a"))
+
+	sent := make(chan time.Duration, 1)
+	go func() {
+		// send while RecvMsg is waiting out the backoff of retry.
+		time.Sleep(backoff / 4)
+		start := time.Now()
+		_ = stream.Send(payload("b"))
+		sent <- time.Since(start)
+	}()
+	for _, want := range []string{"a", "b"} {
+		resp, err := stream.Recv()
+		if err != nil || string(resp.GetPayload().GetBody()) != want {
+			t.Fatalf("resp = %v, err = %v, want %s replayed", resp, err, want)
+		}
+	}
+	if elapsed := <-sent; elapsed > backoff/2 {
+		t.Errorf("send blocked %v by backoff", elapsed)
+	}
+	if atomic.LoadInt64(&s.attempts) != 2 {
+		t.Errorf("attempts = %d, want 2", s.attempts)
+	}
+}
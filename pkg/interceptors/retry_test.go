@@ -0,0 +1,210 @@
+package interceptors
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	thorerrors "github.com/go-board/thor/pkg/errors"
+	"github.com/go-board/thor/pkg/policy"
+	thor_proto "github.com/go-board/thor/proto"
+)
+
+const unaryCallMethod = "/grpc.testing.TestService/UnaryCall"
+
+// failingService fail the first failures unary calls with err and trailer,
+// or by waiting until the deadline of the attempt if slow.
+type failingService struct {
+	testpb.UnimplementedTestServiceServer
+	failures int64
+	err      error
+	trailer  metadata.MD
+	slow     bool
+	attempts int64
+}
+
+func (s *failingService) UnaryCall(ctx context.Context, req *testpb.SimpleRequest) (*testpb.SimpleResponse, error) {
+	if atomic.AddInt64(&s.attempts, 1) > s.failures {
+		return &testpb.SimpleResponse{}, nil
+	}
+	if s.slow {
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	if s.trailer != nil {
+		_ = grpc.SetTrailer(ctx, s.trailer)
+	}
+	return nil, s.err
+}
+
+// dialUnaryRetry serve s on bufconn and return a conn retrying unary calls by g.
+func dialUnaryRetry(t *testing.T, s *failingService, g *GrpcRetry) *grpc.ClientConn {
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	testpb.RegisterTestServiceServer(srv, s)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+	cc, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithUnaryInterceptor(g.UnaryClientInterceptor),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return ln.Dial() }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cc.Close() })
+	return cc
+}
+
+// unaryCall call s by g idempotently, and return attempts and the error of the call.
+func unaryCall(t *testing.T, s *failingService, g *GrpcRetry) (int64, error) {
+	_, err := testpb.NewTestServiceClient(dialUnaryRetry(t, s, g)).UnaryCall(idempotentContext(t), &testpb.SimpleRequest{})
+	return atomic.LoadInt64(&s.attempts), err
+}
+
+// idempotentContext return a context marking calls idempotent, which is canceled after test.
+func idempotentContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+	return metadata.AppendToOutgoingContext(ctx, IdempotentKey, "1")
+}
+
+// maxRetriesRequest return an empty request whose message type declares `max_retries` option of n.
+func maxRetriesRequest(t *testing.T, n int32) proto.Message {
+	opts := &descriptorpb.MessageOptions{}
+	if err := proto.SetExtension(opts, thor_proto.E_MaxRetries, proto.Int32(n)); err != nil {
+		t.Fatal(err)
+	}
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String(t.Name() + ".proto"),
+		Package:     proto.String("thor.test"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Request"), Options: opts}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proto.MessageV1(dynamicpb.NewMessage(fd.Messages().Get(0)))
+}
+
+func TestRetryStopOnSuccess(t *testing.T) {
+	s := &failingService{failures: 1, err: status.Error(codes.Unavailable, "unavailable")}
+	attempts, err := unaryCall(t, s, NewGrpcRetry(MaxRetries(3), RetryBackoff(time.Millisecond, time.Millisecond)))
+	if err != nil || attempts != 2 {
+		t.Fatalf("err = %v, attempts = %d, want success of the second attempt", err, attempts)
+	}
+}
+
+func TestRetryMaxRetries(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	g := NewGrpcRetry(MaxRetries(1), RetryBackoff(time.Millisecond, time.Millisecond))
+	s := &failingService{failures: 10, err: unavailable}
+	if attempts, err := unaryCall(t, s, g); status.Code(err) != codes.Unavailable || attempts != 2 {
+		t.Errorf("option: err = %v, attempts = %d, want 2 attempts", err, attempts)
+	}
+
+	// max retries of method table takes precedence over the option.
+	policy.Register("grpc.testing.TestService", policy.Service{"UnaryCall": {Idempotent: true, MaxRetries: 3}})
+	defer policy.Register("grpc.testing.TestService", nil)
+	s = &failingService{failures: 10, err: unavailable}
+	if attempts, err := unaryCall(t, s, g); status.Code(err) != codes.Unavailable || attempts != 4 {
+		t.Errorf("policy: err = %v, attempts = %d, want 4 attempts", err, attempts)
+	}
+	policy.Register("grpc.testing.TestService", nil)
+
+	// so does `max_retries` option of request message.
+	s = &failingService{failures: 10, err: unavailable}
+	cc := dialUnaryRetry(t, s, g)
+	if err := cc.Invoke(idempotentContext(t), unaryCallMethod, maxRetriesRequest(t, 2), &testpb.SimpleResponse{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("message: err = %v, want unavailable", err)
+	}
+	if attempts := atomic.LoadInt64(&s.attempts); attempts != 3 {
+		t.Errorf("message: attempts = %d, want 3", attempts)
+	}
+}
+
+func TestRetryCodes(t *testing.T) {
+	internal := status.Error(codes.Internal, "internal")
+	s := &failingService{failures: 1, err: internal}
+	if attempts, err := unaryCall(t, s, NewGrpcRetry(RetryBackoff(time.Millisecond, time.Millisecond))); status.Code(err) != codes.Internal || attempts != 1 {
+		t.Errorf("default codes: err = %v, attempts = %d, want internal not retried", err, attempts)
+	}
+	s = &failingService{failures: 1, err: internal}
+	g := NewGrpcRetry(RetryCodes(int(codes.Internal)), RetryBackoff(time.Millisecond, time.Millisecond))
+	if attempts, err := unaryCall(t, s, g); err != nil || attempts != 2 {
+		t.Errorf("internal code: err = %v, attempts = %d, want internal retried", err, attempts)
+	}
+}
+
+func TestRetryPerAttemptTimeout(t *testing.T) {
+	s := &failingService{failures: 1, slow: true}
+	g := NewGrpcRetry(RetryPerAttemptTimeout(time.Millisecond*50), RetryBackoff(time.Millisecond, time.Millisecond))
+	start := time.Now()
+	attempts, err := unaryCall(t, s, g)
+	if err != nil || attempts != 2 {
+		t.Fatalf("err = %v, attempts = %d, want the attempt timed out retried", err, attempts)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("elapsed = %s, want bounded by the attempt timeout", elapsed)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	exhausted := testutil.ToFloat64(retryCounter.WithLabelValues(unaryCallMethod, "budget_exhausted"))
+	// the budget allows retries as many as calls, so only one retry of the single call.
+	s := &failingService{failures: 10, err: status.Error(codes.Unavailable, "unavailable")}
+	g := NewGrpcRetry(MaxRetries(5), RetryBudget(1, 0), RetryBackoff(time.Millisecond, time.Millisecond))
+	if attempts, err := unaryCall(t, s, g); status.Code(err) != codes.Unavailable || attempts != 2 {
+		t.Errorf("err = %v, attempts = %d, want retries capped by budget", err, attempts)
+	}
+	if got := testutil.ToFloat64(retryCounter.WithLabelValues(unaryCallMethod, "budget_exhausted")); got != exhausted+1 {
+		t.Errorf("budget exhausted = %v, want %v", got, exhausted+1)
+	}
+}
+
+func TestRetryPushback(t *testing.T) {
+	internal := status.Error(codes.Internal, "internal")
+	for _, c := range []struct {
+		name     string
+		s        *failingService
+		attempts int64
+		delay    time.Duration
+	}{
+		// pushback makes the error retryable, and the delay replaces backoff.
+		{name: "trailer", s: &failingService{failures: 1, err: internal, trailer: metadata.Pairs(RetryPushbackKey, "100")}, attempts: 2, delay: time.Millisecond * 100},
+		{name: "retry info", s: &failingService{failures: 1, err: thorerrors.New(codes.Internal, "busy", "busy").WithRetry(time.Millisecond * 100)}, attempts: 2, delay: time.Millisecond * 100},
+		// negative pushback asks not to retry even retryable codes.
+		{name: "negative trailer", s: &failingService{failures: 1, err: status.Error(codes.Unavailable, "unavailable"), trailer: metadata.Pairs(RetryPushbackKey, "-1")}, attempts: 1},
+	} {
+		start := time.Now()
+		attempts, _ := unaryCall(t, c.s, NewGrpcRetry(RetryBackoff(time.Millisecond, time.Millisecond)))
+		if attempts != c.attempts {
+			t.Errorf("%s: attempts = %d, want %d", c.name, attempts, c.attempts)
+		}
+		if elapsed := time.Since(start); elapsed < c.delay {
+			t.Errorf("%s: elapsed = %s, want delay of pushback %s", c.name, elapsed, c.delay)
+		}
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	s := &failingService{failures: 1, err: status.Error(codes.Unavailable, "unavailable")}
+	g := NewGrpcRetry(RetryBackoff(time.Millisecond, time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	_, err := testpb.NewTestServiceClient(dialUnaryRetry(t, s, g)).UnaryCall(ctx, &testpb.SimpleRequest{})
+	if status.Code(err) != codes.Unavailable || atomic.LoadInt64(&s.attempts) != 1 {
+		t.Fatalf("err = %v, attempts = %d, want no retry", err, s.attempts)
+	}
+}
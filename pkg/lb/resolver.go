@@ -1,25 +1,232 @@
 package lb
 
 import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/resolver"
+
+	"github.com/go-board/thor/pkg/registry"
 )
 
-type etcdResolver struct{}
+// ConsulScheme is the scheme of targets like `consul:///service-name` resolved by watching registry.
+const ConsulScheme = "consul"
 
-func (c *etcdResolver) ResolveNow(options resolver.ResolveNowOptions) {
-	panic("implement me")
+// Metadata keys of registry.Service carried to balancers as address attributes.
+const (
+	MetadataVersion = "version"
+	MetadataZone    = "zone"
+	MetadataWeight  = "weight"
+)
+
+// defaultWeight is weight of addresses without valid weight metadata.
+const defaultWeight = 1
+
+type attributeKey string
+
+// Version return version of address resolved from registry.
+func Version(addr resolver.Address) string {
+	v, _ := attributeOf(addr, MetadataVersion).(string)
+	return v
 }
 
-func (c *etcdResolver) Close() {
-	panic("implement me")
+// Zone return zone of address resolved from registry.
+func Zone(addr resolver.Address) string {
+	v, _ := attributeOf(addr, MetadataZone).(string)
+	return v
 }
 
-type consulResolver struct{}
+// Weight return weight of address resolved from registry, default is 1.
+func Weight(addr resolver.Address) int {
+	if v, ok := attributeOf(addr, MetadataWeight).(int); ok {
+		return v
+	}
+	return defaultWeight
+}
+
+func attributeOf(addr resolver.Address, key string) interface{} {
+	if addr.Attributes == nil {
+		return nil
+	}
+	return addr.Attributes.Value(attributeKey(key))
+}
+
+func init() {
+	// consul agent is configured by environment like CONSUL_HTTP_ADDR, RegisterConsul replace it.
+	if c, err := api.NewClient(api.DefaultConfig()); err == nil {
+		resolver.Register(NewConsulBuilder(registry.NewConsul(c)))
+	}
+}
+
+// ResolverOption configure consul resolver.
+type ResolverOption func(b *consulBuilder)
+
+// WatchBackoff set backoff of re-watching after watch errors, default is backoff.DefaultConfig.
+func WatchBackoff(config backoff.Config) ResolverOption {
+	return func(b *consulBuilder) { b.backoff = config }
+}
+
+// RegisterConsul register resolver of ConsulScheme watching r, which replace the default consul agent,
+// it must be called before dialing, typically in init.
+func RegisterConsul(r registry.Registry, options ...ResolverOption) {
+	resolver.Register(NewConsulBuilder(r, options...))
+}
+
+// NewConsulBuilder create builder of ConsulScheme watching r, it's registered per ClientConn by grpc.WithResolvers.
+func NewConsulBuilder(r registry.Registry, options ...ResolverOption) resolver.Builder {
+	b := &consulBuilder{registry: r, backoff: backoff.DefaultConfig}
+	for _, o := range options {
+		o(b)
+	}
+	return b
+}
 
-func (c *consulResolver) ResolveNow(options resolver.ResolveNowOptions) {
+type consulBuilder struct {
+	registry registry.Registry
+	backoff  backoff.Config
+}
+
+func (b *consulBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &consulResolver{
+		builder:   b,
+		service:   target.Endpoint,
+		cc:        cc,
+		ctx:       ctx,
+		cancel:    cancel,
+		instances: make(map[string]*registry.Service),
+	}
+	r.wg.Add(1)
+	go r.run()
+	return r, nil
+}
+
+func (b *consulBuilder) Scheme() string { return ConsulScheme }
+
+// consulResolver push addresses to ClientConn on every change of watch, and re-watch with backoff on errors.
+type consulResolver struct {
+	builder   *consulBuilder
+	service   string
+	cc        resolver.ClientConn
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	instances map[string]*registry.Service
+}
+
+func (r *consulResolver) run() {
+	defer r.wg.Done()
+	for attempt := 0; ; attempt++ {
+		w, err := r.builder.registry.Watch(r.ctx, r.service)
+		if err == nil {
+			var updated bool
+			updated, err = r.watch(w)
+			w.Shutdown()
+			if updated {
+				attempt = 0
+			}
+		}
+		if r.ctx.Err() != nil {
+			return
+		}
+		r.cc.ReportError(err)
+		timer := time.NewTimer(r.backoff(attempt))
+		select {
+		case <-r.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// watch apply results of w until it fails, updated is true if any result is applied.
+func (r *consulResolver) watch(w registry.Watcher) (updated bool, err error) {
+	for {
+		wr, err := w.Next()
+		if err != nil {
+			return updated, err
+		}
+		if wr.Error != nil {
+			return updated, wr.Error
+		}
+		switch wr.Action {
+		case registry.ActionUpdate:
+			r.instances = make(map[string]*registry.Service, len(wr.Services))
+			fallthrough
+		case registry.ActionAdd:
+			for _, s := range wr.Services {
+				r.instances[s.ServiceID] = s
+			}
+		case registry.ActionRemove:
+			for _, s := range wr.Services {
+				delete(r.instances, s.ServiceID)
+			}
+		}
+		r.cc.UpdateState(resolver.State{Addresses: r.addresses()})
+		updated = true
+	}
+}
+
+// addresses return addresses of instances sorted by ServiceID, with version, zone and weight attributes.
+func (r *consulResolver) addresses() []resolver.Address {
+	ids := make([]string, 0, len(r.instances))
+	for id := range r.instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	addrs := make([]resolver.Address, 0, len(ids))
+	for _, id := range ids {
+		s := r.instances[id]
+		weight, err := strconv.Atoi(s.Metadata[MetadataWeight])
+		if err != nil || weight < 0 {
+			weight = defaultWeight
+		}
+		addrs = append(addrs, resolver.Address{
+			Addr:       s.ServiceAddr,
+			ServerName: r.service,
+			Attributes: attributes.New(
+				attributeKey(MetadataVersion), s.Metadata[MetadataVersion],
+				attributeKey(MetadataZone), s.Metadata[MetadataZone],
+				attributeKey(MetadataWeight), weight,
+			),
+		})
+	}
+	return addrs
+}
+
+func (r *consulResolver) backoff(attempt int) time.Duration {
+	c := r.builder.backoff
+	delay := float64(c.BaseDelay) * math.Pow(c.Multiplier, float64(attempt))
+	if delay > float64(c.MaxDelay) {
+		delay = float64(c.MaxDelay)
+	}
+	delay *= 1 + c.Jitter*(2*rand.Float64()-1)
+	return time.Duration(delay)
+}
+
+// ResolveNow does nothing, as addresses are pushed on every change of watch.
+func (r *consulResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *consulResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+type etcdResolver struct{}
+
+func (c *etcdResolver) ResolveNow(options resolver.ResolveNowOptions) {
 	panic("implement me")
 }
 
-func (c *consulResolver) Close() {
+func (c *etcdResolver) Close() {
 	panic("implement me")
 }
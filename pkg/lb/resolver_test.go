@@ -0,0 +1,109 @@
+package lb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/resolver"
+
+	"github.com/go-board/thor/pkg/registry"
+)
+
+// testClientConn send states and errors of resolver to channels.
+type testClientConn struct {
+	resolver.ClientConn
+	states chan resolver.State
+	errs   chan error
+}
+
+func (cc *testClientConn) UpdateState(s resolver.State) { cc.states <- s }
+
+func (cc *testClientConn) ReportError(err error) { cc.errs <- err }
+
+// nextAddrs wait for the next state and return its addresses keyed by Addr.
+func (cc *testClientConn) nextAddrs(t *testing.T) map[string]resolver.Address {
+	t.Helper()
+	select {
+	case s := <-cc.states:
+		addrs := make(map[string]resolver.Address, len(s.Addresses))
+		for _, addr := range s.Addresses {
+			addrs[addr.Addr] = addr
+		}
+		return addrs
+	case err := <-cc.errs:
+		t.Fatalf("unexpected error %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("no state updated")
+	}
+	return nil
+}
+
+func expectAddrs(t *testing.T, addrs map[string]resolver.Address, want ...string) {
+	t.Helper()
+	if len(addrs) != len(want) {
+		t.Fatalf("addresses = %v, want %v", addrs, want)
+	}
+	for _, addr := range want {
+		if _, ok := addrs[addr]; !ok {
+			t.Fatalf("addresses = %v, want %v", addrs, want)
+		}
+	}
+}
+
+func TestConsulResolver(t *testing.T) {
+	ctx := context.Background()
+	r := registry.NewMemory()
+	a := registry.Service{ServiceName: "user", ServiceID: "a", ServiceAddr: "10.0.0.1:80", Metadata: map[string]string{
+		MetadataVersion: "v2", MetadataZone: "z1", MetadataWeight: "3",
+	}}
+	b := registry.Service{ServiceName: "user", ServiceID: "b", ServiceAddr: "10.0.0.2:80", Metadata: map[string]string{MetadataWeight: "heavy"}}
+	_ = r.Register(ctx, a)
+	_ = r.Register(ctx, b)
+
+	delay := time.Millisecond * 50
+	builder := NewConsulBuilder(r, WatchBackoff(backoff.Config{BaseDelay: delay, Multiplier: 1, MaxDelay: delay}))
+	cc := &testClientConn{states: make(chan resolver.State, 16), errs: make(chan error, 16)}
+	res, err := builder.Build(resolver.Target{Scheme: ConsulScheme, Endpoint: "user"}, cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Close()
+
+	// the first state is the snapshot of ActionUpdate, with attributes from metadata.
+	addrs := cc.nextAddrs(t)
+	expectAddrs(t, addrs, "10.0.0.1:80", "10.0.0.2:80")
+	if got := addrs["10.0.0.1:80"]; Version(got) != "v2" || Zone(got) != "z1" || Weight(got) != 3 {
+		t.Errorf("attributes of a = %s, %s, %d", Version(got), Zone(got), Weight(got))
+	}
+	if got := addrs["10.0.0.2:80"]; Version(got) != "" || Zone(got) != "" || Weight(got) != defaultWeight {
+		t.Errorf("attributes of b = %s, %s, %d, want defaults", Version(got), Zone(got), Weight(got))
+	}
+
+	// ActionAdd and ActionRemove change instances incrementally.
+	c := registry.Service{ServiceName: "user", ServiceID: "c", ServiceAddr: "10.0.0.3:80"}
+	_ = r.Register(ctx, c)
+	expectAddrs(t, cc.nextAddrs(t), "10.0.0.1:80", "10.0.0.2:80", "10.0.0.3:80")
+	_ = r.Deregister(ctx, a)
+	expectAddrs(t, cc.nextAddrs(t), "10.0.0.2:80", "10.0.0.3:80")
+
+	// a failed watch is reported, then re-watched after backoff, the snapshot of ActionUpdate replaces all instances.
+	failure := errors.New("watch broken")
+	r.Fail("user", failure)
+	select {
+	case err := <-cc.errs:
+		if err != failure {
+			t.Fatalf("reported error = %v, want %v", err, failure)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("watch error not reported")
+	}
+	failed := time.Now()
+	_ = r.Deregister(ctx, b)
+	expectAddrs(t, cc.nextAddrs(t), "10.0.0.3:80")
+	if elapsed := time.Since(failed); elapsed < delay*4/5 {
+		t.Errorf("re-watched after %v, want backoff of %v", elapsed, delay)
+	}
+}
@@ -1,6 +1,7 @@
 package lb
 
 import (
+	"context"
 	"math/rand"
 
 	"github.com/go-board/x-go/xhash/ring"
@@ -9,6 +10,14 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
+// ShardKey is the outgoing metadata key, calls with the same value are sent to the same backend by `shard` policy.
+const ShardKey = "x-shard-key"
+
+// WithShardKey set shard key of outgoing calls made with the returned context.
+func WithShardKey(ctx context.Context, key string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ShardKey, key)
+}
+
 func init() {
 	balancer.Register(base.NewBalancerBuilderV2("shard", shardPickerBuilder{}, base.Config{HealthCheck: true}))
 }
@@ -16,7 +25,10 @@ func init() {
 type shardPickerBuilder struct{}
 
 func (shardPickerBuilder) Build(info base.PickerBuildInfo) balancer.V2Picker {
-	picker := &shardPicker{subConns: map[string]balancer.SubConn{}, m: ring.New(50, nil)}
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPickerV2(balancer.ErrNoSubConnAvailable)
+	}
+	picker := &shardPicker{key: ShardKey, subConns: map[string]balancer.SubConn{}, m: ring.New(50, nil)}
 	for sc, scInfo := range info.ReadySCs {
 		picker.m.Add(scInfo.Address.Addr)
 		picker.subConns[scInfo.Address.Addr] = sc
@@ -0,0 +1,27 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FromContext return the call-scoped logger injected by grpc logging interceptor,
+// fallback to global logger, trace id and span id are attached if ctx carries a span.
+func FromContext(ctx context.Context) *zap.Logger {
+	l := ctxzap.Extract(ctx)
+	// ctxzap return a nop logger if ctx doesn't carry one.
+	if !l.Core().Enabled(zapcore.FatalLevel) {
+		l = zap.L()
+	}
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		if sc, ok := span.Context().(jaeger.SpanContext); ok {
+			l = l.With(zap.String("trace_id", sc.TraceID().String()), zap.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return l
+}
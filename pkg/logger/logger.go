@@ -64,3 +64,25 @@ func Initialize(dir string, namespace, serviceName, serviceId string) {
 		zap.ReplaceGlobals(logger)
 	})
 }
+
+// Global return a logger writing to the core of zap.L() at every entry,
+// so that it follows the global logger replaced by Initialize after it's created.
+func Global() *zap.Logger {
+	return zap.New(globalCore{})
+}
+
+type globalCore struct{}
+
+func (globalCore) Enabled(level zapcore.Level) bool { return zap.L().Core().Enabled(level) }
+
+func (globalCore) With(fields []zapcore.Field) zapcore.Core { return zap.L().Core().With(fields) }
+
+func (globalCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return zap.L().Core().Check(entry, checked)
+}
+
+func (globalCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return zap.L().Core().Write(entry, fields)
+}
+
+func (globalCore) Sync() error { return zap.L().Core().Sync() }
@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/go-board/x-go/xos"
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -33,6 +34,8 @@ func Initialize(namespace, serviceName, serviceId string, version string) {
 		)
 		registerer = prometheus.WrapRegistererWithPrefix(fmt.Sprintf("%s_%s_", namespace, serviceName), registerer)
 		registerer.MustRegister(processCollector, goCollector, buildInfoCollector)
+		// grpc_prometheus registers client metrics to the original default registerer in init, so register them again.
+		registerer.MustRegister(grpc_prometheus.DefaultClientMetrics)
 
 		prometheus.DefaultGatherer = gatherer
 		prometheus.DefaultRegisterer = registerer
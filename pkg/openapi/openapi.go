@@ -0,0 +1,237 @@
+// Package openapi hold OpenAPI 3 documents generated by protoc-gen-thor from `google.api.http` annotations,
+// which are registered by init of generated code and merged to serve by web.Server.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Version is the OpenAPI version of documents.
+const Version = "3.0.3"
+
+// ErrorSchema is the name of schema of error body written by web.WriteErr.
+const ErrorSchema = "thor.Error"
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem is operations of a path keyed by lower case http method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Schema is a subset of OpenAPI schema object used by generated documents.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *uint64            `json:"minLength,omitempty"`
+	MaxLength            *uint64            `json:"maxLength,omitempty"`
+	MinItems             *uint64            `json:"minItems,omitempty"`
+	MaxItems             *uint64            `json:"maxItems,omitempty"`
+	MinProperties        *uint64            `json:"minProperties,omitempty"`
+	MaxProperties        *uint64            `json:"maxProperties,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+}
+
+// RefOf return schema referring to component schema named name.
+func RefOf(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// JSONContent return content of application/json with schema.
+func JSONContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// New create an empty document with error schema.
+func New(title string, version string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version},
+		Paths:   make(map[string]PathItem),
+		Components: Components{Schemas: map[string]*Schema{
+			ErrorSchema: {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"code":        {Type: "integer", Format: "int32", Description: "grpc status code."},
+					"status":      {Type: "string", Description: "name of grpc status code."},
+					"msg":         {Type: "string"},
+					"reason":      {Type: "string"},
+					"domain":      {Type: "string"},
+					"metadata":    {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+					"retryable":   {Type: "boolean"},
+					"retry_delay": {Type: "string"},
+				},
+			},
+		}},
+	}
+}
+
+// AddOperation add op of method at path, existing operation is replaced.
+func (d *Document) AddOperation(method string, path string, op *Operation) {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = op
+}
+
+// Merge paths and schemas of other into d, those of other take precedence.
+func (d *Document) Merge(other *Document) {
+	for path, item := range other.Paths {
+		for method, op := range item {
+			d.AddOperation(method, path, op)
+		}
+	}
+	if d.Components.Schemas == nil {
+		d.Components.Schemas = make(map[string]*Schema)
+	}
+	for name, schema := range other.Components.Schemas {
+		d.Components.Schemas[name] = schema
+	}
+}
+
+// AddRoute document route registered to http router, path is in httprouter syntax like `/users/:id`.
+// Operation documented at a suffix of path is moved to path, as routes may be grouped under a prefix,
+// or an operation without schema is added if not documented.
+func (d *Document) AddRoute(method string, path string) {
+	path = RouterPath(path)
+	method = strings.ToLower(method)
+	if op, ok := d.Paths[path][method]; ok && op != nil {
+		return
+	}
+	paths := make([]string, 0, len(d.Paths))
+	for p := range d.Paths {
+		paths = append(paths, p)
+	}
+	// the longest suffix wins.
+	sort.Slice(paths, func(i, j int) bool { return len(paths[i]) > len(paths[j]) })
+	for _, p := range paths {
+		if op, ok := d.Paths[p][method]; ok && strings.HasSuffix(path, p) {
+			delete(d.Paths[p], method)
+			if len(d.Paths[p]) == 0 {
+				delete(d.Paths, p)
+			}
+			d.AddOperation(method, path, op)
+			return
+		}
+	}
+	op := &Operation{
+		Summary:   strings.ToUpper(method) + " " + path,
+		Responses: map[string]*Response{"default": {Description: "response of handler."}},
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:     segment[1 : len(segment)-1],
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+	}
+	d.AddOperation(method, path, op)
+}
+
+// RouterPath convert httprouter path to OpenAPI path, `:x` and `*x` are converted to `{x}`.
+func RouterPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+var (
+	mu        sync.RWMutex
+	documents = make(map[string][]byte)
+)
+
+// Register JSON document of proto file, it's called by generated code.
+func Register(file string, doc []byte) {
+	mu.Lock()
+	defer mu.Unlock()
+	documents[file] = doc
+}
+
+// Merged return a document merged from registered documents in order of file name.
+func Merged(title string, version string) (*Document, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	files := make([]string, 0, len(documents))
+	for file := range documents {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	merged := New(title, version)
+	for _, file := range files {
+		doc := &Document{}
+		if err := json.Unmarshal(documents[file], doc); err != nil {
+			return nil, fmt.Errorf("openapi: invalid document of %s: %w", file, err)
+		}
+		merged.Merge(doc)
+	}
+	return merged, nil
+}
@@ -0,0 +1,113 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// register JSON document of file for the test, and unregister it at cleanup.
+func register(t *testing.T, file string, doc []byte) {
+	Register(file, doc)
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(documents, file)
+	})
+}
+
+func TestMerged(t *testing.T) {
+	docOf := func(id string, schema string) []byte {
+		d := New("", "")
+		d.AddOperation("GET", "/users/{id}", &Operation{OperationID: id})
+		d.Components.Schemas["User"] = &Schema{Type: "object", Description: schema}
+		b, err := json.Marshal(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	// documents are merged in order of file name, so the latter file takes precedence.
+	register(t, "b/user.proto", docOf("GetUserB", "b"))
+	register(t, "a/user.proto", docOf("GetUserA", "a"))
+	register(t, "c/user.proto", []byte(`{"paths": {"/users": {"post": {"operationId": "CreateUser"}}}}`))
+	merged, err := Merged("Test", "1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Info.Title != "Test" || merged.OpenAPI != Version {
+		t.Errorf("info = %+v, openapi = %s", merged.Info, merged.OpenAPI)
+	}
+	if op := merged.Paths["/users/{id}"]["get"]; op == nil || op.OperationID != "GetUserB" {
+		t.Errorf("operation = %+v, want GetUserB", op)
+	}
+	if op := merged.Paths["/users"]["post"]; op == nil || op.OperationID != "CreateUser" {
+		t.Errorf("operation = %+v, want CreateUser", op)
+	}
+	if s := merged.Components.Schemas["User"]; s == nil || s.Description != "b" {
+		t.Errorf("schema = %+v, want schema of b", s)
+	}
+	if merged.Components.Schemas[ErrorSchema] == nil {
+		t.Error("error schema is lost")
+	}
+
+	register(t, "d/invalid.proto", []byte("{"))
+	if _, err := Merged("Test", "1.0"); err == nil {
+		t.Error("merged invalid document, want error")
+	}
+}
+
+func TestAddRoute(t *testing.T) {
+	d := New("", "")
+	d.AddOperation("GET", "/{id}", &Operation{OperationID: "Get"})
+	d.AddOperation("GET", "/users/{id}", &Operation{OperationID: "GetUser"})
+	d.AddOperation("DELETE", "/users/{id}", &Operation{OperationID: "DeleteUser"})
+	d.AddOperation("GET", "/v1/items", &Operation{OperationID: "ListItems"})
+
+	// the operation documented at the longest suffix is moved under the prefix of group.
+	d.AddRoute("GET", "/api/v1/users/:id")
+	if op := d.Paths["/api/v1/users/{id}"]["get"]; op == nil || op.OperationID != "GetUser" {
+		t.Errorf("relocated operation = %+v, want GetUser", op)
+	}
+	if _, ok := d.Paths["/users/{id}"]["get"]; ok {
+		t.Error("operation is kept at the suffix")
+	}
+	if op := d.Paths["/users/{id}"]["delete"]; op == nil {
+		t.Error("operation of other method is moved")
+	}
+	// documented routes are kept, and paths without operations are removed.
+	d.AddRoute("GET", "/v1/items")
+	d.AddRoute("DELETE", "/api/users/:id")
+	if op := d.Paths["/v1/items"]["get"]; op == nil || op.OperationID != "ListItems" {
+		t.Errorf("operation = %+v, want ListItems", op)
+	}
+	if _, ok := d.Paths["/users/{id}"]; ok {
+		t.Error("empty path is kept")
+	}
+
+	// routes not documented are added with path params.
+	d.AddRoute("POST", "/files/:bucket/*path")
+	op := d.Paths["/files/{bucket}/{path}"]["post"]
+	if op == nil || op.Summary != "POST /files/{bucket}/{path}" || len(op.Parameters) != 2 {
+		t.Fatalf("operation = %+v, want operation of params bucket and path", op)
+	}
+	for i, name := range []string{"bucket", "path"} {
+		if p := op.Parameters[i]; p.Name != name || p.In != "path" || !p.Required {
+			t.Errorf("param %d = %+v, want required path param %s", i, p, name)
+		}
+	}
+}
+
+func TestRouterPath(t *testing.T) {
+	for path, want := range map[string]string{
+		"/users":             "/users",
+		"/users/:id":         "/users/{id}",
+		"/users/:id/items":   "/users/{id}/items",
+		"/files/*path":       "/files/{path}",
+		"/a:b/c*d":           "/a:b/c*d",
+		"/:kind/items/*name": "/{kind}/items/{name}",
+	} {
+		if got := RouterPath(path); got != want {
+			t.Errorf("RouterPath(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package openapi
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// page is a self-contained docs page rendering the document fetched from spec url, no external assets are loaded.
+var page = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0 auto; max-width: 1080px; padding: 16px; color: #222; }
+h2 { border-bottom: 1px solid #ddd; padding-bottom: 4px; }
+details { border: 1px solid #ddd; border-radius: 4px; margin: 8px 0; }
+summary { cursor: pointer; padding: 8px; font-family: monospace; font-size: 14px; }
+.method { display: inline-block; width: 64px; font-weight: bold; text-transform: uppercase; }
+.get { color: #1a7f37; } .post { color: #0969da; } .put, .patch { color: #9a6700; } .delete { color: #cf222e; }
+.body { padding: 0 16px 8px; }
+table { border-collapse: collapse; width: 100%; font-size: 13px; }
+td, th { border: 1px solid #eee; padding: 4px 8px; text-align: left; vertical-align: top; }
+pre { background: #f6f8fa; padding: 8px; overflow: auto; font-size: 12px; }
+.muted { color: #777; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="muted">OpenAPI document: <a href="{{.Spec}}">{{.Spec}}</a></p>
+<div id="docs">Loading...</div>
+<script>
+(function () {
+  function el(tag, attrs, children) {
+    var e = document.createElement(tag);
+    for (var k in attrs || {}) { if (k === "class") { e.className = attrs[k]; } else { e.setAttribute(k, attrs[k]); } }
+    (children || []).forEach(function (c) { e.appendChild(typeof c === "string" ? document.createTextNode(c) : c); });
+    return e;
+  }
+  function resolve(doc, schema, depth) {
+    if (!schema) { return {}; }
+    if (schema.$ref) {
+      var name = schema.$ref.replace("#/components/schemas/", "");
+      if (depth > 4) { return {$ref: name}; }
+      return resolve(doc, (doc.components.schemas || {})[name], depth + 1);
+    }
+    var out = {};
+    for (var k in schema) { out[k] = schema[k]; }
+    if (schema.properties) {
+      out.properties = {};
+      for (var p in schema.properties) { out.properties[p] = resolve(doc, schema.properties[p], depth + 1); }
+    }
+    if (schema.items) { out.items = resolve(doc, schema.items, depth + 1); }
+    if (schema.additionalProperties) { out.additionalProperties = resolve(doc, schema.additionalProperties, depth + 1); }
+    return out;
+  }
+  function schemaBlock(doc, title, content) {
+    if (!content || !content["application/json"]) { return el("div"); }
+    return el("div", {}, [el("h4", {}, [title]), el("pre", {}, [JSON.stringify(resolve(doc, content["application/json"].schema, 0), null, 2)])]);
+  }
+  function operation(doc, path, method, op) {
+    var body = el("div", {class: "body"});
+    if (op.description) { body.appendChild(el("p", {}, [op.description])); }
+    if (op.parameters && op.parameters.length) {
+      var rows = [el("tr", {}, [el("th", {}, ["name"]), el("th", {}, ["in"]), el("th", {}, ["schema"]), el("th", {}, ["description"])])];
+      op.parameters.forEach(function (p) {
+        rows.push(el("tr", {}, [el("td", {}, [p.name + (p.required ? " *" : "")]), el("td", {}, [p.in]),
+          el("td", {}, [JSON.stringify(resolve(doc, p.schema, 0))]), el("td", {}, [p.description || ""])]));
+      });
+      body.appendChild(el("h4", {}, ["Parameters"]));
+      body.appendChild(el("table", {}, rows));
+    }
+    if (op.requestBody) { body.appendChild(schemaBlock(doc, "Request body", op.requestBody.content)); }
+    for (var code in op.responses || {}) {
+      var r = op.responses[code];
+      body.appendChild(r.content ? schemaBlock(doc, "Response " + code, r.content) : el("h4", {}, ["Response " + code + ": " + r.description]));
+    }
+    var summary = el("summary", {}, [el("span", {class: "method " + method}, [method]), path + " ", el("span", {class: "muted"}, [op.summary || ""])]);
+    return el("details", {}, [summary, body]);
+  }
+  fetch({{.Spec}}).then(function (r) { return r.json(); }).then(function (doc) {
+    var groups = {}, root = document.getElementById("docs");
+    Object.keys(doc.paths || {}).sort().forEach(function (path) {
+      Object.keys(doc.paths[path]).forEach(function (method) {
+        var op = doc.paths[path][method], tag = (op.tags && op.tags[0]) || "default";
+        (groups[tag] = groups[tag] || []).push(operation(doc, path, method, op));
+      });
+    });
+    root.textContent = "";
+    Object.keys(groups).sort().forEach(function (tag) {
+      root.appendChild(el("h2", {}, [tag]));
+      groups[tag].forEach(function (e) { root.appendChild(e); });
+    });
+  }).catch(function (e) { document.getElementById("docs").textContent = "Failed to load document: " + e; });
+})();
+</script>
+</body>
+</html>
+`))
+
+// Page return the docs page titled title, which renders document fetched from spec.
+func Page(title string, spec string) []byte {
+	var buf bytes.Buffer
+	_ = page.Execute(&buf, struct{ Title, Spec string }{Title: title, Spec: spec})
+	return buf.Bytes()
+}
@@ -0,0 +1,237 @@
+package pbjson
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var null = []byte("null")
+
+// UnmarshalOptions configure Unmarshal.
+type UnmarshalOptions struct {
+	// DiscardUnknown ignore unknown fields instead of failing.
+	DiscardUnknown bool
+	// Merge merge JSON into m instead of resetting m first.
+	Merge bool
+}
+
+// Unmarshal JSON b into m with default options, fields are matched by JSON name or proto name,
+// fields missing in JSON are set to `default_value` option.
+func Unmarshal(b []byte, m proto.Message) error {
+	return UnmarshalOptions{}.Unmarshal(b, m)
+}
+
+// Unmarshal JSON b into m, fields are matched by JSON name or proto name, m is reset first unless Merge.
+func (o UnmarshalOptions) Unmarshal(b []byte, m proto.Message) error {
+	if !o.Merge {
+		m.Reset()
+	}
+	return o.unmarshalMessage(b, proto.MessageReflect(m))
+}
+
+func (o UnmarshalOptions) unmarshalMessage(b []byte, m protoreflect.Message) error {
+	md := m.Descriptor()
+	if isWellKnown(md) {
+		return protojson.UnmarshalOptions{DiscardUnknown: o.DiscardUnknown}.Unmarshal(b, m.Interface())
+	}
+	if bytes.Equal(bytes.TrimSpace(b), null) {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return fmt.Errorf("pbjson: %s: %w", md.FullName(), err)
+	}
+	info := messageInfoOf(md)
+	seen := make(map[protoreflect.FieldNumber]bool, len(fields))
+	for name, raw := range fields {
+		fd := info.fields[name]
+		if fd == nil {
+			if o.DiscardUnknown {
+				continue
+			}
+			return fmt.Errorf("pbjson: unknown field %q of %s", name, md.FullName())
+		}
+		if bytes.Equal(bytes.TrimSpace(raw), null) && !isValue(fd) {
+			continue
+		}
+		seen[fd.Number()] = true
+		if err := o.unmarshalField(raw, m, fd); err != nil {
+			return err
+		}
+	}
+	// fields missing or null in JSON are set to default_value.
+	for number, v := range info.defaults {
+		if fd := md.Fields().ByNumber(number); !seen[number] && !m.Has(fd) {
+			m.Set(fd, v)
+		}
+	}
+	return nil
+}
+
+func (o UnmarshalOptions) unmarshalField(b []byte, m protoreflect.Message, fd protoreflect.FieldDescriptor) error {
+	switch {
+	case fd.IsList():
+		var items []json.RawMessage
+		if err := json.Unmarshal(b, &items); err != nil {
+			return fmt.Errorf("pbjson: %s: %w", fd.FullName(), err)
+		}
+		list := m.Mutable(fd).List()
+		for _, item := range items {
+			v, err := o.unmarshalValue(item, fd, list.NewElement)
+			if err != nil {
+				return err
+			}
+			list.Append(v)
+		}
+		return nil
+	case fd.IsMap():
+		var items map[string]json.RawMessage
+		if err := json.Unmarshal(b, &items); err != nil {
+			return fmt.Errorf("pbjson: %s: %w", fd.FullName(), err)
+		}
+		mp := m.Mutable(fd).Map()
+		for key, item := range items {
+			k, err := ParseValue(fd.MapKey(), key)
+			if err != nil {
+				return err
+			}
+			v, err := o.unmarshalValue(item, fd.MapValue(), mp.NewValue)
+			if err != nil {
+				return err
+			}
+			mp.Set(k.MapKey(), v)
+		}
+		return nil
+	case fd.Message() != nil:
+		return o.unmarshalMessage(b, m.Mutable(fd).Message())
+	default:
+		v, err := o.unmarshalValue(b, fd, nil)
+		if err != nil {
+			return err
+		}
+		m.Set(fd, v)
+		return nil
+	}
+}
+
+// unmarshalValue decode a singular value, newValue create the message to decode into if fd is a message.
+func (o UnmarshalOptions) unmarshalValue(b []byte, fd protoreflect.FieldDescriptor, newValue func() protoreflect.Value) (protoreflect.Value, error) {
+	if fd.Message() != nil {
+		v := newValue()
+		return v, o.unmarshalMessage(b, v.Message())
+	}
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return protoreflect.Value{}, fmt.Errorf("pbjson: %s: %w", fd.FullName(), err)
+		}
+		if fd.Kind() == protoreflect.BoolKind {
+			return protoreflect.Value{}, fmt.Errorf("pbjson: %s: invalid bool %s", fd.FullName(), b)
+		}
+		return ParseValue(fd, s)
+	}
+	switch fd.Kind() {
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return protoreflect.Value{}, fmt.Errorf("pbjson: %s: invalid string %s", fd.FullName(), b)
+	case protoreflect.EnumKind:
+		if bytes.Equal(b, null) {
+			return protoreflect.ValueOfEnum(0), nil
+		}
+	}
+	return ParseValue(fd, string(b))
+}
+
+// ParseValue parse text s as a singular scalar value of fd, like a query or path param,
+// enums are parsed by name or number, bytes are parsed as standard or URL base64.
+func ParseValue(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return protoreflect.Value{}, parseError(fd, s)
+		}
+		return protoreflect.ValueOfBool(b), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, parseError(fd, s)
+		}
+		return protoreflect.ValueOfInt32(int32(n)), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return protoreflect.Value{}, parseError(fd, s)
+		}
+		return protoreflect.ValueOfInt64(n), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, parseError(fd, s)
+		}
+		return protoreflect.ValueOfUint32(uint32(n)), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return protoreflect.Value{}, parseError(fd, s)
+		}
+		return protoreflect.ValueOfUint64(n), nil
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		f, err := parseFloat(s)
+		if err != nil {
+			return protoreflect.Value{}, parseError(fd, s)
+		}
+		if fd.Kind() == protoreflect.FloatKind {
+			return protoreflect.ValueOfFloat32(float32(f)), nil
+		}
+		return protoreflect.ValueOfFloat64(f), nil
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+			if b, err := enc.DecodeString(s); err == nil {
+				return protoreflect.ValueOfBytes(b), nil
+			}
+		}
+		return protoreflect.Value{}, parseError(fd, s)
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, parseError(fd, s)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("pbjson: %s isn't a scalar field", fd.FullName())
+}
+
+func parseFloat(s string) (float64, error) {
+	switch s {
+	case "NaN":
+		return math.NaN(), nil
+	case "Infinity":
+		return math.Inf(1), nil
+	case "-Infinity":
+		return math.Inf(-1), nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func parseError(fd protoreflect.FieldDescriptor, s string) error {
+	return fmt.Errorf("pbjson: invalid value %q of %s", s, fd.FullName())
+}
+
+// isValue report whether fd is google.protobuf.Value, which decodes null as NullValue.
+func isValue(fd protoreflect.FieldDescriptor) bool {
+	return fd.Message() != nil && fd.Message().FullName() == "google.protobuf.Value"
+}
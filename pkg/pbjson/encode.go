@@ -0,0 +1,192 @@
+package pbjson
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MarshalOptions configure Marshal.
+type MarshalOptions struct {
+	// EmitUnpopulated emit fields not set with zero values, fields of oneof are emitted only if set.
+	EmitUnpopulated bool
+	// Indent indent output with it if not empty.
+	Indent string
+}
+
+// Marshal m to JSON with default options.
+func Marshal(m proto.Message) ([]byte, error) {
+	return MarshalOptions{}.Marshal(m)
+}
+
+// Marshal m to JSON.
+func (o MarshalOptions) Marshal(m proto.Message) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := o.marshalMessage(&buf, proto.MessageReflect(m)); err != nil {
+		return nil, err
+	}
+	if o.Indent == "" {
+		return buf.Bytes(), nil
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", o.Indent); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// MarshalField marshal field of m named by proto name to JSON.
+func (o MarshalOptions) MarshalField(m proto.Message, name string) ([]byte, error) {
+	msg := proto.MessageReflect(m)
+	fd := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+	if fd == nil {
+		return nil, fmt.Errorf("pbjson: unknown field %q of %s", name, msg.Descriptor().FullName())
+	}
+	var buf bytes.Buffer
+	if err := o.marshalField(&buf, msg, fd); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (o MarshalOptions) marshalMessage(buf *bytes.Buffer, m protoreflect.Message) error {
+	md := m.Descriptor()
+	if isWellKnown(md) {
+		b, err := protojson.Marshal(m.Interface())
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		return nil
+	}
+	buf.WriteByte('{')
+	fields := md.Fields()
+	first := true
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if !m.Has(fd) && (!o.EmitUnpopulated || fd.ContainingOneof() != nil) {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		writeString(buf, FieldName(fd))
+		buf.WriteByte(':')
+		if err := o.marshalField(buf, m, fd); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func (o MarshalOptions) marshalField(buf *bytes.Buffer, m protoreflect.Message, fd protoreflect.FieldDescriptor) error {
+	switch {
+	case fd.IsList():
+		list := m.Get(fd).List()
+		buf.WriteByte('[')
+		for i := 0; i < list.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := o.marshalValue(buf, fd, list.Get(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case fd.IsMap():
+		return o.marshalMap(buf, fd, m.Get(fd).Map())
+	case fd.Message() != nil && !m.Has(fd):
+		buf.WriteString("null")
+		return nil
+	default:
+		return o.marshalValue(buf, fd, m.Get(fd))
+	}
+}
+
+func (o MarshalOptions) marshalMap(buf *bytes.Buffer, fd protoreflect.FieldDescriptor, m protoreflect.Map) error {
+	keys := make([]protoreflect.MapKey, 0, m.Len())
+	m.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	// keys are sorted so that output is stable.
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeString(buf, k.String())
+		buf.WriteByte(':')
+		if err := o.marshalValue(buf, fd.MapValue(), m.Get(k)); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func (o MarshalOptions) marshalValue(buf *bytes.Buffer, fd protoreflect.FieldDescriptor, v protoreflect.Value) error {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		buf.WriteString(strconv.FormatBool(v.Bool()))
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		writeString(buf, strconv.FormatInt(v.Int(), 10))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		writeString(buf, strconv.FormatUint(v.Uint(), 10))
+	case protoreflect.FloatKind:
+		writeFloat(buf, v.Float(), 32)
+	case protoreflect.DoubleKind:
+		writeFloat(buf, v.Float(), 64)
+	case protoreflect.StringKind:
+		writeString(buf, v.String())
+	case protoreflect.BytesKind:
+		writeString(buf, base64.StdEncoding.EncodeToString(v.Bytes()))
+	case protoreflect.EnumKind:
+		if fd.Enum().FullName() == "google.protobuf.NullValue" {
+			buf.WriteString("null")
+			return nil
+		}
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			writeString(buf, string(ev.Name()))
+		} else {
+			buf.WriteString(strconv.FormatInt(int64(v.Enum()), 10))
+		}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return o.marshalMessage(buf, v.Message())
+	}
+	return nil
+}
+
+func writeFloat(buf *bytes.Buffer, f float64, bitSize int) {
+	switch {
+	case math.IsNaN(f):
+		writeString(buf, "NaN")
+	case math.IsInf(f, 1):
+		writeString(buf, "Infinity")
+	case math.IsInf(f, -1):
+		writeString(buf, "-Infinity")
+	default:
+		buf.WriteString(strconv.FormatFloat(f, 'g', -1, bitSize))
+	}
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	buf.Write(b)
+}
@@ -0,0 +1,120 @@
+// Package pbjson marshal proto messages to JSON with field names controlled by `json_tag` and `json_tag_mode` options,
+// and `default_value` option set to fields missing in JSON when unmarshaling, encoding of values follows protojson:
+// 64-bit integers are strings, enums are names, bytes are base64, well-known types use their JSON mapping.
+package pbjson
+
+import (
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	thor_proto "github.com/go-board/thor/proto"
+)
+
+// messageInfo is names and default values of fields of a message type.
+type messageInfo struct {
+	names    map[protoreflect.FieldNumber]string
+	fields   map[string]protoreflect.FieldDescriptor
+	defaults map[protoreflect.FieldNumber]protoreflect.Value
+}
+
+var infos sync.Map // protoreflect.FullName -> *messageInfo
+
+// FieldName return JSON name of field, it's `json_tag` if set, or proto name if `json_tag_mode` is SnakeCase,
+// or lowerCamelCase name otherwise. `json_tag_mode` is resolved by field, message, then file option.
+func FieldName(fd protoreflect.FieldDescriptor) string {
+	return messageInfoOf(fd.ContainingMessage()).names[fd.Number()]
+}
+
+// FieldByName return field named by JSON name or proto name, nil if not found.
+func FieldByName(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	return messageInfoOf(md).fields[name]
+}
+
+func messageInfoOf(md protoreflect.MessageDescriptor) *messageInfo {
+	if n, ok := infos.Load(md.FullName()); ok {
+		return n.(*messageInfo)
+	}
+	n := &messageInfo{
+		names:    make(map[protoreflect.FieldNumber]string),
+		fields:   make(map[string]protoreflect.FieldDescriptor),
+		defaults: make(map[protoreflect.FieldNumber]protoreflect.Value),
+	}
+	mode := messageMode(md)
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		name := fieldName(fd, mode)
+		n.names[fd.Number()] = name
+		n.fields[name] = fd
+		if v, ok := defaultValue(fd); ok {
+			n.defaults[fd.Number()] = v
+		}
+	}
+	// proto names are accepted unless taken by JSON names.
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if _, ok := n.fields[string(fd.Name())]; !ok {
+			n.fields[string(fd.Name())] = fd
+		}
+	}
+	v, _ := infos.LoadOrStore(md.FullName(), n)
+	return v.(*messageInfo)
+}
+
+// messageMode return `json_tag_mode` of message option, or file option, or CamelCase.
+func messageMode(md protoreflect.MessageDescriptor) thor_proto.JsonTagMode {
+	if opts, ok := md.Options().(proto.Message); ok {
+		if ext, err := proto.GetExtension(opts, thor_proto.E_MessageJsonTagMode); err == nil {
+			return *ext.(*thor_proto.JsonTagMode)
+		}
+	}
+	if opts, ok := md.ParentFile().Options().(proto.Message); ok {
+		if ext, err := proto.GetExtension(opts, thor_proto.E_FileJsonTagMode); err == nil {
+			return *ext.(*thor_proto.JsonTagMode)
+		}
+	}
+	return thor_proto.JsonTagMode_CamelCase
+}
+
+func fieldName(fd protoreflect.FieldDescriptor, mode thor_proto.JsonTagMode) string {
+	if opts, ok := fd.Options().(proto.Message); ok {
+		if ext, err := proto.GetExtension(opts, thor_proto.E_JsonTag); err == nil && *ext.(*string) != "" {
+			return *ext.(*string)
+		}
+		if ext, err := proto.GetExtension(opts, thor_proto.E_JsonTagMode); err == nil {
+			mode = *ext.(*thor_proto.JsonTagMode)
+		}
+	}
+	if mode == thor_proto.JsonTagMode_SnakeCase {
+		return string(fd.Name())
+	}
+	return fd.JSONName()
+}
+
+// defaultValue return `default_value` option of singular scalar field out of oneof, bytes value is the raw string.
+func defaultValue(fd protoreflect.FieldDescriptor) (protoreflect.Value, bool) {
+	if fd.IsList() || fd.IsMap() || fd.Message() != nil || fd.ContainingOneof() != nil {
+		return protoreflect.Value{}, false
+	}
+	opts, ok := fd.Options().(proto.Message)
+	if !ok {
+		return protoreflect.Value{}, false
+	}
+	ext, err := proto.GetExtension(opts, thor_proto.E_DefaultValue)
+	if err != nil {
+		return protoreflect.Value{}, false
+	}
+	s := *ext.(*string)
+	if fd.Kind() == protoreflect.BytesKind {
+		return protoreflect.ValueOfBytes([]byte(s)), true
+	}
+	v, err := ParseValue(fd, s)
+	return v, err == nil
+}
+
+// isWellKnown report whether md is a well-known type, which is encoded by protojson.
+func isWellKnown(md protoreflect.MessageDescriptor) bool {
+	return md.ParentFile().Package() == "google.protobuf"
+}
@@ -0,0 +1,248 @@
+package pbjson
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	thor_proto "github.com/go-board/thor/proto"
+)
+
+// testFile is built once, since field names and defaults of message types are cached by full name.
+var testFile = newTestFile()
+
+// newTestFile return descriptor of file like:
+//
+//	option (file_json_tag_mode) = SnakeCase;
+//	enum Kind { KIND_UNSPECIFIED = 0; BOOK = 1; }
+//	message Snake {
+//	  string user_name = 1; string display_name = 2 [(json_tag_mode) = CamelCase]; string nick_name = 3 [(json_tag) = "nick"];
+//	}
+//	message Camel {
+//	  option (message_json_tag_mode) = CamelCase;
+//	  string user_name = 1; string home_page = 2 [(json_tag_mode) = SnakeCase];
+//	}
+//	message Values {
+//	  int64 count = 1; uint64 size = 2; Kind kind = 3;
+//	  google.protobuf.Timestamp created_at = 4; google.protobuf.Duration timeout = 5; google.protobuf.Int64Value limit = 6;
+//	  int32 page_size = 7 [(default_value) = "20"]; string title = 8 [(default_value) = "untitled"];
+//	  bool enabled = 9 [(default_value) = "true"];
+//	}
+func newTestFile() protoreflect.FileDescriptor {
+	ext := func(opts proto.Message, xt *proto.ExtensionDesc, v interface{}) proto.Message {
+		if err := proto.SetExtension(opts, xt, v); err != nil {
+			panic(err)
+		}
+		return opts
+	}
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, opts proto.Message) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   typ.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		if opts != nil {
+			f.Options = opts.(*descriptorpb.FieldOptions)
+		}
+		return f
+	}
+	const (
+		str = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		msg = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	snake, camel := thor_proto.JsonTagMode_SnakeCase.Enum(), thor_proto.JsonTagMode_CamelCase.Enum()
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("thor/pbjson/test.proto"),
+		Package:    proto.String("thor.pbjson.test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto", "google/protobuf/duration.proto", "google/protobuf/wrappers.proto"},
+		Options:    ext(&descriptorpb.FileOptions{}, thor_proto.E_FileJsonTagMode, snake).(*descriptorpb.FileOptions),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Kind"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("KIND_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("BOOK"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Snake"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("user_name", 1, str, "", nil),
+				field("display_name", 2, str, "", ext(&descriptorpb.FieldOptions{}, thor_proto.E_JsonTagMode, camel)),
+				field("nick_name", 3, str, "", ext(&descriptorpb.FieldOptions{}, thor_proto.E_JsonTag, proto.String("nick"))),
+			}},
+			{
+				Name:    proto.String("Camel"),
+				Options: ext(&descriptorpb.MessageOptions{}, thor_proto.E_MessageJsonTagMode, camel).(*descriptorpb.MessageOptions),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("user_name", 1, str, "", nil),
+					field("home_page", 2, str, "", ext(&descriptorpb.FieldOptions{}, thor_proto.E_JsonTagMode, snake)),
+				},
+			},
+			{Name: proto.String("Values"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("count", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, "", nil),
+				field("size", 2, descriptorpb.FieldDescriptorProto_TYPE_UINT64, "", nil),
+				field("kind", 3, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".thor.pbjson.test.Kind", nil),
+				field("created_at", 4, msg, ".google.protobuf.Timestamp", nil),
+				field("timeout", 5, msg, ".google.protobuf.Duration", nil),
+				field("limit", 6, msg, ".google.protobuf.Int64Value", nil),
+				field("page_size", 7, descriptorpb.FieldDescriptorProto_TYPE_INT32, "", ext(&descriptorpb.FieldOptions{}, thor_proto.E_DefaultValue, proto.String("20"))),
+				field("title", 8, str, "", ext(&descriptorpb.FieldOptions{}, thor_proto.E_DefaultValue, proto.String("untitled"))),
+				field("enabled", 9, descriptorpb.FieldDescriptorProto_TYPE_BOOL, "", ext(&descriptorpb.FieldOptions{}, thor_proto.E_DefaultValue, proto.String("true"))),
+			}},
+		},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	return fd
+}
+
+// newMessage return an empty message of type named name in testFile, with fields set to values by proto name.
+func newMessage(name string, values map[string]interface{}) protoreflect.Message {
+	m := dynamicpb.NewMessage(testFile.Messages().ByName(protoreflect.Name(name)))
+	for k, v := range values {
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(k))
+		if pm, ok := v.(proto.Message); ok {
+			v = proto.MessageReflect(pm)
+		}
+		m.Set(fd, protoreflect.ValueOf(v))
+	}
+	return m
+}
+
+// roundTrip marshal m, decode the JSON object, and unmarshal the JSON into a message of the same type,
+// which must be equal to m.
+func roundTrip(t *testing.T, m protoreflect.Message) map[string]interface{} {
+	t.Helper()
+	b, err := Marshal(proto.MessageV1(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		t.Fatalf("marshaled %s: %v", b, err)
+	}
+	got := m.New()
+	if err := Unmarshal(b, proto.MessageV1(got)); err != nil {
+		t.Fatalf("unmarshal %s: %v", b, err)
+	}
+	if !proto.Equal(proto.MessageV1(got), proto.MessageV1(m)) {
+		t.Errorf("round trip of %s = %v, want %v", b, got, m)
+	}
+	return obj
+}
+
+func TestJsonTag(t *testing.T) {
+	for _, c := range []struct {
+		m    protoreflect.Message
+		want []string
+	}{
+		// file mode is SnakeCase, overridden by field mode, and json_tag takes precedence over modes.
+		{m: newMessage("Snake", map[string]interface{}{"user_name": "u", "display_name": "d", "nick_name": "n"}), want: []string{"user_name", "displayName", "nick"}},
+		// message mode takes precedence over file mode.
+		{m: newMessage("Camel", map[string]interface{}{"user_name": "u", "home_page": "h"}), want: []string{"userName", "home_page"}},
+	} {
+		obj := roundTrip(t, c.m)
+		if len(obj) != len(c.want) {
+			t.Errorf("%s: JSON = %v, want names %v", c.m.Descriptor().Name(), obj, c.want)
+		}
+		for _, name := range c.want {
+			if _, ok := obj[name]; !ok {
+				t.Errorf("%s: JSON = %v, want name %s", c.m.Descriptor().Name(), obj, name)
+			}
+		}
+	}
+	// proto names are accepted too.
+	m := newMessage("Snake", nil)
+	if err := Unmarshal([]byte(`{"nick_name": "n", "display_name": "d"}`), proto.MessageV1(m)); err != nil {
+		t.Fatal(err)
+	}
+	if want := newMessage("Snake", map[string]interface{}{"display_name": "d", "nick_name": "n"}); !proto.Equal(proto.MessageV1(m), proto.MessageV1(want)) {
+		t.Errorf("unmarshal of proto names = %v, want %v", m, want)
+	}
+}
+
+func TestDefaultValue(t *testing.T) {
+	m := newMessage("Values", map[string]interface{}{"count": int64(1)})
+	// m is reset first, so count isn't kept.
+	if err := Unmarshal([]byte(`{"title": null}`), proto.MessageV1(m)); err != nil {
+		t.Fatal(err)
+	}
+	want := newMessage("Values", map[string]interface{}{"page_size": int32(20), "title": "untitled", "enabled": true})
+	if !proto.Equal(proto.MessageV1(m), proto.MessageV1(want)) {
+		t.Errorf("missing fields = %v, want %v", m, want)
+	}
+	// explicit zero values are kept.
+	if err := Unmarshal([]byte(`{"page_size": 0, "title": "", "enabled": false}`), proto.MessageV1(m)); err != nil {
+		t.Fatal(err)
+	}
+	if want := newMessage("Values", nil); !proto.Equal(proto.MessageV1(m), proto.MessageV1(want)) {
+		t.Errorf("zero values = %v, want %v", m, want)
+	}
+	// fields set before are kept by Merge.
+	m = newMessage("Values", map[string]interface{}{"title": "kept"})
+	if err := (UnmarshalOptions{Merge: true}).Unmarshal([]byte(`{}`), proto.MessageV1(m)); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Get(m.Descriptor().Fields().ByName("title")).String(); got != "kept" {
+		t.Errorf("title merged = %q, want kept", got)
+	}
+}
+
+func TestValues(t *testing.T) {
+	m := newMessage("Values", map[string]interface{}{
+		"count":      int64(1) << 62,
+		"size":       uint64(1) << 63,
+		"kind":       protoreflect.EnumNumber(1),
+		"created_at": &timestamppb.Timestamp{Seconds: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC).Unix()},
+		"timeout":    &durationpb.Duration{Seconds: 1, Nanos: int32(time.Millisecond * 500)},
+		"limit":      &wrapperspb.Int64Value{Value: 5},
+		"page_size":  int32(10),
+		"title":      "t",
+		"enabled":    true,
+	})
+	obj := roundTrip(t, m)
+	for name, want := range map[string]interface{}{
+		"count":      "4611686018427387904",
+		"size":       "9223372036854775808",
+		"kind":       "BOOK",
+		"created_at": "2020-01-02T03:04:05Z",
+		"timeout":    "1.500s",
+		"limit":      "5",
+		"page_size":  float64(10),
+		"title":      "t",
+		"enabled":    true,
+	} {
+		if obj[name] != want {
+			t.Errorf("%s = %#v, want %#v", name, obj[name], want)
+		}
+	}
+	// 64-bit integers are accepted as numbers, and enums by number.
+	got := newMessage("Values", nil)
+	if err := Unmarshal([]byte(`{"count": 7, "size": 8, "kind": 1}`), proto.MessageV1(got)); err != nil {
+		t.Fatal(err)
+	}
+	fields := got.Descriptor().Fields()
+	if got.Get(fields.ByName("count")).Int() != 7 || got.Get(fields.ByName("size")).Uint() != 8 || got.Get(fields.ByName("kind")).Enum() != 1 {
+		t.Errorf("unmarshal of numbers = %v", got)
+	}
+	for _, b := range []string{`{"kind": "NOVEL"}`, `{"count": "1.5"}`, `{"created_at": "yesterday"}`, `{"unknown": 1}`} {
+		if err := Unmarshal([]byte(b), proto.MessageV1(newMessage("Values", nil))); err == nil {
+			t.Errorf("unmarshal of %s succeeded, want error", b)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Package policy hold resilience policies of methods, which are generated by protoc-gen-thor from `policy` method option
+// and registered by init of generated code.
+package policy
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hedge send another attempt if no response is received after Delay, until MaxAttempts.
+type Hedge struct {
+	MaxAttempts int
+	Delay       time.Duration
+}
+
+// Method is the resilience policy of a method, zero fields are not declared.
+type Method struct {
+	Idempotent bool
+	MaxRetries int
+	Timeout    time.Duration
+	Hedge      *Hedge
+}
+
+// Service is method table of a service keyed by method name.
+type Service map[string]Method
+
+var (
+	mu       sync.RWMutex
+	services = make(map[string]Service)
+)
+
+// Register method table of service by full name like `pkg.Service`, it's called by generated code.
+func Register(service string, methods Service) {
+	mu.Lock()
+	defer mu.Unlock()
+	services[service] = methods
+}
+
+// Lookup return policy of full method like `/pkg.Service/Method`.
+func Lookup(fullMethod string) (Method, bool) {
+	i := strings.LastIndex(fullMethod, "/")
+	if i < 1 {
+		return Method{}, false
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	m, ok := services[fullMethod[1:i]][fullMethod[i+1:]]
+	return m, ok
+}
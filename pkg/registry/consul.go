@@ -2,51 +2,113 @@ package registry
 
 import (
 	"context"
-	"errors"
 	"net"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-board/x-go/xnet"
 	"github.com/hashicorp/consul/api"
-	"github.com/hashicorp/consul/api/watch"
-	"github.com/hashicorp/go-hclog"
 )
 
 type consulRegistry struct{ client *api.Client }
 
+// NewConsul create registry backed by consul agent of client, only instances passing health checks are found.
+func NewConsul(client *api.Client) Registry { return &consulRegistry{client: client} }
+
+// consulWatcher run blocking queries of healthy instances, each change is sent as a full snapshot,
+// a query error is sent as the last result, so that the consumer re-watches with its own backoff.
 type consulWatcher struct {
-	plan        *watch.Plan
-	registry    *consulRegistry
-	watchResult chan *WatchResult
+	client  *api.Client
+	name    string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	results chan *WatchResult
+	done    chan struct{}
+	once    sync.Once
 }
 
-func (r *consulRegistry) watch() (*consulWatcher, error) {
-	wp, err := watch.Parse(nil)
-	if err != nil {
-		return nil, err
+func (r *consulRegistry) watch(ctx context.Context, name string) (*consulWatcher, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	w := &consulWatcher{
+		client:  r.client,
+		name:    name,
+		ctx:     ctx,
+		cancel:  cancel,
+		results: make(chan *WatchResult, 1),
+		done:    make(chan struct{}),
 	}
-	wp.Handler = r.watchHandler
-	go func() { err = wp.RunWithClientAndHclog(r.client, hclog.L()) }()
-	return &consulWatcher{
-		plan:        wp,
-		registry:    r,
-		watchResult: make(chan *WatchResult, 1),
-	}, err
+	go w.run()
+	go func() {
+		<-ctx.Done()
+		w.Shutdown()
+	}()
+	return w, nil
 }
 
-func (r *consulRegistry) watchHandler(id uint64, data interface{}) {
-	services, ok := data.([]*api.ServiceEntry)
-	if !ok {
-		return
+func (w *consulWatcher) run() {
+	var index uint64
+	for {
+		entries, meta, err := w.client.Health().Service(w.name, "", true, (&api.QueryOptions{WaitIndex: index}).WithContext(w.ctx))
+		if err != nil {
+			w.send(&WatchResult{Error: err})
+			return
+		}
+		if index > 0 && meta.LastIndex == index {
+			// the blocking query timed out without change.
+			continue
+		}
+		// index going backwards means consul state is reset, start over as suggested by consul.
+		if meta.LastIndex < index {
+			index = 0
+		} else {
+			index = meta.LastIndex
+		}
+		w.send(&WatchResult{Services: consulServices(entries), Action: ActionUpdate})
 	}
-	for _, service := range services {
-		print(service)
+}
+
+// send replace pending result, so that a slow consumer only sees the latest snapshot.
+func (w *consulWatcher) send(wr *WatchResult) {
+	for {
+		select {
+		case <-w.done:
+			return
+		case w.results <- wr:
+			return
+		default:
+		}
+		select {
+		case <-w.results:
+		default:
+		}
 	}
 }
 
+func consulServices(entries []*api.ServiceEntry) []*Service {
+	services := make([]*Service, 0, len(entries))
+	for _, entry := range entries {
+		address := entry.Service.Address
+		if address == "" {
+			address = entry.Node.Address
+		}
+		services = append(services, &Service{
+			Namespace:   entry.Service.Namespace,
+			ServiceName: entry.Service.Service,
+			ServiceID:   entry.Service.ID,
+			ServiceAddr: net.JoinHostPort(address, strconv.Itoa(entry.Service.Port)),
+			Metadata:    entry.Service.Meta,
+		})
+	}
+	return services
+}
+
 func (r *consulRegistry) GetService(ctx context.Context, name string) ([]*Service, error) {
-	return nil, nil
+	entries, _, err := r.client.Health().Service(name, "", true, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return consulServices(entries), nil
 }
 
 func (r *consulRegistry) Register(ctx context.Context, service Service) error {
@@ -78,14 +140,22 @@ func (r *consulRegistry) Deregister(ctx context.Context, service Service) error
 	return r.client.Agent().ServiceDeregister(service.ServiceID)
 }
 
-func (r *consulRegistry) Watch(ctx context.Context, name string) (Watcher, error) { return r.watch() }
+func (r *consulRegistry) Watch(ctx context.Context, name string) (Watcher, error) {
+	return r.watch(ctx, name)
+}
 
-func (c *consulWatcher) Next() (*WatchResult, error) {
-	wr, ok := <-c.watchResult
-	if !ok {
-		return nil, errors.New("err: retrieve watch result from closed channel")
+func (w *consulWatcher) Next() (*WatchResult, error) {
+	select {
+	case wr := <-w.results:
+		return wr, nil
+	case <-w.done:
+		return nil, ErrWatcherClosed
 	}
-	return wr, nil
 }
 
-func (c *consulWatcher) Shutdown() { c.plan.Stop() }
+func (w *consulWatcher) Shutdown() {
+	w.once.Do(func() {
+		close(w.done)
+		w.cancel()
+	})
+}
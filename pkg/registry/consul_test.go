@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+func TestConsulWatchError(t *testing.T) {
+	var queries int64
+	read := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first query returns an instance, the blocking one fails after it's read,
+		// as a pending snapshot is replaced by the error.
+		if atomic.AddInt64(&queries, 1) > 1 {
+			<-read
+			http.Error(w, "agent unavailable", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("X-Consul-Index", "7")
+		_, _ = w.Write([]byte(`[{"Node":{"Address":"10.0.0.1"},"Service":{"ID":"a","Service":"user","Port":80}}]`))
+	}))
+	defer srv.Close()
+	client, err := api.NewClient(&api.Config{Address: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	w, err := NewConsul(client).Watch(ctx, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Shutdown()
+	wr, err := w.Next()
+	if err != nil || wr.Error != nil || wr.Action != ActionUpdate || len(wr.Services) != 1 || wr.Services[0].ServiceAddr != "10.0.0.1:80" {
+		t.Fatalf("first result = %+v, %v, want snapshot of a", wr, err)
+	}
+	close(read)
+	// the error of blocking query ends the watch instead of being retried inside.
+	wr, err = w.Next()
+	if err != nil || wr.Error == nil {
+		t.Fatalf("second result = %+v, %v, want query error", wr, err)
+	}
+	if n := atomic.LoadInt64(&queries); n != 2 {
+		t.Errorf("queries = %d, want 2", n)
+	}
+}
@@ -0,0 +1,149 @@
+package registry
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Memory is an in-process registry for tests and local development, services are found by ServiceName.
+type Memory struct {
+	mu       sync.Mutex
+	services map[string]map[string]*Service
+	watchers map[string]map[*memoryWatcher]struct{}
+}
+
+// NewMemory create an empty in-memory registry.
+func NewMemory() *Memory {
+	return &Memory{
+		services: make(map[string]map[string]*Service),
+		watchers: make(map[string]map[*memoryWatcher]struct{}),
+	}
+}
+
+func (m *Memory) GetService(ctx context.Context, name string) ([]*Service, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snapshot(name), nil
+}
+
+// Register add or replace service by ServiceID, watchers of the service get ActionAdd.
+func (m *Memory) Register(ctx context.Context, service Service) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	instances, ok := m.services[service.ServiceName]
+	if !ok {
+		instances = make(map[string]*Service)
+		m.services[service.ServiceName] = instances
+	}
+	instances[service.ServiceID] = &service
+	m.notify(service.ServiceName, &WatchResult{Services: []*Service{&service}, Action: ActionAdd})
+	return nil
+}
+
+// Deregister remove service by ServiceID, watchers of the service get ActionRemove.
+func (m *Memory) Deregister(ctx context.Context, service Service) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.services[service.ServiceName][service.ServiceID]; !ok {
+		return nil
+	}
+	delete(m.services[service.ServiceName], service.ServiceID)
+	m.notify(service.ServiceName, &WatchResult{Services: []*Service{&service}, Action: ActionRemove})
+	return nil
+}
+
+func (m *Memory) Watch(ctx context.Context, name string) (Watcher, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w := &memoryWatcher{
+		registry: m,
+		name:     name,
+		pending:  []*WatchResult{{Services: m.snapshot(name), Action: ActionUpdate}},
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	if m.watchers[name] == nil {
+		m.watchers[name] = make(map[*memoryWatcher]struct{})
+	}
+	m.watchers[name][w] = struct{}{}
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.Shutdown()
+		case <-w.done:
+		}
+	}()
+	return w, nil
+}
+
+// Fail send err to watchers of service, which simulates a broken watch.
+func (m *Memory) Fail(name string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notify(name, &WatchResult{Error: err})
+}
+
+// snapshot return instances of service sorted by ServiceID, m.mu must be held.
+func (m *Memory) snapshot(name string) []*Service {
+	services := make([]*Service, 0, len(m.services[name]))
+	for _, s := range m.services[name] {
+		services = append(services, s)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].ServiceID < services[j].ServiceID })
+	return services
+}
+
+// notify queue wr to watchers of service, m.mu must be held.
+func (m *Memory) notify(name string, wr *WatchResult) {
+	for w := range m.watchers[name] {
+		w.mu.Lock()
+		w.pending = append(w.pending, wr)
+		w.mu.Unlock()
+		select {
+		case w.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+type memoryWatcher struct {
+	registry *Memory
+	name     string
+	mu       sync.Mutex
+	pending  []*WatchResult
+	notify   chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+func (w *memoryWatcher) Next() (*WatchResult, error) {
+	for {
+		select {
+		case <-w.done:
+			return nil, ErrWatcherClosed
+		default:
+		}
+		w.mu.Lock()
+		if len(w.pending) > 0 {
+			wr := w.pending[0]
+			w.pending = w.pending[1:]
+			w.mu.Unlock()
+			return wr, nil
+		}
+		w.mu.Unlock()
+		select {
+		case <-w.notify:
+		case <-w.done:
+		}
+	}
+}
+
+func (w *memoryWatcher) Shutdown() {
+	w.once.Do(func() {
+		close(w.done)
+		w.registry.mu.Lock()
+		delete(w.registry.watchers[w.name], w)
+		w.registry.mu.Unlock()
+	})
+}
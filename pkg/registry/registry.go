@@ -13,6 +13,7 @@ type Service struct {
 	Metadata    map[string]string
 }
 
+// WatchResult is a change of instances of the watched service.
 type WatchResult struct {
 	Services []*Service
 	Action   Action
@@ -22,8 +23,11 @@ type WatchResult struct {
 type Action int
 
 const (
+	// ActionAdd add or replace instances in Services by ServiceID.
 	ActionAdd Action = iota
+	// ActionUpdate replace all instances with Services, which is a full snapshot.
 	ActionUpdate
+	// ActionRemove remove instances in Services by ServiceID.
 	ActionRemove
 )
 
@@ -35,11 +39,18 @@ type Registry interface {
 	Watch(ctx context.Context, name string) (Watcher, error)
 }
 
+// Watcher is changes of a service, the first result is a full snapshot of ActionUpdate.
 type Watcher interface {
+	// Next block until next change, it fails after Shutdown or context of Watch done,
+	// a result with Error means the watch is broken, the watcher should be shutdown and watched again.
 	Next() (*WatchResult, error)
+	// Shutdown stop watching, it's safe to call more than once.
 	Shutdown()
 }
 
+// ErrWatcherClosed is returned by Next of a watcher shutdown.
+var ErrWatcherClosed = errors.New("registry: watcher closed")
+
 type etcdRegistry struct{}
 
 func (r *etcdRegistry) GetService(ctx context.Context, name string) ([]*Service, error) {
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/go-board/thor/pkg/metric"
+)
+
+var deadlineExceededCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "grpc_server_deadline_exceeded_total",
+	Help: "Total number of RPCs exceeded deadline on the server, hop is inbound if rejected before handled.",
+}, []string{"grpc_method", "hop"})
+
+// DeadlineOption declares server side deadline enforcement.
+type DeadlineOption struct {
+	// Timeouts is keyed by full method name, DefaultLimitKey applies to each method not listed,
+	// deadline of the call is capped by the timeout.
+	Timeouts map[string]time.Duration `yaml:"timeouts"`
+	// MinBudget reject calls arrived with remaining time less than it.
+	MinBudget time.Duration `yaml:"min_budget"`
+}
+
+type deadline struct {
+	option DeadlineOption
+}
+
+func newDeadline(o DeadlineOption) *deadline {
+	if len(o.Timeouts) == 0 && o.MinBudget <= 0 {
+		return nil
+	}
+	metric.MustRegister(deadlineExceededCounter)
+	return &deadline{option: o}
+}
+
+func (d *deadline) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, cancel, err := d.enforce(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	resp, err := handler(ctx, req)
+	d.observe(ctx, info.FullMethod, err)
+	return resp, err
+}
+
+func (d *deadline) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, cancel, err := d.enforce(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	err = handler(srv, &deadlineServerStream{ServerStream: ss, ctx: ctx})
+	d.observe(ctx, info.FullMethod, err)
+	return err
+}
+
+// enforce reject call without enough budget, and apply the default timeout of method.
+func (d *deadline) enforce(ctx context.Context, method string) (context.Context, context.CancelFunc, error) {
+	if dl, ok := ctx.Deadline(); ok && d.option.MinBudget > 0 && time.Until(dl) < d.option.MinBudget {
+		deadlineExceededCounter.WithLabelValues(method, "inbound").Inc()
+		return nil, nil, status.Errorf(codes.DeadlineExceeded, "remaining budget %s less than %s", time.Until(dl), d.option.MinBudget)
+	}
+	timeout, ok := d.option.Timeouts[method]
+	if !ok {
+		timeout, ok = d.option.Timeouts[DefaultLimitKey]
+	}
+	if !ok || timeout <= 0 {
+		return ctx, func() {}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, nil
+}
+
+func (d *deadline) observe(ctx context.Context, method string, err error) {
+	if status.Code(err) == codes.DeadlineExceeded || ctx.Err() == context.DeadlineExceeded {
+		deadlineExceededCounter.WithLabelValues(method, "handler").Inc()
+	}
+}
+
+type deadlineServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *deadlineServerStream) Context() context.Context { return s.ctx }
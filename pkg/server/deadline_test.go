@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/status"
+)
+
+func TestDeadlineMinBudget(t *testing.T) {
+	_, client := startTestServer(t, Deadline(DeadlineOption{MinBudget: time.Second}))
+	inbound := testutil.ToFloat64(deadlineExceededCounter.WithLabelValues(unaryCallMethod, "inbound"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+	_, err := client.UnaryCall(ctx, &testpb.SimpleRequest{})
+	if status.Code(err) != codes.DeadlineExceeded || ctx.Err() != nil {
+		t.Fatalf("err = %v, want deadline exceeded rejected by server before timeout", err)
+	}
+	if got := testutil.ToFloat64(deadlineExceededCounter.WithLabelValues(unaryCallMethod, "inbound")); got != inbound+1 {
+		t.Errorf("inbound deadline exceeded = %v, want %v", got, inbound+1)
+	}
+	// calls without deadline have unlimited budget.
+	if _, err := client.UnaryCall(context.Background(), &testpb.SimpleRequest{}); err != nil {
+		t.Errorf("err of call without deadline = %v", err)
+	}
+}
+
+func TestDeadlineDefaultTimeout(t *testing.T) {
+	var remaining time.Duration
+	// wait records budget of the handler, and waits the deadline if the call asks for an error.
+	wait := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		dl, ok := ctx.Deadline()
+		if !ok {
+			return nil, status.Error(codes.Internal, "no deadline")
+		}
+		remaining = time.Until(dl)
+		if req.(*testpb.SimpleRequest).GetResponseStatus() != nil {
+			<-ctx.Done()
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		return handler(ctx, req)
+	}
+	_, client := startTestServer(t, UnaryInterceptor(wait), Deadline(DeadlineOption{
+		Timeouts: map[string]time.Duration{DefaultLimitKey: time.Millisecond * 50},
+	}))
+	handled := testutil.ToFloat64(deadlineExceededCounter.WithLabelValues(unaryCallMethod, "handler"))
+
+	if _, err := client.UnaryCall(context.Background(), &testpb.SimpleRequest{}); err != nil {
+		t.Fatalf("err of call without deadline = %v", err)
+	}
+	if remaining <= 0 || remaining > time.Millisecond*50 {
+		t.Errorf("budget of call without deadline = %s, want default timeout", remaining)
+	}
+	// the default timeout caps longer deadline of the call.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := client.UnaryCall(ctx, &testpb.SimpleRequest{}); err != nil {
+		t.Fatalf("err of call with long deadline = %v", err)
+	}
+	if remaining > time.Millisecond*50 {
+		t.Errorf("budget of call with long deadline = %s, want capped by default timeout", remaining)
+	}
+
+	_, err := client.UnaryCall(ctx, &testpb.SimpleRequest{ResponseStatus: &testpb.EchoStatus{}})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("err of slow call = %v, want deadline exceeded", err)
+	}
+	if got := testutil.ToFloat64(deadlineExceededCounter.WithLabelValues(unaryCallMethod, "handler")); got != handled+1 {
+		t.Errorf("handler deadline exceeded = %v, want %v", got, handled+1)
+	}
+}
@@ -2,34 +2,163 @@ package server
 
 import (
 	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/stats"
-	"google.golang.org/grpc/tap"
+
+	"github.com/go-board/thor/pkg/metric"
+)
+
+var (
+	activeConnGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "grpc_server_active_connections",
+		Help: "Number of active connections per peer, series of a peer is deleted when its last connection closed.",
+	}, []string{"peer"})
+	payloadBytesHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_payload_bytes",
+		Help:    "Histogram of uncompressed message size received and sent by the server.",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 10),
+	}, []string{"grpc_method", "direction"})
+	bytesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_bytes_total",
+		Help: "Total bytes of messages received and sent by the server, wire size is compressed size.",
+	}, []string{"grpc_method", "direction", "size"})
+	phaseHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "grpc_server_phase_seconds",
+		Help: "Histogram of time from RPC begin to header sent, first byte sent and trailer sent.",
+	}, []string{"grpc_method", "phase"})
 )
 
-type statsHandler struct {
+// activeConns count connections per peer shared by all servers, to delete series of peers gone.
+var activeConns = struct {
+	sync.Mutex
+	peers map[string]int
+}{peers: make(map[string]int)}
+
+type connTagKey struct{}
+
+type rpcTagKey struct{}
+
+// rpcStats is the per RPC state shared by stats handler and span interceptors.
+type rpcStats struct {
+	method    string
+	begin     time.Time
+	firstByte sync.Once
+
+	recvBytes     int64
+	recvWireBytes int64
+	sentBytes     int64
+	sentWireBytes int64
 }
 
-func (s *statsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
-	return ctx
+// statsHandler collect connection and RPC telemetry, which is exported as metrics,
+// and attached to span by span interceptors if enabled.
+type statsHandler struct{}
+
+func newStatsHandler() *statsHandler {
+	metric.MustRegister(activeConnGauge, payloadBytesHistogram, bytesCounter, phaseHistogram)
+	return &statsHandler{}
 }
 
-func (s *statsHandler) HandleRPC(ctx context.Context, stats stats.RPCStats) {
+func (s *statsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, rpcTagKey{}, &rpcStats{method: info.FullMethodName, begin: time.Now()})
+}
 
+func (s *statsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
+	r, ok := ctx.Value(rpcTagKey{}).(*rpcStats)
+	if !ok || rs.IsClient() {
+		return
+	}
+	switch rs := rs.(type) {
+	case *stats.Begin:
+		r.begin = rs.BeginTime
+	case *stats.InPayload:
+		atomic.AddInt64(&r.recvBytes, int64(rs.Length))
+		atomic.AddInt64(&r.recvWireBytes, int64(rs.WireLength))
+		payloadBytesHistogram.WithLabelValues(r.method, "in").Observe(float64(rs.Length))
+		bytesCounter.WithLabelValues(r.method, "in", "uncompressed").Add(float64(rs.Length))
+		bytesCounter.WithLabelValues(r.method, "in", "wire").Add(float64(rs.WireLength))
+	case *stats.OutHeader:
+		phaseHistogram.WithLabelValues(r.method, "header").Observe(time.Since(r.begin).Seconds())
+	case *stats.OutPayload:
+		r.firstByte.Do(func() {
+			phaseHistogram.WithLabelValues(r.method, "first_byte").Observe(rs.SentTime.Sub(r.begin).Seconds())
+		})
+		atomic.AddInt64(&r.sentBytes, int64(rs.Length))
+		atomic.AddInt64(&r.sentWireBytes, int64(rs.WireLength))
+		payloadBytesHistogram.WithLabelValues(r.method, "out").Observe(float64(rs.Length))
+		bytesCounter.WithLabelValues(r.method, "out", "uncompressed").Add(float64(rs.Length))
+		bytesCounter.WithLabelValues(r.method, "out", "wire").Add(float64(rs.WireLength))
+	case *stats.OutTrailer:
+		phaseHistogram.WithLabelValues(r.method, "trailer").Observe(time.Since(r.begin).Seconds())
+	}
 }
 
 func (s *statsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
-	return ctx
+	return context.WithValue(ctx, connTagKey{}, peerHost(info.RemoteAddr))
+}
+
+func (s *statsHandler) HandleConn(ctx context.Context, cs stats.ConnStats) {
+	peer, ok := ctx.Value(connTagKey{}).(string)
+	if !ok || cs.IsClient() {
+		return
+	}
+	activeConns.Lock()
+	defer activeConns.Unlock()
+	switch cs.(type) {
+	case *stats.ConnBegin:
+		activeConns.peers[peer]++
+		activeConnGauge.WithLabelValues(peer).Set(float64(activeConns.peers[peer]))
+	case *stats.ConnEnd:
+		if activeConns.peers[peer]--; activeConns.peers[peer] > 0 {
+			activeConnGauge.WithLabelValues(peer).Set(float64(activeConns.peers[peer]))
+			return
+		}
+		delete(activeConns.peers, peer)
+		activeConnGauge.DeleteLabelValues(peer)
+	}
+}
+
+// peerHost strip port of remote address to keep peer label bounded.
+func peerHost(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
 }
 
-func (s *statsHandler) HandleConn(ctx context.Context, stats stats.ConnStats) {
+// statsSpanUnaryInterceptor tag span with payload size of the RPC before span finished,
+// response size of unary RPC is unknown here because it's sent after interceptors returned.
+func statsSpanUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	tagSpanWithStats(ctx)
+	return resp, err
 }
 
-func unknownServiceHandler(srv interface{}, stream grpc.ServerStream) error {
-	return nil
+func statsSpanStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := handler(srv, ss)
+	tagSpanWithStats(ss.Context())
+	return err
 }
 
-func inTapHandle(ctx context.Context, info *tap.Info) (context.Context, error) {
-	return ctx, nil
+func tagSpanWithStats(ctx context.Context) {
+	r, ok := ctx.Value(rpcTagKey{}).(*rpcStats)
+	span := opentracing.SpanFromContext(ctx)
+	if !ok || span == nil {
+		return
+	}
+	span.SetTag("grpc.recv_bytes", atomic.LoadInt64(&r.recvBytes))
+	span.SetTag("grpc.recv_wire_bytes", atomic.LoadInt64(&r.recvWireBytes))
+	span.SetTag("grpc.sent_bytes", atomic.LoadInt64(&r.sentBytes))
+	span.SetTag("grpc.sent_wire_bytes", atomic.LoadInt64(&r.sentWireBytes))
 }
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const unaryCallMethod = "/grpc.testing.TestService/UnaryCall"
+
+func histogramOf(t *testing.T, h *prometheus.HistogramVec, labels ...string) (count uint64, sum float64) {
+	t.Helper()
+	var m dto.Metric
+	if err := h.WithLabelValues(labels...).(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+}
+
+func TestStatsHandler(t *testing.T) {
+	ln := bufconn.Listen(1 << 20)
+	s := New(DisableLogging())
+	testpb.RegisterTestServiceServer(s.GRPCServer(), &testService{})
+	go func() { _ = s.Serve(ln) }()
+	defer s.Close()
+	cc, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return ln.Dial()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := testpb.NewTestServiceClient(cc)
+
+	inCount, inSum := histogramOf(t, payloadBytesHistogram, unaryCallMethod, "in")
+	outCount, _ := histogramOf(t, payloadBytesHistogram, unaryCallMethod, "out")
+	inWire := testutil.ToFloat64(bytesCounter.WithLabelValues(unaryCallMethod, "in", "wire"))
+	inUncompressed := testutil.ToFloat64(bytesCounter.WithLabelValues(unaryCallMethod, "in", "uncompressed"))
+	phases := map[string]uint64{}
+	for _, phase := range []string{"header", "first_byte", "trailer"} {
+		phases[phase], _ = histogramOf(t, phaseHistogram, unaryCallMethod, phase)
+	}
+
+	// a compressible payload, so that wire size is less than uncompressed size.
+	req := &testpb.SimpleRequest{Payload: &testpb.Payload{Body: bytes.Repeat([]byte("a"), 4096)}}
+	if _, err := client.UnaryCall(context.Background(), req, grpc.UseCompressor(gzip.Name)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := testutil.ToFloat64(activeConnGauge.WithLabelValues("bufconn")); got != 1 {
+		t.Errorf("active connections = %v, want 1", got)
+	}
+	count, sum := histogramOf(t, payloadBytesHistogram, unaryCallMethod, "in")
+	if count != inCount+1 || sum-inSum < 4096 {
+		t.Errorf("in payload count = %d, sum = %v, want one payload of at least 4096 bytes", count-inCount, sum-inSum)
+	}
+	waitFor(t, "out payload", func() bool {
+		count, _ := histogramOf(t, payloadBytesHistogram, unaryCallMethod, "out")
+		return count == outCount+1
+	})
+	wire := testutil.ToFloat64(bytesCounter.WithLabelValues(unaryCallMethod, "in", "wire")) - inWire
+	uncompressed := testutil.ToFloat64(bytesCounter.WithLabelValues(unaryCallMethod, "in", "uncompressed")) - inUncompressed
+	if wire <= 0 || wire >= uncompressed {
+		t.Errorf("wire bytes = %v, uncompressed bytes = %v, want compressed wire bytes", wire, uncompressed)
+	}
+	for phase, before := range phases {
+		waitFor(t, phase+" phase", func() bool {
+			count, _ := histogramOf(t, phaseHistogram, unaryCallMethod, phase)
+			return count == before+1
+		})
+	}
+
+	// series of peer is deleted when its last connection closed.
+	_ = cc.Close()
+	waitFor(t, "connection end", func() bool {
+		activeConns.Lock()
+		defer activeConns.Unlock()
+		_, ok := activeConns.peers["bufconn"]
+		return !ok
+	})
+	if n := testutil.CollectAndCount(activeConnGauge); n != 0 {
+		t.Errorf("series of active connections = %d, want 0", n)
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/go-board/thor/pkg/registry"
+)
+
+const (
+	defaultHealthCheckInterval = time.Second * 10
+	healthServiceName          = "grpc.health.v1.Health"
+)
+
+// HealthCheckFunc check a dependency of the server, server is not serving if any check failed.
+type HealthCheckFunc func(ctx context.Context) error
+
+// Pinger is implemented by *sql.DB, which is also the underlying db of gorm.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingCheck check dependency by ping, e.g. database.
+func PingCheck(p Pinger) HealthCheckFunc {
+	return p.PingContext
+}
+
+// RegistryCheck check registry connectivity by looking up the given service.
+func RegistryCheck(r registry.Registry, name string) HealthCheckFunc {
+	return func(ctx context.Context) error {
+		_, err := r.GetService(ctx, name)
+		return err
+	}
+}
+
+// SetServingStatus set serving status of service, empty service means the whole server.
+// It's kept across health checks, and service is reported NOT_SERVING while any dependency check fails.
+func (s *Server) SetServingStatus(service string, status healthpb.HealthCheckResponse_ServingStatus) {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	s.healthStatuses[service] = status
+	s.health.SetServingStatus(service, s.servingStatus(service))
+}
+
+// runHealthChecks update serving status of all services by checking dependencies every interval.
+func (s *Server) runHealthChecks(ctx context.Context) {
+	ticker := time.NewTicker(s.healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		s.checkHealth(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) checkHealth(ctx context.Context) {
+	healthy := true
+	for name, check := range s.healthChecks {
+		checkCtx, cancel := context.WithTimeout(ctx, s.healthCheckInterval)
+		err := check(checkCtx)
+		cancel()
+		if err != nil {
+			zap.L().Warn("health check failed", zap.String("dependency", name), zap.Error(err))
+			healthy = false
+		}
+	}
+	s.setDependenciesHealthy(healthy)
+}
+
+func (s *Server) setDependenciesHealthy(healthy bool) {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	s.dependenciesHealthy = healthy
+	s.health.SetServingStatus("", s.servingStatus(""))
+	for name := range s.srv.GetServiceInfo() {
+		if name != healthServiceName {
+			s.health.SetServingStatus(name, s.servingStatus(name))
+		}
+	}
+	for name := range s.healthStatuses {
+		s.health.SetServingStatus(name, s.servingStatus(name))
+	}
+}
+
+// servingStatus combine dependency health with status set by SetServingStatus, s.healthMu must be held.
+func (s *Server) servingStatus(service string) healthpb.HealthCheckResponse_ServingStatus {
+	if !s.dependenciesHealthy {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	if status, ok := s.healthStatuses[service]; ok {
+		return status
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+func newHealthServer() *health.Server {
+	h := health.NewServer()
+	h.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	return h
+}
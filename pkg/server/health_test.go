@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthStatus(t *testing.T) {
+	var failing int32
+	s, _ := startTestServer(t, HealthCheckInterval(time.Millisecond*10), HealthCheck("db", func(context.Context) error {
+		if atomic.LoadInt32(&failing) == 1 {
+			return errors.New("db down")
+		}
+		return nil
+	}))
+	const service = "grpc.testing.TestService"
+	assertStatus := func(service string, want healthpb.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for {
+			resp, err := s.health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			if err == nil && resp.GetStatus() == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("status of %q = %v (%v), want %v", service, resp.GetStatus(), err, want)
+			}
+			time.Sleep(time.Millisecond * 5)
+		}
+	}
+	assertStatus("", healthpb.HealthCheckResponse_SERVING)
+	assertStatus(service, healthpb.HealthCheckResponse_SERVING)
+
+	// status set by SetServingStatus survives health checks.
+	s.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	time.Sleep(time.Millisecond * 30)
+	assertStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	assertStatus("", healthpb.HealthCheckResponse_SERVING)
+
+	// failed dependency make all services not serving, and status set before is restored after recovered.
+	s.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	atomic.StoreInt32(&failing, 1)
+	assertStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	assertStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	atomic.StoreInt32(&failing, 0)
+	assertStatus("", healthpb.HealthCheckResponse_SERVING)
+	assertStatus(service, healthpb.HealthCheckResponse_SERVING)
+}
@@ -0,0 +1,368 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/tap"
+
+	"github.com/go-board/thor/pkg/metric"
+)
+
+// RetryPushbackKey is the trailer key tells client how long to wait before retry, in milliseconds.
+const RetryPushbackKey = "grpc-retry-pushback-ms"
+
+// DefaultLimitKey is the key of default limit for methods or callers not listed.
+const DefaultLimitKey = "*"
+
+var (
+	rateLimitedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_rate_limited_total",
+		Help: "Total number of RPCs rejected by rate limiter or concurrency limiter.",
+	}, []string{"grpc_method", "limiter"})
+	concurrencyLimitGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "grpc_server_concurrency_limit",
+		Help: "Current limit of adaptive concurrency limiter.",
+	})
+	inflightGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "grpc_server_inflight",
+		Help: "Number of RPCs in flight counted by concurrency limiter.",
+	})
+)
+
+// BucketOption is a token bucket filled with Rate tokens per second, holding at most Burst tokens.
+type BucketOption struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// defaultMaxCallers bound buckets of callers not listed.
+const defaultMaxCallers = 10000
+
+// RateLimitOption declares token bucket limits of methods and callers.
+type RateLimitOption struct {
+	// Methods is keyed by full method name, DefaultLimitKey is a bucket shared by all methods not listed,
+	// so that method names sent by clients don't create buckets.
+	Methods map[string]BucketOption `yaml:"methods"`
+	// CallerKey is the metadata key identify caller, e.g. x-app-id.
+	CallerKey string `yaml:"caller_key"`
+	// Callers is keyed by caller, DefaultLimitKey applies to each caller not listed.
+	Callers map[string]BucketOption `yaml:"callers"`
+	// MaxCallers is the maximum buckets of callers not listed, the least recently used is evicted, default is 10000.
+	MaxCallers int `yaml:"max_callers"`
+}
+
+// ConcurrencyLimitOption configures the AIMD adaptive concurrency limiter,
+// limit increases by one when a call completes with at least half of the limit in use, and multiplies by BackoffRatio when a call dropped,
+// a call is dropped when it's slower than Threshold or failed as overloaded.
+// Only unary calls are the latency signal, streams live arbitrarily long, so they count in flight and drop only by overloaded errors.
+type ConcurrencyLimitOption struct {
+	Enabled      bool          `yaml:"enabled"`
+	InitialLimit int           `yaml:"initial_limit"`
+	MinLimit     int           `yaml:"min_limit"`
+	MaxLimit     int           `yaml:"max_limit"`
+	BackoffRatio float64       `yaml:"backoff_ratio"`
+	Threshold    time.Duration `yaml:"threshold"`
+	// RetryAfter is the pushback returned to client when rejected.
+	RetryAfter time.Duration `yaml:"retry_after"`
+}
+
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(o BucketOption, now time.Time) *tokenBucket {
+	burst := float64(o.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: o.Rate, burst: burst, tokens: burst, last: now}
+}
+
+// available refill the bucket and report whether a token is left, or how long to wait for next token,
+// the token isn't taken, so that a call is charged only if all its buckets allow it.
+func (b *tokenBucket) available(now time.Time) (bool, time.Duration) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		return true, 0
+	}
+	if b.rate <= 0 {
+		return false, time.Second
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+type rateLimiter struct {
+	option RateLimitOption
+	now    func() time.Time
+
+	mu            sync.Mutex
+	methods       map[string]*tokenBucket
+	defaultMethod *tokenBucket
+	callers       map[string]*tokenBucket
+	others        *bucketCache // buckets of callers not listed
+}
+
+// newRateLimiter create buckets of listed methods and callers up front, only buckets of callers not listed are created on demand.
+func newRateLimiter(o RateLimitOption) *rateLimiter {
+	if o.MaxCallers <= 0 {
+		o.MaxCallers = defaultMaxCallers
+	}
+	now := time.Now()
+	l := &rateLimiter{
+		option:  o,
+		now:     time.Now,
+		methods: make(map[string]*tokenBucket, len(o.Methods)),
+		callers: make(map[string]*tokenBucket, len(o.Callers)),
+		others:  newBucketCache(o.MaxCallers),
+	}
+	for method, bo := range o.Methods {
+		if method == DefaultLimitKey {
+			l.defaultMethod = newTokenBucket(bo, now)
+		} else {
+			l.methods[method] = newTokenBucket(bo, now)
+		}
+	}
+	for caller, bo := range o.Callers {
+		if caller != DefaultLimitKey {
+			l.callers[caller] = newTokenBucket(bo, now)
+		}
+	}
+	return l
+}
+
+// allow check method limit then caller limit, tokens are taken only if both allow the call,
+// name of the limiter rejected the call is returned.
+func (l *rateLimiter) allow(ctx context.Context, method string) (bool, string, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	mb, ok := l.methods[method]
+	if !ok {
+		mb = l.defaultMethod
+	}
+	if mb != nil {
+		if ok, wait := mb.available(now); !ok {
+			return false, "method", wait
+		}
+	}
+	var cb *tokenBucket
+	if l.option.CallerKey != "" {
+		caller := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(l.option.CallerKey); len(values) > 0 {
+				caller = values[0]
+			}
+		}
+		cb = l.callerBucket(caller, now)
+	}
+	if cb != nil {
+		if ok, wait := cb.available(now); !ok {
+			return false, "caller", wait
+		}
+		cb.tokens--
+	}
+	if mb != nil {
+		mb.tokens--
+	}
+	return true, "", 0
+}
+
+func (l *rateLimiter) callerBucket(caller string, now time.Time) *tokenBucket {
+	if b, ok := l.callers[caller]; ok {
+		return b
+	}
+	o, ok := l.option.Callers[DefaultLimitKey]
+	if !ok {
+		return nil
+	}
+	if b := l.others.get(caller); b != nil {
+		return b
+	}
+	b := newTokenBucket(o, now)
+	l.others.add(caller, b)
+	return b
+}
+
+// bucketCache is a LRU cache of token buckets, a caller evicted starts with a full bucket again.
+type bucketCache struct {
+	size    int
+	order   *list.List // front is the most recently used
+	entries map[string]*list.Element
+}
+
+type bucketEntry struct {
+	key    string
+	bucket *tokenBucket
+}
+
+func newBucketCache(size int) *bucketCache {
+	return &bucketCache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *bucketCache) get(key string) *tokenBucket {
+	e, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*bucketEntry).bucket
+}
+
+func (c *bucketCache) add(key string, b *tokenBucket) {
+	c.entries[key] = c.order.PushFront(&bucketEntry{key: key, bucket: b})
+	for c.order.Len() > c.size {
+		e := c.order.Back()
+		c.order.Remove(e)
+		delete(c.entries, e.Value.(*bucketEntry).key)
+	}
+}
+
+type concurrencyLimiter struct {
+	option ConcurrencyLimitOption
+
+	mu       sync.Mutex
+	limit    float64
+	inflight int
+}
+
+func newConcurrencyLimiter(o ConcurrencyLimitOption) *concurrencyLimiter {
+	if o.MinLimit <= 0 {
+		o.MinLimit = 1
+	}
+	if o.MaxLimit <= 0 {
+		o.MaxLimit = 1000
+	}
+	if o.InitialLimit <= 0 {
+		o.InitialLimit = 20
+	}
+	if o.BackoffRatio <= 0 || o.BackoffRatio >= 1 {
+		o.BackoffRatio = 0.9
+	}
+	if o.Threshold <= 0 {
+		o.Threshold = time.Second
+	}
+	if o.RetryAfter <= 0 {
+		o.RetryAfter = time.Second
+	}
+	concurrencyLimitGauge.Set(float64(o.InitialLimit))
+	return &concurrencyLimiter{option: o, limit: float64(o.InitialLimit)}
+}
+
+func (l *concurrencyLimiter) acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight >= int(l.limit) {
+		return false
+	}
+	l.inflight++
+	inflightGauge.Set(float64(l.inflight))
+	return true
+}
+
+// release a call, the limit is adjusted by latency of unary calls, and by overloaded errors of all calls.
+func (l *concurrencyLimiter) release(unary bool, latency time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch {
+	case overloaded(err) || unary && latency > l.option.Threshold:
+		l.limit = math.Max(float64(l.option.MinLimit), l.limit*l.option.BackoffRatio)
+	case unary && l.inflight*2 >= int(l.limit):
+		l.limit = math.Min(float64(l.option.MaxLimit), l.limit+1)
+	}
+	l.inflight--
+	inflightGauge.Set(float64(l.inflight))
+	concurrencyLimitGauge.Set(l.limit)
+}
+
+func overloaded(err error) bool {
+	switch status.Code(err) {
+	case codes.DeadlineExceeded, codes.ResourceExhausted, codes.Unavailable:
+		return true
+	}
+	return false
+}
+
+type rejectedKey struct{}
+
+type rejected struct {
+	limiter    string
+	retryAfter time.Duration
+}
+
+// limiter reject calls exceed rate limit in tap handle,
+// and count concurrency in interceptors, because tap has no hook for call completion.
+// The rejection of tap is carried by context and returned by interceptors,
+// since error returned by tap handle is seen by client as codes.Unavailable,
+// so interceptors of limiter are the first ones after the outermost recovery to reject calls cheaply.
+type limiter struct {
+	rate        *rateLimiter
+	concurrency *concurrencyLimiter
+}
+
+func newLimiter(o *Options) *limiter {
+	if len(o.RateLimit.Methods) == 0 && len(o.RateLimit.Callers) == 0 && !o.ConcurrencyLimit.Enabled {
+		return nil
+	}
+	metric.MustRegister(rateLimitedCounter, concurrencyLimitGauge, inflightGauge)
+	l := &limiter{rate: newRateLimiter(o.RateLimit)}
+	if o.ConcurrencyLimit.Enabled {
+		l.concurrency = newConcurrencyLimiter(o.ConcurrencyLimit)
+	}
+	return l
+}
+
+func (l *limiter) TapHandle(ctx context.Context, info *tap.Info) (context.Context, error) {
+	if ok, name, wait := l.rate.allow(ctx, info.FullMethodName); !ok {
+		return context.WithValue(ctx, rejectedKey{}, rejected{limiter: name, retryAfter: wait}), nil
+	}
+	return ctx, nil
+}
+
+func (l *limiter) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ interface{}, err error) {
+	if err := l.check(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	if l.concurrency != nil {
+		start := time.Now()
+		defer func() { l.concurrency.release(true, time.Since(start), err) }()
+	}
+	return handler(ctx, req)
+}
+
+func (l *limiter) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	if err := l.check(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	if l.concurrency != nil {
+		defer func() { l.concurrency.release(false, 0, err) }()
+	}
+	return handler(srv, ss)
+}
+
+// check return rejection made by tap handle, then try to acquire concurrency.
+func (l *limiter) check(ctx context.Context, method string) error {
+	r, ok := ctx.Value(rejectedKey{}).(rejected)
+	if !ok && l.concurrency != nil && !l.concurrency.acquire() {
+		r, ok = rejected{limiter: "concurrency", retryAfter: l.concurrency.option.RetryAfter}, true
+	}
+	if !ok {
+		return nil
+	}
+	rateLimitedCounter.WithLabelValues(method, r.limiter).Inc()
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(RetryPushbackKey, strconv.FormatInt(r.retryAfter.Milliseconds(), 10)))
+	return status.Errorf(codes.ResourceExhausted, "rejected by %s limiter, retry after %s", r.limiter, r.retryAfter)
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimiterBuckets(t *testing.T) {
+	l := newRateLimiter(RateLimitOption{
+		Methods: map[string]BucketOption{
+			"/pkg.Service/Listed": {Rate: 0, Burst: 1},
+			DefaultLimitKey:       {Rate: 0, Burst: 3},
+		},
+		CallerKey:  "x-app-id",
+		Callers:    map[string]BucketOption{DefaultLimitKey: {Rate: 0, Burst: 1}},
+		MaxCallers: 2,
+	})
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+	caller := func(id string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-app-id", id))
+	}
+
+	if ok, _, _ := l.allow(caller("a"), "/pkg.Service/Listed"); !ok {
+		t.Fatal("first call of listed method should be allowed")
+	}
+	if ok, name, _ := l.allow(caller("b"), "/pkg.Service/Listed"); ok || name != "method" {
+		t.Fatalf("listed method should be limited by its bucket, got %v %s", ok, name)
+	}
+	// methods not listed share the default bucket.
+	for i := 0; i < 3; i++ {
+		if ok, _, _ := l.allow(caller(strconv.Itoa(i)), "/pkg.Service/Unlisted"+strconv.Itoa(i)); !ok {
+			t.Fatalf("call %d of unlisted methods should be allowed", i)
+		}
+	}
+	if ok, name, _ := l.allow(caller("c"), "/pkg.Service/Another"); ok || name != "method" {
+		t.Fatalf("unlisted methods should share the default bucket, got %v %s", ok, name)
+	}
+	if len(l.methods) != 1 {
+		t.Fatalf("buckets of methods = %d, want 1", len(l.methods))
+	}
+
+	l.defaultMethod = nil
+	for i := 0; i < 100; i++ {
+		l.allow(caller("caller-"+strconv.Itoa(i)), "/pkg.Service/Unlisted")
+	}
+	if n := len(l.others.entries); n != 2 {
+		t.Fatalf("buckets of callers = %d, want 2", n)
+	}
+	// the most recently used caller is kept.
+	if ok, name, _ := l.allow(caller("caller-99"), "/pkg.Service/Unlisted"); ok || name != "caller" {
+		t.Fatalf("recent caller should keep its bucket, got %v %s", ok, name)
+	}
+}
+
+func TestRateLimiterChargeAllowedOnly(t *testing.T) {
+	l := newRateLimiter(RateLimitOption{
+		Methods:   map[string]BucketOption{DefaultLimitKey: {Rate: 0, Burst: 2}},
+		CallerKey: "x-app-id",
+		Callers:   map[string]BucketOption{DefaultLimitKey: {Rate: 0, Burst: 1}},
+	})
+	caller := func(id string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-app-id", id))
+	}
+	if ok, _, _ := l.allow(caller("a"), "/pkg.Service/Method"); !ok {
+		t.Fatal("first call of caller a should be allowed")
+	}
+	if ok, name, _ := l.allow(caller("a"), "/pkg.Service/Method"); ok || name != "caller" {
+		t.Fatalf("second call of caller a should be limited by caller, got %v %s", ok, name)
+	}
+	// the call rejected by caller bucket doesn't spend token of method bucket.
+	if ok, name, _ := l.allow(caller("b"), "/pkg.Service/Method"); !ok {
+		t.Fatalf("first call of caller b should be allowed, rejected by %s", name)
+	}
+}
+
+func TestConcurrencyLimiterSignal(t *testing.T) {
+	l := newConcurrencyLimiter(ConcurrencyLimitOption{Enabled: true, InitialLimit: 2, Threshold: time.Millisecond})
+	l.acquire()
+	l.release(false, time.Hour, nil)
+	if l.limit != 2 {
+		t.Errorf("limit after long stream = %v, want 2", l.limit)
+	}
+	l.acquire()
+	l.release(false, 0, status.Error(codes.ResourceExhausted, "overloaded"))
+	if l.limit >= 2 {
+		t.Errorf("limit after overloaded stream = %v, want less than 2", l.limit)
+	}
+	limit := l.limit
+	l.acquire()
+	l.release(true, 0, nil)
+	if l.limit != limit+1 {
+		t.Errorf("limit after fast unary call in use = %v, want %v", l.limit, limit+1)
+	}
+	limit = l.limit
+	l.acquire()
+	l.release(true, time.Second, nil)
+	if l.limit >= limit {
+		t.Errorf("limit after slow unary call = %v, want less than %v", l.limit, limit)
+	}
+}
+
+func TestRateLimitedBeforeInterceptors(t *testing.T) {
+	calls := 0
+	count := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		calls++
+		return handler(ctx, req)
+	}
+	_, client := startTestServer(t, PrependUnaryInterceptor(count), RateLimit(RateLimitOption{
+		Methods: map[string]BucketOption{DefaultLimitKey: {Rate: 0, Burst: 1}},
+	}))
+	if _, err := client.UnaryCall(context.Background(), &testpb.SimpleRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := client.UnaryCall(context.Background(), &testpb.SimpleRequest{})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("err = %v, want resource exhausted", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls seen by prepended interceptor = %d, want 1", calls)
+	}
+}
@@ -0,0 +1,312 @@
+package server
+
+import (
+	"crypto/tls"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/go-board/thor/pkg/auth"
+	"github.com/go-board/thor/pkg/fault"
+	"github.com/go-board/thor/pkg/registry"
+)
+
+type Option func(o *Options)
+
+// Options is the grpc server configuration.
+type Options struct {
+	// Interceptors chained before the built-in interceptors, the first one is the outermost.
+	PrependUnaryInterceptors  []grpc.UnaryServerInterceptor
+	PrependStreamInterceptors []grpc.StreamServerInterceptor
+	// Interceptors chained after the built-in interceptors, the last one is the innermost.
+	AppendUnaryInterceptors  []grpc.UnaryServerInterceptor
+	AppendStreamInterceptors []grpc.StreamServerInterceptor
+
+	DisableRecovery bool
+	DisableTracing  bool
+	DisableCtxTags  bool
+	DisableLogging  bool
+	DisableMetrics  bool
+	DisableStats    bool
+	// DisableValidation skip checking rules and applying default_value declared in resilience.proto.
+	DisableValidation bool
+	// StatsSpan tag span with payload size collected by stats handler.
+	StatsSpan bool
+
+	// RecoveryMessage is the error message returned to client when handler panic.
+	RecoveryMessage string
+	// RecoveryDebug attach an error id to the error message and trailer, which is also logged.
+	RecoveryDebug bool
+
+	// Logger used by logging interceptor, if nil, zap.L() is read at every call,
+	// so the logger replaced by logger.Initialize after server created still works.
+	Logger *zap.Logger
+
+	KeepaliveParams      *keepalive.ServerParameters
+	KeepalivePolicy      *keepalive.EnforcementPolicy
+	MaxRecvMsgSize       int
+	MaxSendMsgSize       int
+	MaxConcurrentStreams uint32
+	Creds                credentials.TransportCredentials
+
+	Registry registry.Registry
+	Service  registry.Service
+
+	Deadline         DeadlineOption
+	RateLimit        RateLimitOption
+	ConcurrencyLimit ConcurrencyLimitOption
+
+	// Authenticator authenticate calls and AuthPolicy authorize them, auth is disabled if Authenticator is nil.
+	Authenticator auth.Authenticator
+	AuthPolicy    auth.Policy
+
+	// FaultInjector inject faults into calls by its rules if not nil.
+	FaultInjector *fault.Injector
+
+	// Proxy forward calls to unknown services if set, otherwise codes.Unimplemented is returned.
+	Proxy *ProxyOption
+
+	// HealthChecks is dependencies checked every HealthCheckInterval, keyed by dependency name.
+	HealthChecks        map[string]HealthCheckFunc
+	HealthCheckInterval time.Duration
+	EnableReflection    bool
+	EnableChannelz      bool
+
+	// ServerOptions is passed to grpc.NewServer directly, after all other options.
+	ServerOptions []grpc.ServerOption
+}
+
+// UnaryInterceptor add interceptors after built-in interceptors.
+func UnaryInterceptor(interceptors ...grpc.UnaryServerInterceptor) Option {
+	return func(o *Options) {
+		o.AppendUnaryInterceptors = append(o.AppendUnaryInterceptors, interceptors...)
+	}
+}
+
+// StreamInterceptor add interceptors after built-in interceptors.
+func StreamInterceptor(interceptors ...grpc.StreamServerInterceptor) Option {
+	return func(o *Options) {
+		o.AppendStreamInterceptors = append(o.AppendStreamInterceptors, interceptors...)
+	}
+}
+
+// PrependUnaryInterceptor add interceptors before built-in interceptors.
+func PrependUnaryInterceptor(interceptors ...grpc.UnaryServerInterceptor) Option {
+	return func(o *Options) {
+		o.PrependUnaryInterceptors = append(o.PrependUnaryInterceptors, interceptors...)
+	}
+}
+
+// PrependStreamInterceptor add interceptors before built-in interceptors.
+func PrependStreamInterceptor(interceptors ...grpc.StreamServerInterceptor) Option {
+	return func(o *Options) {
+		o.PrependStreamInterceptors = append(o.PrependStreamInterceptors, interceptors...)
+	}
+}
+
+func DisableRecovery() Option {
+	return func(o *Options) {
+		o.DisableRecovery = true
+	}
+}
+
+func DisableTracing() Option {
+	return func(o *Options) {
+		o.DisableTracing = true
+	}
+}
+
+func DisableCtxTags() Option {
+	return func(o *Options) {
+		o.DisableCtxTags = true
+	}
+}
+
+func DisableLogging() Option {
+	return func(o *Options) {
+		o.DisableLogging = true
+	}
+}
+
+func DisableMetrics() Option {
+	return func(o *Options) {
+		o.DisableMetrics = true
+	}
+}
+
+func DisableStats() Option {
+	return func(o *Options) {
+		o.DisableStats = true
+	}
+}
+
+func DisableValidation() Option {
+	return func(o *Options) {
+		o.DisableValidation = true
+	}
+}
+
+func StatsSpan() Option {
+	return func(o *Options) {
+		o.StatsSpan = true
+	}
+}
+
+func RecoveryMessage(msg string) Option {
+	return func(o *Options) {
+		o.RecoveryMessage = msg
+	}
+}
+
+func RecoveryDebug() Option {
+	return func(o *Options) {
+		o.RecoveryDebug = true
+	}
+}
+
+func Logger(l *zap.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+func KeepaliveParams(p keepalive.ServerParameters) Option {
+	return func(o *Options) {
+		o.KeepaliveParams = &p
+	}
+}
+
+func KeepalivePolicy(p keepalive.EnforcementPolicy) Option {
+	return func(o *Options) {
+		o.KeepalivePolicy = &p
+	}
+}
+
+// KeepaliveTime ping client after idle time, and close connection if no response in timeout.
+func KeepaliveTime(idle time.Duration, timeout time.Duration) Option {
+	return func(o *Options) {
+		if o.KeepaliveParams == nil {
+			o.KeepaliveParams = &keepalive.ServerParameters{}
+		}
+		o.KeepaliveParams.Time = idle
+		o.KeepaliveParams.Timeout = timeout
+	}
+}
+
+func MaxRecvMsgSize(n int) Option {
+	return func(o *Options) {
+		o.MaxRecvMsgSize = n
+	}
+}
+
+func MaxSendMsgSize(n int) Option {
+	return func(o *Options) {
+		o.MaxSendMsgSize = n
+	}
+}
+
+func MaxConcurrentStreams(n uint32) Option {
+	return func(o *Options) {
+		o.MaxConcurrentStreams = n
+	}
+}
+
+func Creds(c credentials.TransportCredentials) Option {
+	return func(o *Options) {
+		o.Creds = c
+	}
+}
+
+func TLSConfig(c *tls.Config) Option {
+	return Creds(credentials.NewTLS(c))
+}
+
+// Registry register service to registry when server started, and deregister when closed.
+func Registry(r registry.Registry, service registry.Service) Option {
+	return func(o *Options) {
+		o.Registry = r
+		o.Service = service
+	}
+}
+
+func Deadline(d DeadlineOption) Option {
+	return func(o *Options) {
+		o.Deadline = d
+	}
+}
+
+func RateLimit(l RateLimitOption) Option {
+	return func(o *Options) {
+		o.RateLimit = l
+	}
+}
+
+func ConcurrencyLimit(l ConcurrencyLimitOption) Option {
+	return func(o *Options) {
+		o.ConcurrencyLimit = l
+	}
+}
+
+// Auth authenticate calls by a and authorize them by policy.
+func Auth(a auth.Authenticator, policy auth.Policy) Option {
+	return func(o *Options) {
+		o.Authenticator = a
+		o.AuthPolicy = policy
+	}
+}
+
+// FaultInjection inject faults into calls by rules of i, rules of client side are ignored.
+func FaultInjection(i *fault.Injector) Option {
+	return func(o *Options) {
+		o.FaultInjector = i
+	}
+}
+
+// Proxy make server an edge gateway, which forwards calls to unknown services to backends found in registry.
+// grpc has no codec per handler, so the proxy installs its codec for the whole server by grpc.CustomCodec,
+// it passes raw frames of proxied calls and falls back to proto codec for registered services,
+// a codec set by ServerOptions replaces it and breaks proxied calls, so they must not be used together.
+func Proxy(p ProxyOption) Option {
+	return func(o *Options) {
+		o.Proxy = &p
+	}
+}
+
+// HealthCheck add a dependency check, all services are not serving if it failed.
+func HealthCheck(name string, check HealthCheckFunc) Option {
+	return func(o *Options) {
+		if o.HealthChecks == nil {
+			o.HealthChecks = make(map[string]HealthCheckFunc)
+		}
+		o.HealthChecks[name] = check
+	}
+}
+
+func HealthCheckInterval(d time.Duration) Option {
+	return func(o *Options) {
+		o.HealthCheckInterval = d
+	}
+}
+
+// Reflection register grpc server reflection service, which is used by tools like grpcurl.
+func Reflection() Option {
+	return func(o *Options) {
+		o.EnableReflection = true
+	}
+}
+
+// Channelz register channelz admin service.
+func Channelz() Option {
+	return func(o *Options) {
+		o.EnableChannelz = true
+	}
+}
+
+func ServerOptions(opts ...grpc.ServerOption) Option {
+	return func(o *Options) {
+		o.ServerOptions = append(o.ServerOptions, opts...)
+	}
+}
@@ -0,0 +1,325 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/proto"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/go-board/thor/pkg/registry"
+)
+
+const defaultProxyRefreshInterval = time.Second * 30
+
+// ProxyOption forward calls of services not registered on the server to backends found in registry.
+type ProxyOption struct {
+	Registry registry.Registry
+	// ServiceName map grpc service name like `pkg.Service` to registry service name, default is identity.
+	ServiceName func(grpcService string) string
+	// DialOptions is used to dial backends, insecure is used if empty.
+	DialOptions []grpc.DialOption
+	// RefreshInterval is how often backends of a service are looked up from registry.
+	RefreshInterval time.Duration
+}
+
+// frame is a raw message forwarded by proxy without decoding.
+type frame struct{ payload []byte }
+
+// rawCodec pass frame through and fallback to proto codec for other messages,
+// it's installed as server codec so that registered services still work.
+type rawCodec struct{ proto encoding.Codec }
+
+func newRawCodec() rawCodec { return rawCodec{proto: encoding.GetCodec(proto.Name)} }
+
+func (c rawCodec) Marshal(v interface{}) ([]byte, error) {
+	if f, ok := v.(*frame); ok {
+		return f.payload, nil
+	}
+	return c.proto.Marshal(v)
+}
+
+func (c rawCodec) Unmarshal(data []byte, v interface{}) error {
+	if f, ok := v.(*frame); ok {
+		f.payload = data
+		return nil
+	}
+	return c.proto.Unmarshal(data, v)
+}
+
+func (c rawCodec) Name() string { return c.proto.Name() }
+
+func (c rawCodec) String() string { return c.proto.Name() }
+
+var proxyStreamDesc = &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}
+
+type backends struct {
+	addrs     []string
+	refreshAt time.Time
+	// lookup is closed when the in-flight lookup finished, nil if no lookup in flight.
+	lookup chan struct{}
+	// err is the error of the first lookup, which left no backend.
+	err error
+}
+
+// backendConn is a connection to a backend, it's closed when the backend left registry and no call is using it.
+type backendConn struct {
+	cc    *grpc.ClientConn
+	calls int
+	stale bool
+}
+
+type proxy struct {
+	option ProxyOption
+	codec  rawCodec
+
+	mu       sync.Mutex
+	backends map[string]*backends
+	conns    map[string]*backendConn
+}
+
+func newProxy(o ProxyOption) *proxy {
+	if o.ServiceName == nil {
+		o.ServiceName = func(s string) string { return s }
+	}
+	if o.RefreshInterval <= 0 {
+		o.RefreshInterval = defaultProxyRefreshInterval
+	}
+	if len(o.DialOptions) == 0 {
+		o.DialOptions = []grpc.DialOption{grpc.WithInsecure()}
+	}
+	return &proxy{
+		option:   o,
+		codec:    newRawCodec(),
+		backends: make(map[string]*backends),
+		conns:    make(map[string]*backendConn),
+	}
+}
+
+// unknownServiceHandler reject calls to services not registered.
+func unknownServiceHandler(srv interface{}, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	return status.Errorf(codes.Unimplemented, "unknown service or method %s", method)
+}
+
+// handle forward the stream to a backend frame by frame, header and trailer are forwarded too.
+func (p *proxy) handle(srv interface{}, ss grpc.ServerStream) error {
+	method, ok := grpc.MethodFromServerStream(ss)
+	if !ok {
+		return status.Error(codes.Internal, "proxy: method not found in stream")
+	}
+	cc, release, err := p.conn(ss.Context(), method)
+	if err != nil {
+		return err
+	}
+	defer release()
+	md, _ := metadata.FromIncomingContext(ss.Context())
+	ctx, cancel := context.WithCancel(metadata.NewOutgoingContext(ss.Context(), md.Copy()))
+	defer cancel()
+	cs, err := cc.NewStream(ctx, proxyStreamDesc, method, grpc.ForceCodec(p.codec))
+	if err != nil {
+		return err
+	}
+	s2c := forwardServerToClient(ss, cs)
+	c2s := forwardClientToServer(cs, ss)
+	for {
+		select {
+		case err := <-s2c:
+			if err == io.EOF {
+				// caller finished sending, wait for backend to finish.
+				s2c = nil
+				continue
+			}
+			return status.Errorf(codes.Internal, "proxy: forward to backend failed, %s", err)
+		case err := <-c2s:
+			ss.SetTrailer(cs.Trailer())
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func forwardServerToClient(src grpc.ServerStream, dst grpc.ClientStream) <-chan error {
+	ch := make(chan error, 1)
+	go func() {
+		for {
+			f := &frame{}
+			if err := src.RecvMsg(f); err != nil {
+				if err == io.EOF {
+					_ = dst.CloseSend()
+				}
+				ch <- err
+				return
+			}
+			if err := dst.SendMsg(f); err != nil {
+				ch <- err
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func forwardClientToServer(src grpc.ClientStream, dst grpc.ServerStream) <-chan error {
+	ch := make(chan error, 1)
+	go func() {
+		for i := 0; ; i++ {
+			f := &frame{}
+			if err := src.RecvMsg(f); err != nil {
+				ch <- err
+				return
+			}
+			if i == 0 {
+				// header is available after first message received, and must be sent before first message.
+				md, err := src.Header()
+				if err != nil {
+					ch <- err
+					return
+				}
+				if err := dst.SendHeader(md); err != nil {
+					ch <- err
+					return
+				}
+			}
+			if err := dst.SendMsg(f); err != nil {
+				ch <- err
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// conn pick a random backend of the service, connections are shared by calls to the same backend,
+// release must be called when the call finished.
+// Backends are looked up out of lock, calls use the stale backends while refreshing.
+func (p *proxy) conn(ctx context.Context, method string) (*grpc.ClientConn, func(), error) {
+	service := strings.SplitN(strings.TrimPrefix(method, "/"), "/", 2)[0]
+	name := p.option.ServiceName(service)
+	p.mu.Lock()
+	b, ok := p.backends[name]
+	switch {
+	case !ok:
+		b = &backends{lookup: make(chan struct{})}
+		p.backends[name] = b
+		p.lookup(ctx, name, b)
+	case b.lookup != nil && b.refreshAt.IsZero():
+		// the first lookup is in flight, wait for it.
+		lookup := b.lookup
+		p.mu.Unlock()
+		select {
+		case <-lookup:
+		case <-ctx.Done():
+			return nil, nil, status.FromContextError(ctx.Err()).Err()
+		}
+		p.mu.Lock()
+	case b.lookup == nil && time.Now().After(b.refreshAt):
+		b.lookup = make(chan struct{})
+		go func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.lookup(context.Background(), name, b)
+		}()
+	}
+	defer p.mu.Unlock()
+	if b.err != nil {
+		return nil, nil, status.Errorf(codes.Unavailable, "proxy: lookup service %s failed, %s", name, b.err)
+	}
+	if len(b.addrs) == 0 {
+		// the service is proxied but has no instance by now, callers may retry later.
+		return nil, nil, status.Errorf(codes.Unavailable, "proxy: no backend for service %s", name)
+	}
+	addr := b.addrs[rand.Intn(len(b.addrs))]
+	c, ok := p.conns[addr]
+	if !ok {
+		cc, err := grpc.DialContext(context.Background(), addr, p.option.DialOptions...)
+		if err != nil {
+			return nil, nil, status.Errorf(codes.Unavailable, "proxy: dial backend %s failed, %s", addr, err)
+		}
+		c = &backendConn{cc: cc}
+		p.conns[addr] = c
+	}
+	c.calls++
+	return c.cc, func() { p.release(addr, c) }, nil
+}
+
+// lookup backends of service out of lock, b.lookup must be set and p.mu must be held, and it's held again when returned.
+func (p *proxy) lookup(ctx context.Context, name string, b *backends) {
+	p.mu.Unlock()
+	services, err := p.option.Registry.GetService(ctx, name)
+	p.mu.Lock()
+	close(b.lookup)
+	b.lookup = nil
+	if err != nil {
+		if b.refreshAt.IsZero() {
+			// nothing to fall back on, the next call looks up again.
+			b.err = err
+			delete(p.backends, name)
+			return
+		}
+		// keep the stale backends until next refresh.
+		b.refreshAt = time.Now().Add(p.option.RefreshInterval)
+		return
+	}
+	b.addrs, b.err = b.addrs[:0], nil
+	for _, s := range services {
+		b.addrs = append(b.addrs, s.ServiceAddr)
+	}
+	b.refreshAt = time.Now().Add(p.option.RefreshInterval)
+	p.evictConns()
+}
+
+// evictConns close connections to backends left registry once no call is using them, p.mu must be held.
+func (p *proxy) evictConns() {
+	alive := make(map[string]bool)
+	for _, b := range p.backends {
+		for _, addr := range b.addrs {
+			alive[addr] = true
+		}
+	}
+	for addr, c := range p.conns {
+		if !alive[addr] {
+			c.stale = true
+			delete(p.conns, addr)
+			if c.calls == 0 {
+				_ = c.cc.Close()
+			}
+		}
+	}
+}
+
+func (p *proxy) release(addr string, c *backendConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c.calls--
+	if c.stale && c.calls == 0 {
+		_ = c.cc.Close()
+	}
+}
+
+func (p *proxy) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var errs []string
+	for addr, c := range p.conns {
+		if err := c.cc.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+		delete(p.conns, addr)
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/go-board/thor/pkg/registry"
+)
+
+// blockingRegistry block lookup of service named blocked until unblock closed.
+type blockingRegistry struct {
+	registry.Registry
+	blocked string
+	unblock chan struct{}
+}
+
+func (r *blockingRegistry) GetService(ctx context.Context, name string) ([]*registry.Service, error) {
+	if name == r.blocked {
+		<-r.unblock
+	}
+	return r.Registry.GetService(ctx, name)
+}
+
+func TestProxy(t *testing.T) {
+	backend := bufconn.Listen(1 << 20)
+	b := New(DisableLogging())
+	testpb.RegisterTestServiceServer(b.GRPCServer(), &testService{})
+	go func() { _ = b.Serve(backend) }()
+	defer b.Close()
+
+	mem := registry.NewMemory()
+	backendService := registry.Service{ServiceName: "grpc.testing.TestService", ServiceID: "backend", ServiceAddr: "backend:1"}
+	_ = mem.Register(context.Background(), backendService)
+	r := &blockingRegistry{Registry: mem, blocked: "grpc.testing.ReconnectService", unblock: make(chan struct{})}
+
+	front := bufconn.Listen(1 << 20)
+	f := New(DisableLogging(), Proxy(ProxyOption{
+		Registry: r,
+		DialOptions: []grpc.DialOption{grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return backend.Dial()
+		})},
+		RefreshInterval: time.Millisecond * 10,
+	}))
+	go func() { _ = f.Serve(front) }()
+	defer f.Close()
+	cc, err := grpc.Dial("front", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return front.Dial()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	// unblock lookup before graceful stop, which waits for the call blocked.
+	defer close(r.unblock)
+
+	// a lookup in flight doesn't block calls to other services.
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- cc.Invoke(context.Background(), "/grpc.testing.ReconnectService/Start", &testpb.Empty{}, &testpb.Empty{})
+	}()
+	time.Sleep(time.Millisecond * 10)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client := testpb.NewTestServiceClient(cc)
+	resp, err := client.UnaryCall(ctx, &testpb.SimpleRequest{Payload: &testpb.Payload{Body: []byte("ping")}})
+	if err != nil || string(resp.GetPayload().GetBody()) != "ping" {
+		t.Fatalf("proxied call = %v, %v", resp, err)
+	}
+	select {
+	case err := <-blocked:
+		t.Fatalf("call to service being looked up returned early: %v", err)
+	default:
+	}
+
+	// connection to backend left registry is closed after refresh.
+	_ = mem.Deregister(context.Background(), backendService)
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, err := client.UnaryCall(ctx, &testpb.SimpleRequest{})
+		f.proxy.mu.Lock()
+		conns := len(f.proxy.conns)
+		f.proxy.mu.Unlock()
+		if status.Code(err) == codes.Unavailable && conns == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("backend not evicted, err = %v, conns = %d", err, conns)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/go-board/thor/pkg/logger"
+	"github.com/go-board/thor/pkg/metric"
+)
+
+const (
+	defaultRecoveryMessage = "internal server error"
+	// ErrorIDKey is the trailer key of error id when recovery debug mode enabled.
+	ErrorIDKey = "x-error-id"
+)
+
+var panicCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "grpc_server_panic_total",
+	Help: "Total number of RPCs panicked on the server.",
+}, []string{"grpc_method"})
+
+type recovery struct {
+	message string
+	debug   bool
+}
+
+func newRecovery(o *Options) *recovery {
+	metric.MustRegister(panicCounter)
+	message := o.RecoveryMessage
+	if message == "" {
+		message = defaultRecoveryMessage
+	}
+	return &recovery{message: message, debug: o.RecoveryDebug}
+}
+
+func (r *recovery) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = r.recoverFrom(ctx, info.FullMethod, p)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func (r *recovery) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = r.recoverFrom(ss.Context(), info.FullMethod, p)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// recoverFrom log the panic with stack, mark span as error and return sanitized internal error.
+func (r *recovery) recoverFrom(ctx context.Context, method string, p interface{}) error {
+	stack := debug.Stack()
+	errorID := newErrorID()
+	panicCounter.WithLabelValues(method).Inc()
+	logger.FromContext(ctx).Error("grpc handler panic",
+		zap.String("grpc.method", method),
+		zap.String("error_id", errorID),
+		zap.Any("panic", p),
+		zap.ByteString("stack", stack),
+	)
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(
+			log.String("event", "panic"),
+			log.String("error_id", errorID),
+			log.String("message", fmt.Sprint(p)),
+			log.String("stack", string(stack)),
+		)
+	}
+	if !r.debug {
+		return status.Error(codes.Internal, r.message)
+	}
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(ErrorIDKey, errorID))
+	return status.Errorf(codes.Internal, "%s, error id: %s", r.message, errorID)
+}
+
+func newErrorID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
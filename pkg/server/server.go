@@ -3,49 +3,201 @@ package server
 import (
 	"context"
 	"net"
+	"sync"
+	"time"
 
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_zap "github.com/grpc-ecosystem/go-grpc-middleware/logging/zap"
-	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
 	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
 	grpc_opentracing "github.com/grpc-ecosystem/go-grpc-middleware/tracing/opentracing"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
-	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	channelz "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
+	"github.com/go-board/thor/pkg/auth"
+	"github.com/go-board/thor/pkg/errors"
+	"github.com/go-board/thor/pkg/logger"
 	"github.com/go-board/thor/pkg/registry"
 )
 
 type Server struct {
 	srv      *grpc.Server
 	registry registry.Registry
+	service  registry.Service
+
+	health              *health.Server
+	healthMu            sync.Mutex
+	healthStatuses      map[string]healthpb.HealthCheckResponse_ServingStatus // set by SetServingStatus
+	dependenciesHealthy bool
+	healthChecks        map[string]HealthCheckFunc
+	healthCheckInterval time.Duration
+	cancel              context.CancelFunc
+	proxy               *proxy
+	validator           *validator
 }
 
+// New create grpc server with built-in interceptors and user options,
+// grpc.health.v1 is registered, serving status of all services follows the server lifecycle and health checks.
+// Interceptors are chained in order of prepended, built-in and appended,
+// built-in interceptors are tracing, ctxtags, logging, metrics, fault, deadline, auth, validation, errors and recovery,
+// recovery is the innermost one so that panics of handlers are visible to others as codes.Internal,
+// and it's also installed before prepended interceptors to catch panics of interceptors,
+// followed by limiter, which rejects calls limited by tap handle before any other work.
+func New(options ...Option) *Server {
+	opts := &Options{}
+	for _, option := range options {
+		option(opts)
+	}
+	var p *proxy
+	if opts.Proxy != nil {
+		p = newProxy(*opts.Proxy)
+	}
+	var v *validator
+	if !opts.DisableValidation {
+		v = newValidator()
+	}
+	s := &Server{
+		srv:                 grpc.NewServer(serverOptions(opts, p, v)...),
+		registry:            opts.Registry,
+		service:             opts.Service,
+		health:              newHealthServer(),
+		healthStatuses:      make(map[string]healthpb.HealthCheckResponse_ServingStatus),
+		healthChecks:        opts.HealthChecks,
+		healthCheckInterval: opts.HealthCheckInterval,
+		proxy:               p,
+		validator:           v,
+	}
+	if s.healthCheckInterval <= 0 {
+		s.healthCheckInterval = defaultHealthCheckInterval
+	}
+	healthpb.RegisterHealthServer(s.srv, s.health)
+	if opts.EnableReflection {
+		reflection.Register(s.srv)
+	}
+	if opts.EnableChannelz {
+		channelz.RegisterChannelzServiceToServer(s.srv)
+	}
+	return s
+}
+
+// NewServer create grpc server with default options.
+//
+// Deprecated: use New instead.
 func NewServer() *grpc.Server {
-	srv := grpc.NewServer(
-		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
-			grpc_recovery.UnaryServerInterceptor(grpc_recovery.WithRecoveryHandlerContext(func(ctx context.Context, p interface{}) (err error) {
-				return
-			})),
-			grpc_opentracing.UnaryServerInterceptor(),
-			grpc_ctxtags.UnaryServerInterceptor(),
-			grpc_zap.UnaryServerInterceptor(zap.L()),
-			grpc_prometheus.UnaryServerInterceptor,
-		)),
-		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(
-			grpc_recovery.StreamServerInterceptor(),
-			grpc_opentracing.StreamServerInterceptor(),
-			grpc_ctxtags.StreamServerInterceptor(),
-			grpc_zap.StreamServerInterceptor(zap.L()),
-			grpc_prometheus.StreamServerInterceptor,
-		)),
-		grpc.StatsHandler(&statsHandler{}),
-		grpc.UnknownServiceHandler(unknownServiceHandler),
-		grpc.InTapHandle(inTapHandle),
-	)
-	return srv
+	return New().srv
+}
+
+func serverOptions(o *Options, p *proxy, v *validator) []grpc.ServerOption {
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+	var r *recovery
+	if !o.DisableRecovery {
+		// the outermost recovery catch panics of interceptors, including prepended ones.
+		r = newRecovery(o)
+		unary = append(unary, r.UnaryServerInterceptor)
+		stream = append(stream, r.StreamServerInterceptor)
+	}
+	// reject calls limited by tap handle before any other work.
+	limiter := newLimiter(o)
+	if limiter != nil {
+		unary = append(unary, limiter.UnaryServerInterceptor)
+		stream = append(stream, limiter.StreamServerInterceptor)
+	}
+	unary = append(unary, o.PrependUnaryInterceptors...)
+	stream = append(stream, o.PrependStreamInterceptors...)
+	if !o.DisableTracing {
+		unary = append(unary, grpc_opentracing.UnaryServerInterceptor())
+		stream = append(stream, grpc_opentracing.StreamServerInterceptor())
+		if o.StatsSpan && !o.DisableStats {
+			unary = append(unary, statsSpanUnaryInterceptor)
+			stream = append(stream, statsSpanStreamInterceptor)
+		}
+	}
+	if !o.DisableCtxTags {
+		unary = append(unary, grpc_ctxtags.UnaryServerInterceptor())
+		stream = append(stream, grpc_ctxtags.StreamServerInterceptor())
+	}
+	if !o.DisableLogging {
+		l := o.Logger
+		if l == nil {
+			l = logger.Global()
+		}
+		unary = append(unary, grpc_zap.UnaryServerInterceptor(l))
+		stream = append(stream, grpc_zap.StreamServerInterceptor(l))
+	}
+	if !o.DisableMetrics {
+		unary = append(unary, grpc_prometheus.UnaryServerInterceptor)
+		stream = append(stream, grpc_prometheus.StreamServerInterceptor)
+	}
+	if o.FaultInjector != nil {
+		unary = append(unary, o.FaultInjector.UnaryServerInterceptor)
+		stream = append(stream, o.FaultInjector.StreamServerInterceptor)
+	}
+	if d := newDeadline(o.Deadline); d != nil {
+		unary = append(unary, d.UnaryServerInterceptor)
+		stream = append(stream, d.StreamServerInterceptor)
+	}
+	if o.Authenticator != nil {
+		a := auth.NewInterceptor(o.Authenticator, o.AuthPolicy)
+		unary = append(unary, a.UnaryServerInterceptor)
+		stream = append(stream, a.StreamServerInterceptor)
+	}
+	if v != nil {
+		unary = append(unary, v.UnaryServerInterceptor)
+		stream = append(stream, v.StreamServerInterceptor)
+	}
+	// convert errors returned by handlers before other interceptors inspect their codes.
+	unary = append(unary, errors.UnaryServerInterceptor)
+	stream = append(stream, errors.StreamServerInterceptor)
+	if r != nil {
+		unary = append(unary, r.UnaryServerInterceptor)
+		stream = append(stream, r.StreamServerInterceptor)
+	}
+	unary = append(unary, o.AppendUnaryInterceptors...)
+	stream = append(stream, o.AppendStreamInterceptors...)
+
+	serverOptions := []grpc.ServerOption{
+		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(unary...)),
+		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(stream...)),
+	}
+	if p != nil {
+		serverOptions = append(serverOptions, grpc.UnknownServiceHandler(p.handle), grpc.CustomCodec(p.codec))
+	} else {
+		serverOptions = append(serverOptions, grpc.UnknownServiceHandler(unknownServiceHandler))
+	}
+	if !o.DisableStats {
+		serverOptions = append(serverOptions, grpc.StatsHandler(newStatsHandler()))
+	}
+	if limiter != nil {
+		serverOptions = append(serverOptions, grpc.InTapHandle(limiter.TapHandle))
+	}
+	if o.KeepaliveParams != nil {
+		serverOptions = append(serverOptions, grpc.KeepaliveParams(*o.KeepaliveParams))
+	}
+	if o.KeepalivePolicy != nil {
+		serverOptions = append(serverOptions, grpc.KeepaliveEnforcementPolicy(*o.KeepalivePolicy))
+	}
+	if o.MaxRecvMsgSize > 0 {
+		serverOptions = append(serverOptions, grpc.MaxRecvMsgSize(o.MaxRecvMsgSize))
+	}
+	if o.MaxSendMsgSize > 0 {
+		serverOptions = append(serverOptions, grpc.MaxSendMsgSize(o.MaxSendMsgSize))
+	}
+	if o.MaxConcurrentStreams > 0 {
+		serverOptions = append(serverOptions, grpc.MaxConcurrentStreams(o.MaxConcurrentStreams))
+	}
+	if o.Creds != nil {
+		serverOptions = append(serverOptions, grpc.Creds(o.Creds))
+	}
+	return append(serverOptions, o.ServerOptions...)
 }
 
+// GRPCServer return the underlying grpc server.
+func (s *Server) GRPCServer() *grpc.Server { return s.srv }
+
 func (s *Server) RegisterService(sd *grpc.ServiceDesc, srv interface{}) {
 	s.srv.RegisterService(sd, srv)
 }
@@ -55,10 +207,44 @@ func (s *Server) Start(addr string) error {
 	if err != nil {
 		return err
 	}
-	// todo: register service
+	return s.Serve(ln)
+}
+
+// Serve accept connections on ln, service is registered to registry if configured.
+// It fails if validation rules of registered services are invalid.
+func (s *Server) Serve(ln net.Listener) error {
+	if s.validator != nil {
+		if err := s.validator.compileServices(s.srv.GetServiceInfo()); err != nil {
+			return err
+		}
+	}
+	if s.registry != nil {
+		service := s.service
+		if service.ServiceAddr == "" {
+			service.ServiceAddr = ln.Addr().String()
+		}
+		if err := s.registry.Register(context.Background(), service); err != nil {
+			return err
+		}
+		s.service = service
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go s.runHealthChecks(ctx)
 	return s.srv.Serve(ln)
 }
 
+// Close mark all services not serving and stop server gracefully.
 func (s *Server) Close() {
+	s.health.Shutdown()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	if s.registry != nil {
+		_ = s.registry.Deregister(context.Background(), s.service)
+	}
 	s.srv.GracefulStop()
+	if s.proxy != nil {
+		_ = s.proxy.Close()
+	}
 }
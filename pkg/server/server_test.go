@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testService struct {
+	testpb.UnimplementedTestServiceServer
+}
+
+// UnaryCall fail with status of request if set, and panic if message of status is "panic".
+func (*testService) UnaryCall(ctx context.Context, req *testpb.SimpleRequest) (*testpb.SimpleResponse, error) {
+	if req.GetResponseStatus().GetMessage() == "panic" {
+		panic("handler panic")
+	}
+	if s := req.GetResponseStatus(); s.GetCode() != 0 {
+		return nil, status.Error(codes.Code(s.GetCode()), s.GetMessage())
+	}
+	return &testpb.SimpleResponse{Payload: req.GetPayload()}, nil
+}
+
+// FullDuplexCall echo payloads of requests until client close send.
+func (*testService) FullDuplexCall(stream testpb.TestService_FullDuplexCallServer) error {
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		if err := stream.Send(&testpb.StreamingOutputCallResponse{Payload: req.GetPayload()}); err != nil {
+			return err
+		}
+	}
+}
+
+// startTestServer serve test service by New(options...) on bufconn and return a client of it.
+func startTestServer(t *testing.T, options ...Option) (*Server, testpb.TestServiceClient) {
+	ln := bufconn.Listen(1 << 20)
+	s := New(append([]Option{DisableLogging()}, options...)...)
+	testpb.RegisterTestServiceServer(s.GRPCServer(), &testService{})
+	go func() { _ = s.Serve(ln) }()
+	t.Cleanup(s.Close)
+	cc, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return ln.Dial()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cc.Close() })
+	return s, testpb.NewTestServiceClient(cc)
+}
+
+func TestRecoveryFromHandler(t *testing.T) {
+	_, client := startTestServer(t)
+	_, err := client.UnaryCall(context.Background(), &testpb.SimpleRequest{ResponseStatus: &testpb.EchoStatus{Message: "panic"}})
+	if status.Code(err) != codes.Internal || status.Convert(err).Message() != defaultRecoveryMessage {
+		t.Fatalf("err = %v, want internal error", err)
+	}
+}
+
+func TestRecoveryFromInterceptors(t *testing.T) {
+	panicUnary := func(context.Context, interface{}, *grpc.UnaryServerInfo, grpc.UnaryHandler) (interface{}, error) {
+		panic("interceptor panic")
+	}
+	panicStream := func(interface{}, grpc.ServerStream, *grpc.StreamServerInfo, grpc.StreamHandler) error {
+		panic("interceptor panic")
+	}
+	_, client := startTestServer(t, PrependUnaryInterceptor(panicUnary), PrependStreamInterceptor(panicStream))
+	_, err := client.UnaryCall(context.Background(), &testpb.SimpleRequest{})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("unary err = %v, want internal error", err)
+	}
+	stream, err := client.FullDuplexCall(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Internal {
+		t.Fatalf("stream err = %v, want internal error", err)
+	}
+}
+
+func TestInterceptorOrder(t *testing.T) {
+	var order []string
+	record := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			tagged := grpc_ctxtags.Extract(ctx) != grpc_ctxtags.NoopTags
+			order = append(order, name+":"+strconv.FormatBool(tagged))
+			resp, err := handler(ctx, req)
+			if name == "prepended" {
+				order = append(order, "prepended sees "+status.Code(err).String())
+			}
+			return resp, err
+		}
+	}
+	failure := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return nil, stderrors.New("secret of appended")
+	}
+	_, client := startTestServer(t, PrependUnaryInterceptor(record("prepended")), UnaryInterceptor(record("appended"), failure))
+	_, err := client.UnaryCall(context.Background(), &testpb.SimpleRequest{})
+	// ctxtags is built-in, it's between prepended and appended ones,
+	// and plain errors of appended interceptors are converted by built-in errors interceptor before prepended ones see them.
+	want := []string{"prepended:false", "appended:true", "prepended sees Unknown"}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if status.Code(err) != codes.Unknown || strings.Contains(err.Error(), "secret") {
+		t.Errorf("err = %v, want unknown error without message", err)
+	}
+}
+
+func TestTransportOptions(t *testing.T) {
+	_, client := startTestServer(t, MaxRecvMsgSize(64))
+	_, err := client.UnaryCall(context.Background(), &testpb.SimpleRequest{Payload: &testpb.Payload{Body: make([]byte, 128)}})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("err of large message = %v, want resource exhausted", err)
+	}
+	if _, err := client.UnaryCall(context.Background(), &testpb.SimpleRequest{}); err != nil {
+		t.Errorf("err of small message = %v", err)
+	}
+}
+
+func TestLoggerReplaced(t *testing.T) {
+	ln := bufconn.Listen(1 << 20)
+	s := New(DisableValidation())
+	testpb.RegisterTestServiceServer(s.GRPCServer(), &testService{})
+	go func() { _ = s.Serve(ln) }()
+	defer s.Close()
+	cc, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return ln.Dial()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	// the global logger replaced after the server created is used by logging interceptor.
+	core, logs := observer.New(zapcore.InfoLevel)
+	defer zap.ReplaceGlobals(zap.New(core))()
+	if _, err := testpb.NewTestServiceClient(cc).UnaryCall(context.Background(), &testpb.SimpleRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := logs.FilterField(zap.String("grpc.method", "UnaryCall")).Len(); n != 1 {
+		t.Errorf("logged calls = %d, want 1", n)
+	}
+}
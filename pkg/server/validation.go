@@ -0,0 +1,394 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	thor_proto "github.com/go-board/thor/proto"
+)
+
+// fieldRule is the compiled rules and default value of a field.
+type fieldRule struct {
+	field        protoreflect.FieldDescriptor
+	rules        *thor_proto.FieldRules
+	pattern      *regexp.Regexp
+	in           map[string]bool
+	defaultValue *protoreflect.Value
+}
+
+// compiledRules is the rules of a message type, or the error compiling them.
+type compiledRules struct {
+	rules []*fieldRule
+	err   error
+}
+
+// validator apply default_value and check rules declared by field options of request messages,
+// rules are compiled once per message type.
+type validator struct {
+	rules sync.Map // protoreflect.FullName -> *compiledRules
+}
+
+func newValidator() *validator { return &validator{} }
+
+// compileServices compile rules of request messages of services, so that invalid options fail on serving.
+func (v *validator) compileServices(services map[string]grpc.ServiceInfo) error {
+	for name := range services {
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			continue
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+		for i := 0; i < sd.Methods().Len(); i++ {
+			if err := v.compileAll(sd.Methods().Get(i).Input().FullName(), map[protoreflect.FullName]bool{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compileAll compile rules of message type named name and messages nested in it,
+// rules are cached by descriptors of registered message types, which are the ones of messages validated.
+func (v *validator) compileAll(name protoreflect.FullName, visited map[protoreflect.FullName]bool) error {
+	if visited[name] {
+		return nil
+	}
+	visited[name] = true
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(name)
+	if err != nil {
+		return nil
+	}
+	rules, err := v.compile(mt.Descriptor())
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		if r.field.Message() != nil && !r.field.IsMap() {
+			if err := v.compileAll(r.field.Message().FullName(), visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *validator) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := v.validate(req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (v *validator) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &validatingServerStream{ServerStream: ss, validator: v})
+}
+
+type validatingServerStream struct {
+	grpc.ServerStream
+	validator *validator
+}
+
+func (s *validatingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.validator.validate(m)
+}
+
+func (v *validator) validate(req interface{}) error {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+	var violations []*errdetails.BadRequest_FieldViolation
+	if err := v.validateMessage(proto.MessageReflect(msg), "", &violations); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	st, err := status.New(codes.InvalidArgument, violations[0].Field+": "+violations[0].Description).
+		WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, violations[0].Field+": "+violations[0].Description)
+	}
+	return st.Err()
+}
+
+func (v *validator) validateMessage(m protoreflect.Message, prefix string, violations *[]*errdetails.BadRequest_FieldViolation) error {
+	rules, err := v.compile(m.Descriptor())
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		fd := r.field
+		path := prefix + string(fd.Name())
+		if r.defaultValue != nil && !m.Has(fd) {
+			m.Set(fd, *r.defaultValue)
+		}
+		if r.rules != nil {
+			for _, desc := range r.check(m) {
+				*violations = append(*violations, &errdetails.BadRequest_FieldViolation{Field: path, Description: desc})
+			}
+		}
+		if fd.Message() == nil || fd.IsMap() || !m.Has(fd) {
+			continue
+		}
+		if fd.IsList() {
+			list := m.Get(fd).List()
+			for i := 0; i < list.Len(); i++ {
+				if err := v.validateMessage(list.Get(i).Message(), fmt.Sprintf("%s[%d].", path, i), violations); err != nil {
+					return err
+				}
+			}
+		} else if err := v.validateMessage(m.Get(fd).Message(), path+".", violations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compile return rules of fields having options or message type, which may have nested rules,
+// invalid pattern or default_value is an error.
+func (v *validator) compile(md protoreflect.MessageDescriptor) ([]*fieldRule, error) {
+	if c, ok := v.rules.Load(md.FullName()); ok {
+		return c.(*compiledRules).rules, c.(*compiledRules).err
+	}
+	rules, err := compileRules(md)
+	v.rules.Store(md.FullName(), &compiledRules{rules: rules, err: err})
+	return rules, err
+}
+
+func compileRules(md protoreflect.MessageDescriptor) ([]*fieldRule, error) {
+	var rules []*fieldRule
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		r := &fieldRule{field: fd}
+		if opts, ok := fd.Options().(proto.Message); ok && opts != nil {
+			if ext, err := proto.GetExtension(opts, thor_proto.E_Rules); err == nil {
+				r.rules = ext.(*thor_proto.FieldRules)
+				if r.rules.Pattern != nil {
+					pattern, err := regexp.Compile(r.rules.GetPattern())
+					if err != nil {
+						return nil, fmt.Errorf("validation: invalid pattern of field %s: %v", fd.FullName(), err)
+					}
+					r.pattern = pattern
+				}
+				if len(r.rules.In) > 0 {
+					r.in = make(map[string]bool, len(r.rules.In))
+					for _, s := range r.rules.In {
+						r.in[s] = true
+					}
+				}
+			}
+			if ext, err := proto.GetExtension(opts, thor_proto.E_DefaultValue); err == nil && fd.Syntax() == protoreflect.Proto2 && !fd.IsList() && !fd.IsMap() {
+				value, ok := parseDefaultValue(fd, *ext.(*string))
+				if !ok {
+					return nil, fmt.Errorf("validation: invalid default_value %q of field %s", *ext.(*string), fd.FullName())
+				}
+				r.defaultValue = &value
+			}
+		}
+		if r.rules != nil || r.defaultValue != nil || (fd.Message() != nil && !fd.IsMap()) {
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+// check return descriptions of rules violated by the field of m.
+func (r *fieldRule) check(m protoreflect.Message) []string {
+	fd, rules := r.field, r.rules
+	var violations []string
+	if !m.Has(fd) {
+		if rules.GetRequired() {
+			violations = append(violations, "is required")
+		}
+		return violations
+	}
+	value := m.Get(fd)
+	switch {
+	case fd.IsList():
+		violations = append(violations, r.checkLen(uint64(value.List().Len()))...)
+		for i := 0; i < value.List().Len(); i++ {
+			violations = append(violations, r.checkScalar(value.List().Get(i))...)
+		}
+	case fd.IsMap():
+		violations = append(violations, r.checkLen(uint64(value.Map().Len()))...)
+	default:
+		violations = append(violations, r.checkScalar(value)...)
+	}
+	return violations
+}
+
+func (r *fieldRule) checkScalar(value protoreflect.Value) []string {
+	fd, rules := r.field, r.rules
+	var violations []string
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		s := value.String()
+		violations = append(violations, r.checkLen(uint64(utf8.RuneCountInString(s)))...)
+		if r.pattern != nil && !r.pattern.MatchString(s) {
+			violations = append(violations, fmt.Sprintf("must match pattern %s", rules.GetPattern()))
+		}
+		if rules.GetEmail() {
+			if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+				violations = append(violations, "must be a valid email address")
+			}
+		}
+		if r.in != nil && !r.in[s] {
+			violations = append(violations, fmt.Sprintf("must be one of %v", rules.In))
+		}
+	case protoreflect.BytesKind:
+		violations = append(violations, r.checkLen(uint64(len(value.Bytes())))...)
+	case protoreflect.EnumKind:
+		if r.in != nil {
+			name := strconv.Itoa(int(value.Enum()))
+			if ev := fd.Enum().Values().ByNumber(value.Enum()); ev != nil {
+				name = string(ev.Name())
+			}
+			if !r.in[name] {
+				violations = append(violations, fmt.Sprintf("must be one of %v", rules.In))
+			}
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		violations = append(violations, r.checkIntRange(value.Int())...)
+		violations = append(violations, r.checkIn(value)...)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		violations = append(violations, r.checkUintRange(value.Uint())...)
+		violations = append(violations, r.checkIn(value)...)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		violations = append(violations, r.checkRange(value.Float())...)
+		violations = append(violations, r.checkIn(value)...)
+	}
+	return violations
+}
+
+// maxInt64Float and maxUint64Float are 2^63 and 2^64, the smallest float64 out of range of the integers.
+const (
+	maxInt64Float  = float64(1 << 63)
+	maxUint64Float = float64(1 << 64)
+)
+
+func (r *fieldRule) checkRange(n float64) []string {
+	var violations []string
+	if r.rules.Min != nil && n < r.rules.GetMin() {
+		violations = append(violations, fmt.Sprintf("must be greater than or equal to %v", r.rules.GetMin()))
+	}
+	if r.rules.Max != nil && n > r.rules.GetMax() {
+		violations = append(violations, fmt.Sprintf("must be less than or equal to %v", r.rules.GetMax()))
+	}
+	return violations
+}
+
+// checkIntRange compare in int64, since float64 loses precision of integers beyond 2^53,
+// bounds are rounded inward to integers, and bounds out of int64 range allow or reject every value.
+func (r *fieldRule) checkIntRange(n int64) []string {
+	var violations []string
+	if r.rules.Min != nil {
+		min := math.Ceil(r.rules.GetMin())
+		if min >= maxInt64Float || min > math.MinInt64 && n < int64(min) {
+			violations = append(violations, fmt.Sprintf("must be greater than or equal to %v", r.rules.GetMin()))
+		}
+	}
+	if r.rules.Max != nil {
+		max := math.Floor(r.rules.GetMax())
+		if max < math.MinInt64 || max < maxInt64Float && n > int64(max) {
+			violations = append(violations, fmt.Sprintf("must be less than or equal to %v", r.rules.GetMax()))
+		}
+	}
+	return violations
+}
+
+// checkUintRange is checkIntRange of uint64.
+func (r *fieldRule) checkUintRange(n uint64) []string {
+	var violations []string
+	if r.rules.Min != nil {
+		min := math.Ceil(r.rules.GetMin())
+		if min >= maxUint64Float || min > 0 && n < uint64(min) {
+			violations = append(violations, fmt.Sprintf("must be greater than or equal to %v", r.rules.GetMin()))
+		}
+	}
+	if r.rules.Max != nil {
+		max := math.Floor(r.rules.GetMax())
+		if max < 0 || max < maxUint64Float && n > uint64(max) {
+			violations = append(violations, fmt.Sprintf("must be less than or equal to %v", r.rules.GetMax()))
+		}
+	}
+	return violations
+}
+
+func (r *fieldRule) checkIn(value protoreflect.Value) []string {
+	if r.in != nil && !r.in[fmt.Sprint(value.Interface())] {
+		return []string{fmt.Sprintf("must be one of %v", r.rules.In)}
+	}
+	return nil
+}
+
+func (r *fieldRule) checkLen(n uint64) []string {
+	var violations []string
+	if r.rules.MinLen != nil && n < r.rules.GetMinLen() {
+		violations = append(violations, fmt.Sprintf("length must be at least %d", r.rules.GetMinLen()))
+	}
+	if r.rules.MaxLen != nil && n > r.rules.GetMaxLen() {
+		violations = append(violations, fmt.Sprintf("length must be at most %d", r.rules.GetMaxLen()))
+	}
+	return violations
+}
+
+// parseDefaultValue parse default_value option by kind of field, enum value can be name or number.
+func parseDefaultValue(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, bool) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), true
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(s)), true
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err == nil
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), true
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), err == nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err == nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(n), err == nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err == nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(n), err == nil
+	case protoreflect.FloatKind:
+		n, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(n)), err == nil
+	case protoreflect.DoubleKind:
+		n, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(n), err == nil
+	}
+	return protoreflect.Value{}, false
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	thor_proto "github.com/go-board/thor/proto"
+)
+
+type testField struct {
+	name         string
+	kind         descriptorpb.FieldDescriptorProto_Type
+	rules        *thor_proto.FieldRules
+	defaultValue *string
+}
+
+// newTestMessage build a proto2 message type of fields with validation options.
+func newTestMessage(t *testing.T, fields ...testField) protoreflect.MessageDescriptor {
+	t.Helper()
+	msg := &descriptorpb.DescriptorProto{Name: proto.String("Request")}
+	for i, f := range fields {
+		opts := &descriptorpb.FieldOptions{}
+		if f.rules != nil {
+			if err := proto.SetExtension(opts, thor_proto.E_Rules, f.rules); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if f.defaultValue != nil {
+			if err := proto.SetExtension(opts, thor_proto.E_DefaultValue, f.defaultValue); err != nil {
+				t.Fatal(err)
+			}
+		}
+		msg.Field = append(msg.Field, &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(f.name),
+			Number:   proto.Int32(int32(i + 1)),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     f.kind.Enum(),
+			JsonName: proto.String(f.name),
+			Options:  opts,
+		})
+	}
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String(t.Name() + ".proto"),
+		Package:     proto.String("thor.test"),
+		Syntax:      proto.String("proto2"),
+		MessageType: []*descriptorpb.DescriptorProto{msg},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fd.Messages().Get(0)
+}
+
+func TestValidationRange(t *testing.T) {
+	md := newTestMessage(t,
+		testField{name: "big", kind: descriptorpb.FieldDescriptorProto_TYPE_INT64, rules: &thor_proto.FieldRules{Max: proto.Float64(10)}},
+		testField{name: "count", kind: descriptorpb.FieldDescriptorProto_TYPE_UINT64, rules: &thor_proto.FieldRules{Min: proto.Float64(5)}},
+		testField{name: "ratio", kind: descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, rules: &thor_proto.FieldRules{Max: proto.Float64(1.5)}},
+		testField{name: "level", kind: descriptorpb.FieldDescriptorProto_TYPE_SINT32, rules: &thor_proto.FieldRules{Min: proto.Float64(-1), In: []string{"-1", "2"}}},
+	)
+	m := dynamicpb.NewMessage(md)
+	m.Set(md.Fields().ByName("big"), protoreflect.ValueOfInt64(1<<62))
+	m.Set(md.Fields().ByName("count"), protoreflect.ValueOfUint64(3))
+	m.Set(md.Fields().ByName("ratio"), protoreflect.ValueOfFloat64(2))
+	m.Set(md.Fields().ByName("level"), protoreflect.ValueOfInt32(-1))
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	if err := newValidator().validateMessage(m, "", &violations); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, v := range violations {
+		got[v.Field] = v.Description
+	}
+	want := map[string]string{
+		"big":   "must be less than or equal to 10",
+		"count": "must be greater than or equal to 5",
+		"ratio": "must be less than or equal to 1.5",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("violations = %v, want %v", got, want)
+	}
+	for field, desc := range want {
+		if got[field] != desc {
+			t.Errorf("violation of %s = %q, want %q", field, got[field], desc)
+		}
+	}
+}
+
+func TestValidationIntegerRange(t *testing.T) {
+	md := newTestMessage(t,
+		testField{name: "id", kind: descriptorpb.FieldDescriptorProto_TYPE_INT64, rules: &thor_proto.FieldRules{Max: proto.Float64(1 << 53)}},
+		testField{name: "seq", kind: descriptorpb.FieldDescriptorProto_TYPE_UINT64, rules: &thor_proto.FieldRules{Max: proto.Float64(1 << 64)}},
+		testField{name: "offset", kind: descriptorpb.FieldDescriptorProto_TYPE_SINT64, rules: &thor_proto.FieldRules{Min: proto.Float64(-1.5), Max: proto.Float64(2.5)}},
+		testField{name: "none", kind: descriptorpb.FieldDescriptorProto_TYPE_UINT32, rules: &thor_proto.FieldRules{Max: proto.Float64(-1)}},
+	)
+	tests := []struct {
+		field string
+		value protoreflect.Value
+		want  string
+	}{
+		// 2^53+1 is rounded to 2^53 as float64.
+		{"id", protoreflect.ValueOfInt64(1<<53 + 1), "must be less than or equal to 9.007199254740992e+15"},
+		{"id", protoreflect.ValueOfInt64(1 << 53), ""},
+		{"seq", protoreflect.ValueOfUint64(1<<64 - 1), ""},
+		{"offset", protoreflect.ValueOfInt64(-1), ""},
+		{"offset", protoreflect.ValueOfInt64(-2), "must be greater than or equal to -1.5"},
+		{"offset", protoreflect.ValueOfInt64(2), ""},
+		{"offset", protoreflect.ValueOfInt64(3), "must be less than or equal to 2.5"},
+		{"none", protoreflect.ValueOfUint32(0), "must be less than or equal to -1"},
+	}
+	for _, test := range tests {
+		m := dynamicpb.NewMessage(md)
+		m.Set(md.Fields().ByName(protoreflect.Name(test.field)), test.value)
+		var violations []*errdetails.BadRequest_FieldViolation
+		if err := newValidator().validateMessage(m, "", &violations); err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if len(violations) > 0 {
+			got = violations[0].Description
+		}
+		if len(violations) > 1 || got != test.want {
+			t.Errorf("violations of %s = %v = %v, want %q", test.field, test.value, violations, test.want)
+		}
+	}
+}
+
+func TestValidationCompileError(t *testing.T) {
+	for name, field := range map[string]testField{
+		"pattern":       {name: "name", kind: descriptorpb.FieldDescriptorProto_TYPE_STRING, rules: &thor_proto.FieldRules{Pattern: proto.String("[a-")}},
+		"default_value": {name: "size", kind: descriptorpb.FieldDescriptorProto_TYPE_INT32, defaultValue: proto.String("ten")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := dynamicpb.NewMessage(newTestMessage(t, field))
+			v := newValidator()
+			var violations []*errdetails.BadRequest_FieldViolation
+			err := v.validateMessage(m, "", &violations)
+			if err == nil || !strings.Contains(err.Error(), "thor.test.Request."+field.name) {
+				t.Fatalf("err = %v, want error of field %s", err, field.name)
+			}
+			// the error is cached, rules are not compiled again.
+			if _, again := v.compile(m.Descriptor()); again != err {
+				t.Errorf("compile again = %v, want %v", again, err)
+			}
+		})
+	}
+}
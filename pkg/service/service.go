@@ -0,0 +1,80 @@
+// Package service hold metadata of methods of services, which is generated by protoc-gen-thor
+// and registered by init of generated code.
+package service
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// StreamKind is the streaming kind of method.
+type StreamKind int
+
+const (
+	Unary StreamKind = iota
+	ClientStream
+	ServerStream
+	BidiStream
+)
+
+func (k StreamKind) String() string {
+	switch k {
+	case Unary:
+		return "unary"
+	case ClientStream:
+		return "client_stream"
+	case ServerStream:
+		return "server_stream"
+	case BidiStream:
+		return "bidi_stream"
+	}
+	return "unknown"
+}
+
+// Method is metadata of a method.
+type Method struct {
+	// FullName is like `/pkg.Service/Method`.
+	FullName string
+	Name     string
+	Kind     StreamKind
+	// Idempotent is declared by `policy` method option or `is_idempotent` option of request message.
+	Idempotent bool
+}
+
+var (
+	mu      sync.RWMutex
+	methods = make(map[string]Method)
+)
+
+// Register methods of a service, it's called by generated code.
+func Register(ms ...Method) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, m := range ms {
+		methods[m.FullName] = m
+	}
+}
+
+// Lookup return metadata of full method like `/pkg.Service/Method`.
+func Lookup(fullMethod string) (Method, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	m, ok := methods[fullMethod]
+	return m, ok
+}
+
+// Methods return registered methods of service by full name like `pkg.Service`, sorted by name.
+func Methods(service string) []Method {
+	prefix := "/" + service + "/"
+	mu.RLock()
+	defer mu.RUnlock()
+	var ms []Method
+	for name, m := range methods {
+		if strings.HasPrefix(name, prefix) {
+			ms = append(ms, m)
+		}
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].FullName < ms[j].FullName })
+	return ms
+}
@@ -0,0 +1,150 @@
+package slo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-board/x-go/xnet/xhttp"
+)
+
+type Kind string
+
+const (
+	KindAvailability Kind = "availability"
+	KindLatency      Kind = "latency"
+)
+
+type Protocol string
+
+const (
+	ProtocolGRPC Protocol = "grpc"
+	ProtocolHTTP Protocol = "http"
+)
+
+// Objective declares a service level objective of a single grpc method or http route.
+type Objective struct {
+	Name     string   `yaml:"name" json:"name"`
+	Kind     Kind     `yaml:"kind" json:"kind"`
+	Protocol Protocol `yaml:"protocol" json:"protocol"`
+	// Target is the full method name like `/pkg.Service/Method` for grpc,
+	// method can be `*` to match all methods of the service.
+	// For http, it's the request method and the route pattern, like `GET /users/:id`.
+	Target string `yaml:"target" json:"target"`
+	// Goal is the ratio of good events, e.g. 0.999.
+	Goal float64 `yaml:"goal" json:"goal"`
+	// Threshold is the latency under which an event is considered good, only used by latency objective.
+	// Latency is read from histogram, so the largest bucket not greater than threshold is used.
+	Threshold time.Duration `yaml:"threshold" json:"threshold"`
+}
+
+func (o Objective) validate() error {
+	if o.Name == "" {
+		return errors.New("err: slo objective name is empty")
+	}
+	if o.Goal <= 0 || o.Goal >= 1 {
+		return fmt.Errorf("err: slo objective %s goal must between 0 and 1", o.Name)
+	}
+	switch o.Kind {
+	case KindAvailability:
+	case KindLatency:
+		if o.Threshold <= 0 {
+			return fmt.Errorf("err: slo objective %s latency threshold must be positive", o.Name)
+		}
+	default:
+		return fmt.Errorf("err: slo objective %s has unknown kind %q", o.Name, o.Kind)
+	}
+	switch o.Protocol {
+	case ProtocolGRPC:
+		if _, _, ok := splitGrpcTarget(o.Target); !ok {
+			return fmt.Errorf("err: slo objective %s has invalid grpc target %q", o.Name, o.Target)
+		}
+	case ProtocolHTTP:
+		if _, _, ok := splitHttpTarget(o.Target); !ok {
+			return fmt.Errorf("err: slo objective %s has invalid http target %q", o.Name, o.Target)
+		}
+	default:
+		return fmt.Errorf("err: slo objective %s has unknown protocol %q", o.Name, o.Protocol)
+	}
+	return nil
+}
+
+func splitGrpcTarget(target string) (service string, method string, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(target, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func splitHttpTarget(target string) (method string, route string, ok bool) {
+	parts := strings.Fields(target)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return strings.ToUpper(parts[0]), parts[1], true
+}
+
+// Window is a multi-window burn rate alert condition,
+// it fires when burn rate of both long and short window exceed the factor.
+type Window struct {
+	Long   time.Duration `yaml:"long" json:"long"`
+	Short  time.Duration `yaml:"short" json:"short"`
+	Factor float64       `yaml:"factor" json:"factor"`
+}
+
+func (w Window) String() string { return fmt.Sprintf("%s/%s", w.Long, w.Short) }
+
+// DefaultWindows is the multi-window, multi-burn-rate alert conditions recommended by the SRE workbook.
+var DefaultWindows = []Window{
+	{Long: time.Hour, Short: 5 * time.Minute, Factor: 14.4},
+	{Long: 6 * time.Hour, Short: 30 * time.Minute, Factor: 6},
+	{Long: 72 * time.Hour, Short: 6 * time.Hour, Factor: 1},
+}
+
+// Report is the current state of an objective.
+type Report struct {
+	Objective Objective `json:"objective"`
+	// BurnRates is burn rate keyed by window duration.
+	BurnRates map[string]float64 `json:"burn_rates"`
+	// Firing is the windows whose alert condition is met.
+	Firing []Window `json:"firing"`
+	// ErrorBudgetRemaining is the ratio of error budget left in the longest window.
+	ErrorBudgetRemaining float64 `json:"error_budget_remaining"`
+}
+
+var defaultTracker *Tracker
+
+// Initialize create the default tracker with objectives and start sampling in background,
+// it fails if any objective is invalid.
+func Initialize(objectives []Objective, options ...Option) error {
+	if len(objectives) == 0 {
+		return nil
+	}
+	tracker, err := New(objectives, options...)
+	if err != nil {
+		return err
+	}
+	defaultTracker = tracker
+	go tracker.Run(context.Background())
+	return nil
+}
+
+// ServeHTTP write reports of the default tracker as json, it's mounted by thor.Admin.
+func ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if defaultTracker == nil {
+		writeReports(w, []Report{})
+		return
+	}
+	defaultTracker.ServeHTTP(w, r)
+}
+
+func writeReports(w http.ResponseWriter, reports []Report) {
+	w.Header().Set(xhttp.HeaderContentType, xhttp.MIMEApplicationJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(reports)
+}
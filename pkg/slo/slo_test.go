@@ -0,0 +1,207 @@
+package slo
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/go-board/thor/pkg/server"
+	"github.com/go-board/thor/pkg/web"
+)
+
+type testService struct {
+	testpb.UnimplementedTestServiceServer
+}
+
+// UnaryCall fail with status of request if set.
+func (*testService) UnaryCall(ctx context.Context, req *testpb.SimpleRequest) (*testpb.SimpleResponse, error) {
+	if s := req.GetResponseStatus(); s.GetCode() != 0 {
+		return nil, status.Error(codes.Code(s.GetCode()), s.GetMessage())
+	}
+	return &testpb.SimpleResponse{}, nil
+}
+
+func dialTestServer(t *testing.T) testpb.TestServiceClient {
+	ln := bufconn.Listen(1 << 20)
+	s := server.NewServer()
+	testpb.RegisterTestServiceServer(s, &testService{})
+	go func() { _ = s.Serve(ln) }()
+	t.Cleanup(s.Stop)
+	cc, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return ln.Dial()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cc.Close() })
+	return testpb.NewTestServiceClient(cc)
+}
+
+// fakeClock is advanced by tests between samples.
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTracker(t *testing.T, clock *fakeClock, objectives ...Objective) *Tracker {
+	tracker, err := New(objectives, Clock(clock.Now), Windows(Window{Long: time.Hour, Short: 5 * time.Minute, Factor: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Sample(); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	return tracker
+}
+
+func report(t *testing.T, tracker *Tracker, name string) Report {
+	if err := tracker.Sample(); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range tracker.Reports() {
+		if r.Objective.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("report of %s not found", name)
+	return Report{}
+}
+
+func assertBurnRate(t *testing.T, r Report, want float64, firing bool) {
+	t.Helper()
+	if got := r.BurnRates[time.Hour.String()]; math.Abs(got-want) > 1e-6 {
+		t.Errorf("%s burn rate = %v, want %v", r.Objective.Name, got, want)
+	}
+	if got := len(r.Firing) > 0; got != firing {
+		t.Errorf("%s firing = %v, want %v", r.Objective.Name, got, firing)
+	}
+}
+
+func TestGrpcAvailability(t *testing.T) {
+	client := dialTestServer(t)
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	tracker := newTracker(t, clock, Objective{
+		Name:     "grpc-availability",
+		Kind:     KindAvailability,
+		Protocol: ProtocolGRPC,
+		Target:   "/grpc.testing.TestService/UnaryCall",
+		Goal:     0.9,
+	})
+
+	ctx := context.Background()
+	// 2 of 10 calls fail by server fault, caller faults are good events.
+	for i := 0; i < 10; i++ {
+		req := &testpb.SimpleRequest{}
+		switch {
+		case i < 2:
+			req.ResponseStatus = &testpb.EchoStatus{Code: int32(codes.Internal)}
+		case i < 4:
+			req.ResponseStatus = &testpb.EchoStatus{Code: int32(codes.InvalidArgument)}
+		}
+		_, _ = client.UnaryCall(ctx, req)
+	}
+	assertBurnRate(t, report(t, tracker, "grpc-availability"), 2, true)
+
+	clock.Advance(time.Minute)
+	for i := 0; i < 30; i++ {
+		if _, err := client.UnaryCall(ctx, &testpb.SimpleRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := report(t, tracker, "grpc-availability")
+	assertBurnRate(t, r, 0.5, false)
+	if math.Abs(r.ErrorBudgetRemaining-0.5) > 1e-6 {
+		t.Errorf("error budget remaining = %v, want 0.5", r.ErrorBudgetRemaining)
+	}
+}
+
+func TestHttpObjectives(t *testing.T) {
+	s := web.New()
+	s.Get("/slo/items/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path.Base(r.URL.Path) {
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "slow":
+			time.Sleep(time.Millisecond * 20)
+		}
+	}))
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	tracker := newTracker(t, clock, Objective{
+		Name:     "http-availability",
+		Kind:     KindAvailability,
+		Protocol: ProtocolHTTP,
+		Target:   "GET /slo/items/:id",
+		Goal:     0.9,
+	}, Objective{
+		Name:      "http-latency",
+		Kind:      KindLatency,
+		Protocol:  ProtocolHTTP,
+		Target:    "get /slo/items/:id",
+		Goal:      0.8,
+		Threshold: time.Millisecond * 10,
+	})
+
+	for _, id := range []string{"1", "2", "3", "4", "5", "missing", "missing", "broken", "slow", "slow"} {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slo/items/"+id, nil))
+	}
+	// 1 of 10 requests is 5xx, 404 is caller fault.
+	assertBurnRate(t, report(t, tracker, "http-availability"), 1, false)
+	// 2 of 10 requests are slower than threshold.
+	assertBurnRate(t, report(t, tracker, "http-latency"), 1, false)
+}
+
+func TestHttpLatencyThreshold(t *testing.T) {
+	s := web.New()
+	s.Get("/slo/sleep/:ms", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ms, _ := strconv.Atoi(path.Base(r.URL.Path))
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+	}))
+	// 40ms isn't a default bucket, without it the 25ms bucket is used and 30ms requests are counted as bad.
+	tracker := newTracker(t, &fakeClock{now: time.Unix(0, 0)}, Objective{
+		Name:      "http-latency-threshold",
+		Kind:      KindLatency,
+		Protocol:  ProtocolHTTP,
+		Target:    "GET /slo/sleep/:ms",
+		Goal:      0.8,
+		Threshold: time.Millisecond * 40,
+	})
+	for _, ms := range []string{"0", "0", "0", "30", "60"} {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slo/sleep/"+ms, nil))
+	}
+	assertBurnRate(t, report(t, tracker, "http-latency-threshold"), 1, false)
+}
+
+func TestInitialize(t *testing.T) {
+	if err := Initialize([]Objective{{Name: "invalid", Kind: KindAvailability, Protocol: ProtocolGRPC, Goal: 0.9}}); err == nil {
+		t.Error("initialize with invalid objective succeeded")
+	}
+}
+
+func TestObjectiveValidation(t *testing.T) {
+	for _, o := range []Objective{
+		{Name: "", Kind: KindAvailability, Protocol: ProtocolGRPC, Target: "/a.B/C", Goal: 0.9},
+		{Name: "goal", Kind: KindAvailability, Protocol: ProtocolGRPC, Target: "/a.B/C", Goal: 1},
+		{Name: "threshold", Kind: KindLatency, Protocol: ProtocolGRPC, Target: "/a.B/C", Goal: 0.9},
+		{Name: "target", Kind: KindAvailability, Protocol: ProtocolHTTP, Target: "/users", Goal: 0.9},
+	} {
+		if _, err := New([]Objective{o}); err == nil {
+			t.Errorf("objective %q should be invalid", o.Name)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package slo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/go-board/thor/pkg/metric"
+	"github.com/go-board/thor/pkg/web"
+)
+
+// serverErrorCodes is the grpc codes that considered as server fault, others are caller fault.
+var serverErrorCodes = map[string]bool{
+	codes.Unknown.String():          true,
+	codes.DeadlineExceeded.String(): true,
+	codes.Unimplemented.String():    true,
+	codes.Internal.String():         true,
+	codes.Unavailable.String():      true,
+	codes.DataLoss.String():         true,
+}
+
+var (
+	burnRateGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "slo_burn_rate",
+		Help: "Error budget burn rate of service level objective over window.",
+	}, []string{"slo", "window"})
+	errorBudgetGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "slo_error_budget_remaining",
+		Help: "Ratio of error budget remaining in the longest window.",
+	}, []string{"slo"})
+)
+
+type Option func(t *Tracker)
+
+// Gatherer set where metrics read from, default read from grpc_prometheus.DefaultServerMetrics and web.DefaultServerMetrics.
+func Gatherer(g prometheus.Gatherer) Option {
+	return func(t *Tracker) {
+		t.gatherer = g
+	}
+}
+
+// Windows set the multi-window alert conditions, default is DefaultWindows.
+func Windows(windows ...Window) Option {
+	return func(t *Tracker) {
+		t.windows = windows
+	}
+}
+
+// Interval set how often metrics sampled, default is 10 seconds.
+func Interval(d time.Duration) Option {
+	return func(t *Tracker) {
+		t.interval = d
+	}
+}
+
+// Clock set the time source of tracker.
+func Clock(now func() time.Time) Option {
+	return func(t *Tracker) {
+		t.now = now
+	}
+}
+
+type sample struct {
+	at    time.Time
+	total float64
+	bad   float64
+}
+
+// Tracker sample request metrics periodically and compute burn rate of objectives.
+type Tracker struct {
+	objectives []Objective
+	windows    []Window
+	gatherer   prometheus.Gatherer
+	interval   time.Duration
+	now        func() time.Time
+	retention  time.Duration
+
+	mu      sync.RWMutex
+	samples map[string][]sample
+}
+
+func New(objectives []Objective, options ...Option) (*Tracker, error) {
+	names := make(map[string]bool, len(objectives))
+	for _, o := range objectives {
+		if err := o.validate(); err != nil {
+			return nil, err
+		}
+		if names[o.Name] {
+			return nil, fmt.Errorf("err: duplicated slo objective %s", o.Name)
+		}
+		names[o.Name] = true
+	}
+	t := &Tracker{
+		objectives: objectives,
+		windows:    DefaultWindows,
+		interval:   time.Second * 10,
+		now:        time.Now,
+		samples:    make(map[string][]sample, len(objectives)),
+	}
+	for _, option := range options {
+		option(t)
+	}
+	if t.gatherer == nil {
+		t.gatherer = defaultGatherer(objectives)
+	}
+	for _, w := range t.windows {
+		if w.Long > t.retention {
+			t.retention = w.Long
+		}
+	}
+	metric.MustRegister(burnRateGauge, errorBudgetGauge)
+	return t, nil
+}
+
+// defaultGatherer read metrics from grpc and web default server metrics,
+// thresholds of latency objectives are added to buckets of handling time histograms,
+// and handling time histogram of grpc is enabled if there is any grpc latency objective.
+func defaultGatherer(objectives []Objective) prometheus.Gatherer {
+	thresholds := make(map[Protocol][]float64)
+	for _, o := range objectives {
+		if o.Kind == KindLatency {
+			thresholds[o.Protocol] = append(thresholds[o.Protocol], o.Threshold.Seconds())
+		}
+	}
+	if len(thresholds[ProtocolGRPC]) > 0 {
+		grpc_prometheus.EnableHandlingTimeHistogram(grpc_prometheus.WithHistogramBuckets(withDefBuckets(thresholds[ProtocolGRPC])))
+	}
+	if len(thresholds[ProtocolHTTP]) > 0 {
+		web.DefaultServerMetrics.SetHistogramBuckets(withDefBuckets(thresholds[ProtocolHTTP]))
+	}
+	r := prometheus.NewRegistry()
+	r.MustRegister(uncheckedCollector{grpc_prometheus.DefaultServerMetrics}, uncheckedCollector{web.DefaultServerMetrics})
+	return r
+}
+
+// withDefBuckets return thresholds merged into prometheus.DefBuckets, sorted and deduplicated.
+func withDefBuckets(thresholds []float64) []float64 {
+	buckets := append(thresholds, prometheus.DefBuckets...)
+	sort.Float64s(buckets)
+	return dedupFloat64s(buckets)
+}
+
+func dedupFloat64s(s []float64) []float64 {
+	out := s[:0]
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// uncheckedCollector skip describe consistency check, because collectors like
+// grpc_prometheus.ServerMetrics change their descriptors once histogram enabled.
+type uncheckedCollector struct{ prometheus.Collector }
+
+func (uncheckedCollector) Describe(chan<- *prometheus.Desc) {}
+
+// Run sample metrics every interval until ctx is done.
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		if err := t.Sample(); err != nil {
+			zap.L().Warn("sample slo metrics failed", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sample read the current metrics and update burn rate gauges.
+func (t *Tracker) Sample() error {
+	families, err := t.gatherer.Gather()
+	if err != nil {
+		return err
+	}
+	now := t.now()
+	t.mu.Lock()
+	for _, o := range t.objectives {
+		total, bad := count(o, families)
+		samples := append(t.samples[o.Name], sample{at: now, total: total, bad: bad})
+		// keep one sample older than retention, so the longest window is always covered.
+		i := 0
+		for i < len(samples)-1 && !samples[i+1].at.After(now.Add(-t.retention)) {
+			i++
+		}
+		t.samples[o.Name] = samples[i:]
+	}
+	t.mu.Unlock()
+
+	for _, r := range t.Reports() {
+		for window, rate := range r.BurnRates {
+			burnRateGauge.WithLabelValues(r.Objective.Name, window).Set(rate)
+		}
+		errorBudgetGauge.WithLabelValues(r.Objective.Name).Set(r.ErrorBudgetRemaining)
+	}
+	return nil
+}
+
+// Reports return current state of all objectives.
+func (t *Tracker) Reports() []Report {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	reports := make([]Report, 0, len(t.objectives))
+	for _, o := range t.objectives {
+		samples := t.samples[o.Name]
+		r := Report{Objective: o, BurnRates: map[string]float64{}, Firing: []Window{}}
+		for _, w := range t.windows {
+			long := burnRate(o, samples, w.Long)
+			short := burnRate(o, samples, w.Short)
+			r.BurnRates[w.Long.String()] = long
+			r.BurnRates[w.Short.String()] = short
+			if long >= w.Factor && short >= w.Factor {
+				r.Firing = append(r.Firing, w)
+			}
+		}
+		r.ErrorBudgetRemaining = 1 - burnRate(o, samples, t.retention)
+		reports = append(reports, r)
+	}
+	return reports
+}
+
+func (t *Tracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeReports(w, t.Reports())
+}
+
+// burnRate is the error ratio in the window divided by the error budget ratio.
+func burnRate(o Objective, samples []sample, window time.Duration) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	last := samples[len(samples)-1]
+	base := samples[0]
+	for _, s := range samples {
+		if s.at.After(last.at.Add(-window)) {
+			break
+		}
+		base = s
+	}
+	total, bad := last.total-base.total, last.bad-base.bad
+	if total < 0 || bad < 0 {
+		// counter reset, e.g. metrics collector replaced.
+		total, bad = last.total, last.bad
+	}
+	if total <= 0 {
+		return 0
+	}
+	return bad / total / (1 - o.Goal)
+}
+
+// count return the cumulative total and bad events of the objective.
+func count(o Objective, families []*dto.MetricFamily) (total float64, bad float64) {
+	for _, f := range families {
+		switch {
+		case o.Protocol == ProtocolGRPC && o.Kind == KindAvailability && f.GetName() == "grpc_server_handled_total":
+			for _, m := range f.GetMetric() {
+				if matchGrpc(o, m) {
+					total += m.GetCounter().GetValue()
+					if serverErrorCodes[label(m, "grpc_code")] {
+						bad += m.GetCounter().GetValue()
+					}
+				}
+			}
+		case o.Protocol == ProtocolGRPC && o.Kind == KindLatency && f.GetName() == "grpc_server_handling_seconds":
+			for _, m := range f.GetMetric() {
+				if matchGrpc(o, m) {
+					t, b := slowCount(o, m.GetHistogram())
+					total, bad = total+t, bad+b
+				}
+			}
+		case o.Protocol == ProtocolHTTP && o.Kind == KindAvailability && f.GetName() == "http_server_handled_total":
+			for _, m := range f.GetMetric() {
+				if matchHttp(o, m) {
+					total += m.GetCounter().GetValue()
+					if code, _ := strconv.Atoi(label(m, "http_code")); code >= http.StatusInternalServerError {
+						bad += m.GetCounter().GetValue()
+					}
+				}
+			}
+		case o.Protocol == ProtocolHTTP && o.Kind == KindLatency && f.GetName() == "http_server_handling_seconds":
+			for _, m := range f.GetMetric() {
+				if matchHttp(o, m) {
+					t, b := slowCount(o, m.GetHistogram())
+					total, bad = total+t, bad+b
+				}
+			}
+		}
+	}
+	return total, bad
+}
+
+func slowCount(o Objective, h *dto.Histogram) (total float64, bad float64) {
+	total = float64(h.GetSampleCount())
+	good := 0.0
+	for _, b := range h.GetBucket() {
+		if b.GetUpperBound() > o.Threshold.Seconds() {
+			break
+		}
+		good = float64(b.GetCumulativeCount())
+	}
+	return total, total - good
+}
+
+func matchGrpc(o Objective, m *dto.Metric) bool {
+	service, method, _ := splitGrpcTarget(o.Target)
+	return label(m, "grpc_service") == service && (method == "*" || label(m, "grpc_method") == method)
+}
+
+func matchHttp(o Objective, m *dto.Metric) bool {
+	method, route, _ := splitHttpTarget(o.Target)
+	return label(m, "http_method") == method && label(m, "http_route") == route
+}
+
+func label(m *dto.Metric, name string) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,30 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-board/x-go/xnet/xhttp"
+
+	"github.com/go-board/thor/pkg/fault"
+)
+
+// FaultMiddleware inject faults by rules of i, aborted requests panic with http.ErrAbortHandler,
+// which drops the connection.
+func FaultMiddleware(i *fault.Injector) xhttp.Middleware {
+	return xhttp.MiddlewareFn(func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			f := i.InjectHTTP(request)
+			if f.Abort {
+				panic(http.ErrAbortHandler)
+			}
+			if f.Err != nil {
+				writer.Header().Set(xhttp.HeaderContentType, xhttp.MIMEApplicationJSON)
+				writer.WriteHeader(f.Status)
+				_ = json.NewEncoder(writer).Encode(f.Err.Body())
+				return
+			}
+			h.ServeHTTP(writer, request)
+		})
+	})
+}
@@ -0,0 +1,91 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultServerMetrics is the http server metrics shared by all web.Server instances.
+var DefaultServerMetrics = NewServerMetrics()
+
+// ServerMetrics represents a collection of metrics for http server,
+// it's the http counterpart of grpc_prometheus.ServerMetrics.
+type ServerMetrics struct {
+	serverHandledCounter   *prometheus.CounterVec
+	serverHandledHistogram *prometheus.HistogramVec
+}
+
+func NewServerMetrics() *ServerMetrics {
+	return &ServerMetrics{
+		serverHandledCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_server_handled_total",
+				Help: "Total number of http requests completed on the server, regardless of success or failure.",
+			}, []string{"http_method", "http_route", "http_code"}),
+		serverHandledHistogram: newHandledHistogram(prometheus.DefBuckets),
+	}
+}
+
+func newHandledHistogram(buckets []float64) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_server_handling_seconds",
+			Help:    "Histogram of response latency (seconds) of http requests that had been handled by the server.",
+			Buckets: buckets,
+		}, []string{"http_method", "http_route"})
+}
+
+func (m *ServerMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.serverHandledCounter.Describe(ch)
+	m.serverHandledHistogram.Describe(ch)
+}
+
+func (m *ServerMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.serverHandledCounter.Collect(ch)
+	m.serverHandledHistogram.Collect(ch)
+}
+
+// SetHistogramBuckets replace handling time histogram with one of buckets, observations before are dropped,
+// it must be called before serving, like grpc_prometheus.EnableHandlingTimeHistogram.
+func (m *ServerMetrics) SetHistogramBuckets(buckets []float64) {
+	m.serverHandledHistogram = newHandledHistogram(buckets)
+}
+
+// Handler wrap h and record metrics labeled by method and route pattern.
+func (m *ServerMetrics) Handler(method string, route string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(rw, r)
+		m.serverHandledCounter.WithLabelValues(method, route, strconv.Itoa(rw.status)).Inc()
+		m.serverHandledHistogram.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
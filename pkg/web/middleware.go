@@ -2,6 +2,7 @@ package web
 
 import (
 	"net/http"
+	"runtime"
 
 	"github.com/go-board/x-go/xctx"
 	"github.com/go-board/x-go/xnet/xhttp"
@@ -35,3 +36,22 @@ func injectDataToRequest(r *http.Request, data interface{}) *http.Request {
 	ctx.With(data)
 	return r.WithContext(ctx)
 }
+
+// ErrorMiddleware recover handler panicked with error and write it by WriteErr,
+// other panics, runtime errors like nil dereference and http.ErrAbortHandler are re-panicked.
+func ErrorMiddleware() xhttp.Middleware {
+	return xhttp.MiddlewareFn(func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			defer func() {
+				if r := recover(); r != nil {
+					err, ok := r.(error)
+					if _, isRuntime := r.(runtime.Error); !ok || isRuntime || err == http.ErrAbortHandler {
+						panic(r)
+					}
+					_ = WriteErr(writer, err)
+				}
+			}()
+			h.ServeHTTP(writer, request)
+		})
+	})
+}
@@ -0,0 +1,34 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/go-board/thor/pkg/errors"
+)
+
+func TestErrorMiddleware(t *testing.T) {
+	serve := func(p interface{}) (code int, repanicked interface{}) {
+		h := ErrorMiddleware().Next(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic(p) }))
+		w := httptest.NewRecorder()
+		defer func() { repanicked = recover() }()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code, nil
+	}
+	if code, r := serve(errors.New(codes.NotFound, "", "not found")); r != nil || code != http.StatusNotFound {
+		t.Errorf("panic with error: status = %d, re-panicked %v", code, r)
+	}
+	var m map[string]int
+	for _, p := range []interface{}{"oops", http.ErrAbortHandler, func() (err error) {
+		defer func() { err = recover().(error) }()
+		m["nil"]++
+		return nil
+	}()} {
+		if _, r := serve(p); r != p {
+			t.Errorf("panic with %v: re-panicked %v", p, r)
+		}
+	}
+}
@@ -5,9 +5,24 @@ import (
 	"net/http"
 
 	"github.com/go-board/x-go/xnet/xhttp"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/go-board/thor/pkg/errors"
+	"github.com/go-board/thor/pkg/pbjson"
 )
 
+// WriteJson write v as JSON, proto messages are marshaled by pbjson so that json options of fields are honored.
 func WriteJson(w http.ResponseWriter, v interface{}) error {
+	if m, ok := v.(proto.Message); ok {
+		b, err := pbjson.Marshal(m)
+		if err != nil {
+			return err
+		}
+		w.Header().Set(xhttp.HeaderContentType, xhttp.MIMEApplicationJSON)
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(append(b, '\n'))
+		return err
+	}
 	w.Header().Set(xhttp.HeaderContentType, xhttp.MIMEApplicationJSON)
 	w.WriteHeader(http.StatusOK)
 	return json.NewEncoder(w).Encode(v)
@@ -18,6 +33,24 @@ func WriteNoContent(w http.ResponseWriter) {
 }
 
 func WriteError(w http.ResponseWriter, httpStatus int, code int, msg string) error {
+	w.Header().Set(xhttp.HeaderContentType, xhttp.MIMEApplicationJSON)
 	w.WriteHeader(httpStatus)
 	return json.NewEncoder(w).Encode(map[string]interface{}{"code": code, "msg": msg})
 }
+
+// WriteErr convert err to errors.Error, and write it with mapped http status.
+func WriteErr(w http.ResponseWriter, err error) error {
+	e := errors.FromError(err)
+	w.Header().Set(xhttp.HeaderContentType, xhttp.MIMEApplicationJSON)
+	w.WriteHeader(e.HTTPStatus())
+	return json.NewEncoder(w).Encode(e.Body())
+}
+
+// HandlerFunc is http handler return error, which is written by WriteErr.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f(w, r); err != nil {
+		_ = WriteErr(w, err)
+	}
+}
@@ -0,0 +1,23 @@
+package web
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+)
+
+func TestWriteJsonProto(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := WriteJson(w, &testpb.SimpleRequest{ResponseSize: 1, FillUsername: true}); err != nil {
+		t.Fatal(err)
+	}
+	// proto messages are encoded by pbjson, which names fields in lowerCamelCase.
+	if got, want := strings.TrimSpace(w.Body.String()), `{"responseSize":1,"fillUsername":true}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+		t.Errorf("content type = %s, want application/json", got)
+	}
+}
@@ -2,18 +2,32 @@ package web
 
 import (
 	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/go-board/x-go/xctx"
 	"github.com/go-board/x-go/xnet/xhttp"
 	"github.com/julienschmidt/httprouter"
+
+	"github.com/go-board/thor/pkg/metric"
+	"github.com/go-board/thor/pkg/openapi"
 )
 
+// Router register handlers, it's implemented by Server and Route.
+type Router interface {
+	Handle(method string, path string, h http.Handler, middlewares ...xhttp.Middleware)
+}
+
 type Server struct {
 	router      *httprouter.Router
 	middlewares []xhttp.Middleware
+
+	mu     sync.Mutex
+	routes [][2]string // method and path of registered handlers
 }
 
 func New(middlewares ...xhttp.Middleware) *Server {
+	metric.MustRegister(DefaultServerMetrics)
 	return &Server{
 		router:      httprouter.New(),
 		middlewares: middlewares,
@@ -24,23 +38,33 @@ func (s *Server) Run(addr string) error {
 	return http.ListenAndServe(addr, s.router)
 }
 
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
 func (s *Server) Group(path string, middlewares ...xhttp.Middleware) *Route {
-	newMiddlewares := make([]xhttp.Middleware, len(s.middlewares)+len(middlewares))
-	copy(newMiddlewares, s.middlewares)
-	copy(newMiddlewares[len(s.middlewares):], middlewares)
 	return &Route{
 		s:           s,
 		path:        path,
-		middlewares: newMiddlewares,
+		middlewares: concatMiddlewares(s.middlewares, middlewares),
 	}
 }
 
 func (s *Server) Handle(method string, path string, h http.Handler, middlewares ...xhttp.Middleware) {
+	s.handle(method, path, h, concatMiddlewares(s.middlewares, middlewares))
+}
+
+// handle register h composed with middlewares, middlewares of server aren't added.
+func (s *Server) handle(method string, path string, h http.Handler, middlewares []xhttp.Middleware) {
+	s.mu.Lock()
+	s.routes = append(s.routes, [2]string{method, path})
+	s.mu.Unlock()
+	h = xhttp.ComposeMiddleware(h, middlewares...)
+	h = DefaultServerMetrics.Handler(method, path, h)
 	s.router.Handle(method, path, func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 		ctx := xctx.NewTyped(request.Context())
 		ctx.With(params)
 		request = request.WithContext(ctx)
-		h = xhttp.ComposeMiddleware(h, append(s.middlewares, middlewares...)...)
 		h.ServeHTTP(writer, request)
 	})
 }
@@ -74,6 +98,33 @@ func (s *Server) Connect(path string, h http.Handler, middlewares ...xhttp.Middl
 	s.Handle(http.MethodConnect, path, h, middlewares...)
 }
 
+// Docs serve OpenAPI document merged from documents generated by protoc-gen-thor and routes of s
+// at path + `/openapi.json`, and a self-contained docs page rendering it at path.
+// Documented operations are moved under prefix of group they are registered to,
+// and routes not documented are added without schema.
+func (s *Server) Docs(path string, title string, middlewares ...xhttp.Middleware) {
+	spec := path + "/openapi.json"
+	s.Get(spec, HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		doc, err := openapi.Merged(title, "1.0")
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		routes := append([][2]string{}, s.routes...)
+		s.mu.Unlock()
+		for _, route := range routes {
+			if route[1] != path && !strings.HasPrefix(route[1], path+"/") {
+				doc.AddRoute(route[0], route[1])
+			}
+		}
+		return WriteJson(w, doc)
+	}), middlewares...)
+	s.Get(path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(xhttp.HeaderContentType, xhttp.MIMETextHTMLCharsetUTF8)
+		_, _ = w.Write(openapi.Page(title, spec))
+	}), middlewares...)
+}
+
 type Route struct {
 	s           *Server
 	path        string
@@ -81,52 +132,63 @@ type Route struct {
 }
 
 func (r *Route) Group(path string, middlewares ...xhttp.Middleware) *Route {
-	newMiddlewares := make([]xhttp.Middleware, len(r.middlewares)+len(middlewares))
-	copy(newMiddlewares, r.middlewares)
-	copy(newMiddlewares[len(r.middlewares):], middlewares)
 	return &Route{
 		s:           r.s,
 		path:        r.path + path,
-		middlewares: newMiddlewares,
+		middlewares: concatMiddlewares(r.middlewares, middlewares),
 	}
 }
 
+// Handle register h at path under the route, composed with middlewares of the route.
 func (r *Route) Handle(method string, path string, h http.Handler, middlewares ...xhttp.Middleware) {
-	r.s.Handle(method, path, h, middlewares...)
+	r.s.handle(method, r.path+path, h, concatMiddlewares(r.middlewares, middlewares))
 }
 
 func (r *Route) Get(path string, h http.Handler, middlewares ...xhttp.Middleware) {
-	r.s.Handle(http.MethodGet, path, h, middlewares...)
+	r.Handle(http.MethodGet, path, h, middlewares...)
 }
 
 func (r *Route) Post(path string, h http.Handler, middlewares ...xhttp.Middleware) {
-	r.s.Handle(http.MethodPost, path, h, middlewares...)
+	r.Handle(http.MethodPost, path, h, middlewares...)
 }
 
 func (r *Route) Put(path string, h http.Handler, middlewares ...xhttp.Middleware) {
-	r.s.Handle(http.MethodPut, path, h, middlewares...)
+	r.Handle(http.MethodPut, path, h, middlewares...)
 }
 
 func (r *Route) Delete(path string, h http.Handler, middlewares ...xhttp.Middleware) {
-	r.s.Handle(http.MethodDelete, path, h, middlewares...)
+	r.Handle(http.MethodDelete, path, h, middlewares...)
 }
 
 func (r *Route) Patch(path string, h http.Handler, middlewares ...xhttp.Middleware) {
-	r.s.Handle(http.MethodPatch, path, h, middlewares...)
+	r.Handle(http.MethodPatch, path, h, middlewares...)
 }
 
 func (r *Route) Head(path string, h http.Handler, middlewares ...xhttp.Middleware) {
-	r.s.Handle(http.MethodHead, path, h, middlewares...)
+	r.Handle(http.MethodHead, path, h, middlewares...)
 }
 
 func (r *Route) Options(path string, h http.Handler, middlewares ...xhttp.Middleware) {
-	r.s.Handle(http.MethodOptions, path, h, middlewares...)
+	r.Handle(http.MethodOptions, path, h, middlewares...)
 }
 
 func (r *Route) Trace(path string, h http.Handler, middlewares ...xhttp.Middleware) {
-	r.s.Handle(http.MethodTrace, path, h, middlewares...)
+	r.Handle(http.MethodTrace, path, h, middlewares...)
 }
 
 func (r *Route) Connect(path string, h http.Handler, middlewares ...xhttp.Middleware) {
-	r.s.Handle(http.MethodConnect, path, h, middlewares...)
+	r.Handle(http.MethodConnect, path, h, middlewares...)
+}
+
+// PathParams return path params of request routed by Server.
+func PathParams(r *http.Request) httprouter.Params {
+	params, _ := r.Context().Value(xctx.TypeName(httprouter.Params{})).(httprouter.Params)
+	return params
+}
+
+func concatMiddlewares(a []xhttp.Middleware, b []xhttp.Middleware) []xhttp.Middleware {
+	middlewares := make([]xhttp.Middleware, len(a)+len(b))
+	copy(middlewares, a)
+	copy(middlewares[len(a):], b)
+	return middlewares
 }
@@ -0,0 +1,60 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-board/thor/pkg/openapi"
+)
+
+func TestDocs(t *testing.T) {
+	doc := openapi.New("", "")
+	doc.AddOperation("GET", "/users/{id}", &openapi.Operation{OperationID: "GetUser"})
+	b, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	openapi.Register("thor/web/docs_test.proto", b)
+	defer openapi.Register("thor/web/docs_test.proto", []byte("{}"))
+
+	s := New()
+	noop := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	s.Group("/api/v1").Handle(http.MethodGet, "/users/:id", noop)
+	s.Post("/files/*path", noop)
+	s.Docs("/docs", "Test API")
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil))
+	var got openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("document = %d %s, err = %v", w.Code, w.Body.String(), err)
+	}
+	if got.Info.Title != "Test API" {
+		t.Errorf("title = %s, want Test API", got.Info.Title)
+	}
+	// documented operation is moved under prefix of group, and routes not documented are added.
+	if op := got.Paths["/api/v1/users/{id}"]["get"]; op == nil || op.OperationID != "GetUser" {
+		t.Errorf("operation = %+v, want GetUser", op)
+	}
+	if op := got.Paths["/files/{path}"]["post"]; op == nil {
+		t.Error("route not documented isn't added")
+	}
+	// routes of docs aren't documented.
+	for path := range got.Paths {
+		if strings.HasPrefix(path, "/docs") {
+			t.Errorf("path %s of docs is documented", path)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if body := w.Body.String(); w.Code != http.StatusOK || !strings.Contains(body, "<title>Test API</title>") || !strings.Contains(body, "openapi.json") {
+		t.Errorf("page = %d %s", w.Code, body)
+	}
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+		t.Errorf("content type = %s, want text/html", got)
+	}
+}
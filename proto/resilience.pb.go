@@ -7,6 +7,7 @@ import (
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
 	descriptor "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	_ "github.com/golang/protobuf/ptypes/struct"
 	math "math"
 )
 
@@ -21,6 +22,265 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type JsonTagMode int32
+
+const (
+	JsonTagMode_CamelCase JsonTagMode = 0
+	JsonTagMode_SnakeCase JsonTagMode = 1
+)
+
+var JsonTagMode_name = map[int32]string{
+	0: "CamelCase",
+	1: "SnakeCase",
+}
+
+var JsonTagMode_value = map[string]int32{
+	"CamelCase": 0,
+	"SnakeCase": 1,
+}
+
+func (x JsonTagMode) Enum() *JsonTagMode {
+	p := new(JsonTagMode)
+	*p = x
+	return p
+}
+
+func (x JsonTagMode) String() string {
+	return proto.EnumName(JsonTagMode_name, int32(x))
+}
+
+func (x *JsonTagMode) UnmarshalJSON(data []byte) error {
+	value, err := proto.UnmarshalJSONEnum(JsonTagMode_value, data, "JsonTagMode")
+	if err != nil {
+		return err
+	}
+	*x = JsonTagMode(value)
+	return nil
+}
+
+func (JsonTagMode) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_33bf56ae47cb0799, []int{0}
+}
+
+// MethodPolicy is the resilience policy of a method, protoc-gen-thor generates it into method table of the service,
+// which is applied by clients automatically.
+type MethodPolicy struct {
+	// idempotent method can be retried and hedged, it defaults to is_idempotent of request message.
+	Idempotent *bool `protobuf:"varint,1,opt,name=idempotent" json:"idempotent,omitempty"`
+	// max_retries after the first attempt, it defaults to max_retries of request message.
+	MaxRetries *int32 `protobuf:"varint,2,opt,name=max_retries,json=maxRetries" json:"max_retries,omitempty"`
+	// timeout_ms bound calls without shorter deadline, in milliseconds.
+	TimeoutMs            *uint32      `protobuf:"varint,3,opt,name=timeout_ms,json=timeoutMs" json:"timeout_ms,omitempty"`
+	Hedge                *HedgePolicy `protobuf:"bytes,4,opt,name=hedge" json:"hedge,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *MethodPolicy) Reset()         { *m = MethodPolicy{} }
+func (m *MethodPolicy) String() string { return proto.CompactTextString(m) }
+func (*MethodPolicy) ProtoMessage()    {}
+func (*MethodPolicy) Descriptor() ([]byte, []int) {
+	return fileDescriptor_33bf56ae47cb0799, []int{0}
+}
+
+func (m *MethodPolicy) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MethodPolicy.Unmarshal(m, b)
+}
+func (m *MethodPolicy) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MethodPolicy.Marshal(b, m, deterministic)
+}
+func (m *MethodPolicy) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MethodPolicy.Merge(m, src)
+}
+func (m *MethodPolicy) XXX_Size() int {
+	return xxx_messageInfo_MethodPolicy.Size(m)
+}
+func (m *MethodPolicy) XXX_DiscardUnknown() {
+	xxx_messageInfo_MethodPolicy.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MethodPolicy proto.InternalMessageInfo
+
+func (m *MethodPolicy) GetIdempotent() bool {
+	if m != nil && m.Idempotent != nil {
+		return *m.Idempotent
+	}
+	return false
+}
+
+func (m *MethodPolicy) GetMaxRetries() int32 {
+	if m != nil && m.MaxRetries != nil {
+		return *m.MaxRetries
+	}
+	return 0
+}
+
+func (m *MethodPolicy) GetTimeoutMs() uint32 {
+	if m != nil && m.TimeoutMs != nil {
+		return *m.TimeoutMs
+	}
+	return 0
+}
+
+func (m *MethodPolicy) GetHedge() *HedgePolicy {
+	if m != nil {
+		return m.Hedge
+	}
+	return nil
+}
+
+// HedgePolicy send another attempt of idempotent method if no response is received after delay_ms.
+type HedgePolicy struct {
+	// max_attempts including the first one.
+	MaxAttempts          *int32   `protobuf:"varint,1,opt,name=max_attempts,json=maxAttempts" json:"max_attempts,omitempty"`
+	DelayMs              *uint32  `protobuf:"varint,2,opt,name=delay_ms,json=delayMs" json:"delay_ms,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HedgePolicy) Reset()         { *m = HedgePolicy{} }
+func (m *HedgePolicy) String() string { return proto.CompactTextString(m) }
+func (*HedgePolicy) ProtoMessage()    {}
+func (*HedgePolicy) Descriptor() ([]byte, []int) {
+	return fileDescriptor_33bf56ae47cb0799, []int{1}
+}
+
+func (m *HedgePolicy) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HedgePolicy.Unmarshal(m, b)
+}
+func (m *HedgePolicy) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HedgePolicy.Marshal(b, m, deterministic)
+}
+func (m *HedgePolicy) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HedgePolicy.Merge(m, src)
+}
+func (m *HedgePolicy) XXX_Size() int {
+	return xxx_messageInfo_HedgePolicy.Size(m)
+}
+func (m *HedgePolicy) XXX_DiscardUnknown() {
+	xxx_messageInfo_HedgePolicy.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HedgePolicy proto.InternalMessageInfo
+
+func (m *HedgePolicy) GetMaxAttempts() int32 {
+	if m != nil && m.MaxAttempts != nil {
+		return *m.MaxAttempts
+	}
+	return 0
+}
+
+func (m *HedgePolicy) GetDelayMs() uint32 {
+	if m != nil && m.DelayMs != nil {
+		return *m.DelayMs
+	}
+	return 0
+}
+
+// FieldRules is validation rules of a field, checked before handler called.
+type FieldRules struct {
+	// required field must be set, or non-zero for proto3 scalar, or non-empty for repeated and map.
+	Required *bool `protobuf:"varint,1,opt,name=required" json:"required,omitempty"`
+	// min and max bound numeric value, inclusive.
+	Min *float64 `protobuf:"fixed64,2,opt,name=min" json:"min,omitempty"`
+	Max *float64 `protobuf:"fixed64,3,opt,name=max" json:"max,omitempty"`
+	// min_len and max_len bound length of string in characters, bytes, repeated and map.
+	MinLen *uint64 `protobuf:"varint,4,opt,name=min_len,json=minLen" json:"min_len,omitempty"`
+	MaxLen *uint64 `protobuf:"varint,5,opt,name=max_len,json=maxLen" json:"max_len,omitempty"`
+	// pattern is a regular expression string must match.
+	Pattern *string `protobuf:"bytes,6,opt,name=pattern" json:"pattern,omitempty"`
+	// in is the allowed values, enum values are matched by name.
+	In []string `protobuf:"bytes,7,rep,name=in" json:"in,omitempty"`
+	// email string must be a valid email address.
+	Email                *bool    `protobuf:"varint,8,opt,name=email" json:"email,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FieldRules) Reset()         { *m = FieldRules{} }
+func (m *FieldRules) String() string { return proto.CompactTextString(m) }
+func (*FieldRules) ProtoMessage()    {}
+func (*FieldRules) Descriptor() ([]byte, []int) {
+	return fileDescriptor_33bf56ae47cb0799, []int{2}
+}
+
+func (m *FieldRules) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FieldRules.Unmarshal(m, b)
+}
+func (m *FieldRules) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FieldRules.Marshal(b, m, deterministic)
+}
+func (m *FieldRules) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FieldRules.Merge(m, src)
+}
+func (m *FieldRules) XXX_Size() int {
+	return xxx_messageInfo_FieldRules.Size(m)
+}
+func (m *FieldRules) XXX_DiscardUnknown() {
+	xxx_messageInfo_FieldRules.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FieldRules proto.InternalMessageInfo
+
+func (m *FieldRules) GetRequired() bool {
+	if m != nil && m.Required != nil {
+		return *m.Required
+	}
+	return false
+}
+
+func (m *FieldRules) GetMin() float64 {
+	if m != nil && m.Min != nil {
+		return *m.Min
+	}
+	return 0
+}
+
+func (m *FieldRules) GetMax() float64 {
+	if m != nil && m.Max != nil {
+		return *m.Max
+	}
+	return 0
+}
+
+func (m *FieldRules) GetMinLen() uint64 {
+	if m != nil && m.MinLen != nil {
+		return *m.MinLen
+	}
+	return 0
+}
+
+func (m *FieldRules) GetMaxLen() uint64 {
+	if m != nil && m.MaxLen != nil {
+		return *m.MaxLen
+	}
+	return 0
+}
+
+func (m *FieldRules) GetPattern() string {
+	if m != nil && m.Pattern != nil {
+		return *m.Pattern
+	}
+	return ""
+}
+
+func (m *FieldRules) GetIn() []string {
+	if m != nil {
+		return m.In
+	}
+	return nil
+}
+
+func (m *FieldRules) GetEmail() bool {
+	if m != nil && m.Email != nil {
+		return *m.Email
+	}
+	return false
+}
+
 var E_IsIdempotent = &proto.ExtensionDesc{
 	ExtendedType:  (*descriptor.MessageOptions)(nil),
 	ExtensionType: (*bool)(nil),
@@ -39,6 +299,33 @@ var E_MaxRetries = &proto.ExtensionDesc{
 	Filename:      "resilience.proto",
 }
 
+var E_Policy = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MethodOptions)(nil),
+	ExtensionType: (*MethodPolicy)(nil),
+	Field:         63000,
+	Name:          "policy",
+	Tag:           "bytes,63000,opt,name=policy",
+	Filename:      "resilience.proto",
+}
+
+var E_FileJsonTagMode = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FileOptions)(nil),
+	ExtensionType: (*JsonTagMode)(nil),
+	Field:         61004,
+	Name:          "file_json_tag_mode",
+	Tag:           "varint,61004,opt,name=file_json_tag_mode,enum=JsonTagMode",
+	Filename:      "resilience.proto",
+}
+
+var E_MessageJsonTagMode = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.MessageOptions)(nil),
+	ExtensionType: (*JsonTagMode)(nil),
+	Field:         61003,
+	Name:          "message_json_tag_mode",
+	Tag:           "varint,61003,opt,name=message_json_tag_mode,enum=JsonTagMode",
+	Filename:      "resilience.proto",
+}
+
 var E_JsonTag = &proto.ExtensionDesc{
 	ExtendedType:  (*descriptor.FieldOptions)(nil),
 	ExtensionType: (*string)(nil),
@@ -48,38 +335,93 @@ var E_JsonTag = &proto.ExtensionDesc{
 	Filename:      "resilience.proto",
 }
 
+var E_JsonTagMode = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*JsonTagMode)(nil),
+	Field:         61001,
+	Name:          "json_tag_mode",
+	Tag:           "varint,61001,opt,name=json_tag_mode,enum=JsonTagMode",
+	Filename:      "resilience.proto",
+}
+
 var E_DefaultValue = &proto.ExtensionDesc{
 	ExtendedType:  (*descriptor.FieldOptions)(nil),
 	ExtensionType: (*string)(nil),
-	Field:         61001,
+	Field:         61002,
 	Name:          "default_value",
-	Tag:           "bytes,61001,opt,name=default_value",
+	Tag:           "bytes,61002,opt,name=default_value",
+	Filename:      "resilience.proto",
+}
+
+var E_Rules = &proto.ExtensionDesc{
+	ExtendedType:  (*descriptor.FieldOptions)(nil),
+	ExtensionType: (*FieldRules)(nil),
+	Field:         62000,
+	Name:          "rules",
+	Tag:           "bytes,62000,opt,name=rules",
 	Filename:      "resilience.proto",
 }
 
 func init() {
+	proto.RegisterEnum("JsonTagMode", JsonTagMode_name, JsonTagMode_value)
+	proto.RegisterType((*MethodPolicy)(nil), "MethodPolicy")
+	proto.RegisterType((*HedgePolicy)(nil), "HedgePolicy")
+	proto.RegisterType((*FieldRules)(nil), "FieldRules")
 	proto.RegisterExtension(E_IsIdempotent)
 	proto.RegisterExtension(E_MaxRetries)
+	proto.RegisterExtension(E_Policy)
+	proto.RegisterExtension(E_FileJsonTagMode)
+	proto.RegisterExtension(E_MessageJsonTagMode)
 	proto.RegisterExtension(E_JsonTag)
+	proto.RegisterExtension(E_JsonTagMode)
 	proto.RegisterExtension(E_DefaultValue)
+	proto.RegisterExtension(E_Rules)
 }
 
-func init() { proto.RegisterFile("resilience.proto", fileDescriptor_33bf56ae47cb0799) }
+func init() {
+	proto.RegisterFile("resilience.proto", fileDescriptor_33bf56ae47cb0799)
+}
 
 var fileDescriptor_33bf56ae47cb0799 = []byte{
-	// 224 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0xcc, 0xb1, 0x4b, 0x03, 0x31,
-	0x14, 0xc7, 0x71, 0x44, 0xc4, 0x1a, 0xaf, 0x20, 0x9d, 0x44, 0x10, 0x3b, 0x3a, 0x5d, 0xf7, 0x8c,
-	0x45, 0x0a, 0x0e, 0x22, 0x04, 0x71, 0x70, 0x09, 0xb1, 0x79, 0x8d, 0x4f, 0x72, 0x79, 0x21, 0xef,
-	0x9d, 0xf4, 0x4f, 0xd4, 0xdd, 0xd1, 0x41, 0xff, 0x13, 0xb9, 0xbb, 0xd6, 0xc5, 0xe1, 0xc6, 0x1f,
-	0xfc, 0x3e, 0x5f, 0x75, 0x56, 0x80, 0x31, 0x22, 0xa4, 0x35, 0xd4, 0xb9, 0x90, 0xd0, 0xc5, 0x3c,
-	0x10, 0x85, 0x08, 0x8b, 0x7e, 0x3d, 0xb7, 0x9b, 0x85, 0x07, 0x5e, 0x17, 0xcc, 0x42, 0x65, 0x78,
-	0xe8, 0x95, 0x9a, 0x22, 0x5b, 0xf4, 0xd0, 0x64, 0x12, 0x48, 0x32, 0xbb, 0xaa, 0x07, 0x53, 0xef,
-	0x4d, 0x7d, 0x07, 0xcc, 0x2e, 0xc0, 0x7d, 0x16, 0xa4, 0xc4, 0xe7, 0xdf, 0x9f, 0x87, 0xf3, 0x83,
-	0xeb, 0x89, 0xa9, 0x90, 0x6f, 0xff, 0x98, 0x5e, 0xaa, 0xd3, 0xc6, 0x6d, 0x6d, 0x01, 0x29, 0x08,
-	0x3c, 0x5e, 0xf9, 0xe9, 0x2b, 0x47, 0x46, 0x35, 0x6e, 0x6b, 0x06, 0xa4, 0xb5, 0x9a, 0xbc, 0x32,
-	0x25, 0x2b, 0x2e, 0xcc, 0x2e, 0xff, 0x05, 0x56, 0x08, 0xd1, 0xef, 0xf9, 0xfb, 0x57, 0xc7, 0x4f,
-	0xcc, 0x71, 0x07, 0x1e, 0x5c, 0xd0, 0x37, 0x6a, 0xea, 0x61, 0xe3, 0xda, 0x28, 0xf6, 0xcd, 0xc5,
-	0x16, 0xc6, 0x02, 0x1f, 0xbb, 0x40, 0xb5, 0x53, 0x8f, 0x1d, 0x5a, 0x56, 0x4f, 0x4a, 0x5e, 0xa8,
-	0xd8, 0xde, 0xfc, 0x06, 0x00, 0x00, 0xff, 0xff, 0xa0, 0x31, 0xf4, 0xf6, 0x50, 0x01, 0x00, 0x00,
+	// 637 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x54, 0xcd, 0x6e, 0xd3, 0x4a,
+	0x14, 0xbe, 0x6e, 0x9a, 0x26, 0x39, 0x49, 0x7a, 0xab, 0xd1, 0xbd, 0xba, 0xbe, 0x55, 0x0b, 0x21,
+	0xab, 0x40, 0x85, 0x23, 0x75, 0x69, 0x56, 0xb4, 0xa8, 0xfc, 0x46, 0xc0, 0x80, 0x90, 0x60, 0x63,
+	0x4d, 0xe2, 0x53, 0x67, 0x8a, 0x67, 0xc6, 0x78, 0xc6, 0x28, 0x7d, 0x0d, 0x56, 0x3c, 0x06, 0x4f,
+	0xc0, 0x9a, 0xbf, 0x65, 0x97, 0x2c, 0x40, 0x62, 0xc9, 0x43, 0x20, 0x8f, 0x9d, 0xe0, 0x96, 0x48,
+	0xd9, 0xcd, 0xf9, 0x8e, 0xbf, 0xef, 0x7c, 0x67, 0xce, 0x19, 0xc3, 0x56, 0x8a, 0x9a, 0xc7, 0x1c,
+	0xe5, 0x04, 0xbd, 0x24, 0x55, 0x46, 0x6d, 0xf7, 0x22, 0xa5, 0xa2, 0x18, 0x87, 0x36, 0x1a, 0x67,
+	0xc7, 0xc3, 0x10, 0xf5, 0x24, 0xe5, 0x89, 0x51, 0x69, 0xf9, 0xc5, 0xce, 0xc5, 0x2f, 0xb4, 0x49,
+	0xb3, 0x89, 0x29, 0xb2, 0xfd, 0x37, 0x0e, 0x74, 0x46, 0x68, 0xa6, 0x2a, 0x7c, 0xa4, 0x62, 0x3e,
+	0x39, 0x25, 0x97, 0x00, 0x78, 0x88, 0x22, 0x51, 0x06, 0xa5, 0x71, 0x9d, 0x9e, 0x33, 0x68, 0xd2,
+	0x0a, 0x42, 0x2e, 0x43, 0x5b, 0xb0, 0x59, 0x90, 0xa2, 0x49, 0x39, 0x6a, 0x77, 0xad, 0xe7, 0x0c,
+	0xea, 0x14, 0x04, 0x9b, 0xd1, 0x02, 0x21, 0xbb, 0x00, 0x86, 0x0b, 0x54, 0x99, 0x09, 0x84, 0x76,
+	0x6b, 0x3d, 0x67, 0xd0, 0xa5, 0xad, 0x12, 0x19, 0x69, 0xd2, 0x87, 0xfa, 0x14, 0xc3, 0x08, 0xdd,
+	0xf5, 0x9e, 0x33, 0x68, 0xef, 0x77, 0xbc, 0x3b, 0x79, 0x54, 0x14, 0xa7, 0x45, 0xaa, 0x7f, 0x1f,
+	0xda, 0x15, 0x94, 0x5c, 0x81, 0x4e, 0x5e, 0x92, 0x19, 0x83, 0x22, 0x31, 0xda, 0x9a, 0xaa, 0xd3,
+	0xdc, 0xc6, 0xcd, 0x12, 0x22, 0xff, 0x43, 0x33, 0xc4, 0x98, 0x9d, 0x06, 0xa2, 0xb0, 0xd4, 0xa5,
+	0x0d, 0x1b, 0x8f, 0x74, 0xff, 0xbd, 0x03, 0x70, 0xc4, 0x31, 0x0e, 0x69, 0x16, 0xa3, 0x26, 0xdb,
+	0xd0, 0x4c, 0xf1, 0x55, 0xc6, 0x53, 0x0c, 0xcb, 0xee, 0x16, 0x31, 0xd9, 0x82, 0x9a, 0xe0, 0xd2,
+	0x0a, 0x38, 0x34, 0x3f, 0x5a, 0x84, 0xcd, 0xdc, 0x5a, 0x89, 0xb0, 0x19, 0xf9, 0x0f, 0x1a, 0x82,
+	0xcb, 0x20, 0x46, 0x69, 0x3b, 0x58, 0xa7, 0x1b, 0x82, 0xcb, 0x07, 0x28, 0x6d, 0x82, 0xcd, 0x6c,
+	0xa2, 0x5e, 0x26, 0xd8, 0x2c, 0x4f, 0xb8, 0xd0, 0x48, 0x72, 0xef, 0xa9, 0x74, 0x37, 0x7a, 0xce,
+	0xa0, 0x45, 0xe7, 0x21, 0xd9, 0x84, 0x35, 0x2e, 0xdd, 0x46, 0xaf, 0x36, 0x68, 0xd1, 0x35, 0x2e,
+	0xc9, 0x3f, 0x50, 0x47, 0xc1, 0x78, 0xec, 0x36, 0xad, 0xb1, 0x22, 0xb8, 0xb6, 0x07, 0xed, 0x7b,
+	0x5a, 0xc9, 0xa7, 0x2c, 0x1a, 0xa9, 0x10, 0x49, 0x17, 0x5a, 0x87, 0x4c, 0x60, 0x7c, 0xc8, 0x34,
+	0x6e, 0xfd, 0x95, 0x87, 0x4f, 0x24, 0x7b, 0x89, 0x36, 0x74, 0xfc, 0x23, 0xe8, 0x72, 0x1d, 0x54,
+	0xe7, 0xe5, 0x15, 0xf3, 0xf7, 0xe6, 0xf3, 0xf7, 0x46, 0xa8, 0x35, 0x8b, 0xf0, 0x61, 0x62, 0xb8,
+	0x92, 0xda, 0xfd, 0x76, 0x56, 0xb3, 0xd5, 0x3a, 0x5c, 0xdf, 0x5d, 0xd0, 0xfc, 0x83, 0x73, 0x63,
+	0x5e, 0xad, 0xf2, 0xfd, 0xac, 0x76, 0x71, 0x13, 0xfc, 0xdb, 0xb0, 0x91, 0x94, 0x4b, 0xb5, 0x84,
+	0x9e, 0xef, 0xdc, 0x9c, 0xfd, 0xf6, 0x67, 0xcd, 0x6e, 0x43, 0xd7, 0xab, 0xee, 0x22, 0x2d, 0xe9,
+	0xfe, 0x73, 0x20, 0xc7, 0x3c, 0xc6, 0xe0, 0x44, 0x2b, 0x19, 0x18, 0x16, 0x05, 0x22, 0xbf, 0x88,
+	0x9d, 0x3f, 0x44, 0x8f, 0x78, 0xbc, 0x30, 0xf4, 0xe5, 0x6b, 0x2e, 0xb9, 0xb9, 0xdf, 0xf1, 0x2a,
+	0x97, 0x47, 0xff, 0xce, 0x75, 0x2a, 0x80, 0x3f, 0x86, 0x7f, 0x45, 0xd1, 0xc9, 0x05, 0xf5, 0x95,
+	0x1d, 0x7f, 0x5e, 0x5a, 0x80, 0x94, 0x6a, 0xd5, 0x1a, 0x3e, 0x34, 0xe7, 0xda, 0x64, 0x77, 0x89,
+	0x69, 0x8c, 0x17, 0x17, 0xf1, 0xc1, 0x8a, 0xb6, 0x68, 0xe3, 0xa4, 0xe0, 0xfb, 0x8f, 0xa1, 0x7b,
+	0xde, 0xd7, 0x0a, 0x81, 0x8f, 0x4b, 0x5d, 0xb5, 0x4f, 0x2a, 0x76, 0x6e, 0x41, 0x37, 0xc4, 0x63,
+	0x96, 0xc5, 0x26, 0x78, 0xcd, 0xe2, 0x6c, 0xa5, 0xe4, 0xa7, 0xd2, 0x53, 0xa7, 0x64, 0x3d, 0xcb,
+	0x49, 0xfe, 0x01, 0xd4, 0x53, 0xfb, 0xa0, 0x56, 0xb0, 0xdf, 0xfd, 0x28, 0x46, 0xdb, 0xf6, 0x7e,
+	0x3f, 0x42, 0x5a, 0x50, 0x0f, 0xf6, 0x5e, 0x5c, 0x8d, 0xb8, 0x99, 0x66, 0x63, 0x6f, 0xa2, 0xc4,
+	0x30, 0x52, 0xd7, 0xc7, 0x8a, 0xa5, 0xe1, 0xd0, 0x4c, 0x55, 0x5a, 0xfc, 0xae, 0x6e, 0xe4, 0xc7,
+	0xc0, 0x1e, 0x7f, 0x0d, 0x00, 0xf0, 0x4e, 0x59, 0x3e, 0xfc, 0x04, 0x00, 0x00,
 }